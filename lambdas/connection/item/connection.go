@@ -5,6 +5,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
 	"github.com/ojkelly/linnet/lambdas/util/database"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
@@ -23,6 +24,7 @@ func Get(
 	defer segment.Close(err)
 
 	var edges []string
+	var edgeKeys []database.EdgeKey
 	var nodes []types.Node
 
 	var rootNodeID string
@@ -53,7 +55,7 @@ func Get(
 	tableName := event.DataSource.TableName
 
 	// Query for edges
-	edges, _, err = database.QueryForEdges(
+	edgeKeys, _, err = database.QueryForEdgePage(
 		ctx,
 		dynamo,
 		tableName,
@@ -61,10 +63,15 @@ func Get(
 		edge,
 		limit,
 		"",
+		event.Context.Arguments.IncludeDeleted,
+		false,
 	)
 	if err != nil {
 		errors = append(errors, err)
 	}
+	for _, edgeKey := range edgeKeys {
+		edges = append(edges, edgeKey.ID)
+	}
 
 	// hydrate
 	nodes, err = database.HydrateNodes(
@@ -72,11 +79,28 @@ func Get(
 		dynamo,
 		tableName,
 		edges,
+		event.Context.Arguments.IncludeDeleted,
 	)
 	if err != nil {
 		errors = append(errors, err)
 	}
 
+	// A Relay connection has the node as its only edge, with no pages
+	// before or after it
+	if event.Relay {
+		var cursors []string
+		if len(nodes) == 1 && len(edgeKeys) == 1 {
+			cursors = []string{edgeKeys[0].Cursor}
+		} else {
+			nodes = nil
+		}
+		rootNode, err = util.RelayConnection(nodes, cursors, false, false)
+		if err != nil {
+			errors = append(errors, err)
+		}
+		return rootNode, errors
+	}
+
 	if len(nodes) == 1 {
 		rootNode = nodes[0]
 	}
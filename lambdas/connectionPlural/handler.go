@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
@@ -29,7 +30,7 @@ func handler(ctx context.Context, evt json.RawMessage) (response []byte, err err
 
 	// Unmarshall the Event
 	var event types.ConnectionPluralLambdaEvent
-	err = json.Unmarshal(evt, &event)
+	err = util.UnmarshalEvent(evt, &event)
 	if err != nil {
 		return
 	}
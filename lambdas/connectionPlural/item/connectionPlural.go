@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
 	"github.com/ojkelly/linnet/lambdas/util/database"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
@@ -26,29 +27,65 @@ func Get(
 	var edges []string
 	var nodes []types.Node
 
-	var rootNodeID string
-	var sortKey string
+	// The cursor of each node, and whether there are nodes before and
+	// after the page
+	var cursors []string
+	var hasPreviousPage, hasNextPage bool
 
-	var limit int64
-	limit = 10
+	var rootNodeID string
 
 	var edge types.Edge
 	var lastEvaluatedKey string
 
-	// The number of nodes found
-	var count string
+	// The number of nodes that match the filter, and the number there are
+	// before filtering
+	var count, scannedCount int
 
-	var cursor string
-
-	var haveFilter, haveCursor bool
+	var haveFilter, haveOrderBy bool
 
 	if event.Context.Arguments.Filter != nil {
 		haveFilter = true
 	}
 
-	if event.Context.Arguments.Cursor != "" {
-		haveCursor = true
-		cursor = event.Context.Arguments.Cursor
+	if len(event.Context.Arguments.OrderBy) > 0 {
+		haveOrderBy = true
+	}
+
+	limit, cursor, backward, err := pageArguments(event.Context.Arguments)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+	haveCursor := cursor != ""
+
+	// Check the order and cursor of a filtered or ordered connection before
+	// loading any nodes
+	var order nodeOrder
+	var query string
+	var position types.Node
+	if haveFilter || haveOrderBy {
+		order, err = compileOrder(event.Context.Arguments.OrderBy)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+
+		query, err = queryKey(
+			event.Context.Arguments.Filter,
+			event.Context.Arguments.OrderBy,
+		)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+
+		if haveCursor {
+			position, err = decodeCursor(cursor, query)
+			if err != nil {
+				errors = append(errors, err)
+				return
+			}
+		}
 	}
 
 	if event.EdgeTypes != nil && len(event.EdgeTypes) >= 1 {
@@ -69,37 +106,35 @@ func Get(
 		return
 	}
 
-	if event.Context.Arguments.Limit != 0 {
-		limit = event.Context.Arguments.Limit
-	}
-
 	tableName := event.DataSource.TableName
 
 	fmt.Println("rootNodeID: ", rootNodeID)
 	fmt.Println("haveFilter: ", haveFilter)
 	fmt.Println("haveCursor: ", haveCursor)
-	fmt.Println("sortKey: ", sortKey)
 	fmt.Println("limit: ", limit)
 
-	// Are we using a filter?
-	// This is more expensive as we need to load all the nodes in order to filter them
-	if haveFilter {
+	// Are we using a filter or an order?
+	// This is more expensive as we need to load all the nodes in order to filter
+	// or sort them
+	if haveFilter || haveOrderBy {
 		// We default to a limit of 1000 edges, but will page in order to get all edges
 		var queryLimit int64
 		queryLimit = 1000
 
-		// Query for edges
-		edges, _, err = database.QueryForAllEdges(
+		// Query for edges, always from the first, as the cursor is a position
+		// in the sorted nodes
+		edges, err = database.QueryForAllEdges(
 			ctx,
 			dynamo,
 			tableName,
 			rootNodeID,
 			edge,
 			queryLimit,
-			cursor,
+			event.Context.Arguments.IncludeDeleted,
 		)
 		if err != nil {
 			errors = append(errors, err)
+			return
 		}
 
 		// Hydrate the nodes
@@ -110,36 +145,63 @@ func Get(
 			dynamo,
 			tableName,
 			edges,
+			event.Context.Arguments.IncludeDeleted,
 		)
 		if err != nil {
 			errors = append(errors, err)
+			return
 		}
+		scannedCount = len(hydratedNodes)
 
-		// Sort the nodes
-		sortedNodes, err := SortNodes(
+		// Filter the nodes
+		filteredNodes, err := FilterNodes(
 			ctx,
+			event.Context.Arguments.Filter,
 			hydratedNodes,
-			sortKey,
 		)
 		if err != nil {
 			errors = append(errors, err)
+			return
 		}
+		count = len(filteredNodes)
 
-		// Filter the nodes
-		nodes, err = FilterNodes(
+		// Sort the nodes, by id when there is no orderBy so the order is the
+		// same each time
+		sortedNodes, err := SortNodes(
 			ctx,
-			event.Context.Arguments.Filter,
-			sortedNodes,
+			filteredNodes,
+			event.Context.Arguments.OrderBy,
 		)
-
-		// page to limit
-
 		if err != nil {
 			errors = append(errors, err)
+			return
+		}
+
+		// Page to limit, starting from the cursor
+		nodes, hasPreviousPage, hasNextPage = pageNodes(
+			sortedNodes,
+			order,
+			position,
+			backward,
+			limit,
+		)
+
+		// The cursor of each node is its position in the sorted nodes
+		cursors = make([]string, len(nodes))
+		for i, node := range nodes {
+			cursors[i], err = encodeCursor(query, order, node)
+			if err != nil {
+				errors = append(errors, err)
+				return
+			}
+		}
+		if hasNextPage && len(cursors) > 0 {
+			lastEvaluatedKey = cursors[len(cursors)-1]
 		}
 	} else { // No filter
 		// Query for edges
-		edges, lastEvaluatedKey, err = database.QueryForEdges(
+		var edgeKeys []database.EdgeKey
+		edgeKeys, lastEvaluatedKey, err = database.QueryForEdgePage(
 			ctx,
 			dynamo,
 			tableName,
@@ -147,26 +209,60 @@ func Get(
 			edge,
 			limit,
 			cursor,
+			event.Context.Arguments.IncludeDeleted,
+			backward,
 		)
 		if err != nil {
 			errors = append(errors, err)
 		}
+		for _, edgeKey := range edgeKeys {
+			edges = append(edges, edgeKey.ID)
+		}
 
 		// hydrate
-		nodes, err = database.HydrateNodes(
+		var hydratedNodes []types.Node
+		hydratedNodes, err = database.HydrateNodes(
 			ctx,
 			dynamo,
 			tableName,
 			edges,
+			event.Context.Arguments.IncludeDeleted,
 		)
 		if err != nil {
 			errors = append(errors, err)
 		}
+		nodes, cursors = inEdgeOrder(edgeKeys, hydratedNodes)
+
+		// A page read from a cursor has the cursor's node on the side it was
+		// read from, and a page that ends at the limit may have more past it
+		if backward {
+			hasPreviousPage = lastEvaluatedKey != ""
+			hasNextPage = haveCursor
+		} else {
+			hasPreviousPage = haveCursor
+			hasNextPage = lastEvaluatedKey != ""
+		}
+
+		// Without a filter every node on the page matches
+		count = len(nodes)
+		scannedCount = len(nodes)
+	}
+
+	if event.Relay {
+		data, err = util.RelayConnection(nodes, cursors, hasPreviousPage, hasNextPage)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+		data["count"] = count
+		data["scannedCount"] = scannedCount
+		return data, errors
 	}
 
 	data = types.Node{
-		"edges": nodes,
-		"count": count,
+		"edges":        nodes,
+		"count":        count,
+		"scannedCount": scannedCount,
 	}
 
 	if lastEvaluatedKey == "" {
@@ -178,17 +274,72 @@ func Get(
 	return data, errors
 }
 
-// SortNodes -
-func SortNodes(
-	ctx context.Context,
-	nodes []types.Node,
-	sortKey string,
+// pageArguments gets the size of the page and the cursor to read from.
+// first and after read forward, last and before read backward, otherwise
+// limit and cursor read forward.
+func pageArguments(
+	arguments types.ConnectionPluralLambdaArguments,
 ) (
-	nodesSorted []types.Node,
+	limit int64,
+	cursor string,
+	backward bool,
 	err error,
 ) {
-	ctx, segment := xray.BeginSubsegment(ctx, "SortNodes")
-	defer segment.Close(err)
-	nodesSorted = nodes
-	return nodesSorted, err
+	limit = 10
+	if arguments.Limit != 0 {
+		limit = arguments.Limit
+	}
+	cursor = arguments.Cursor
+
+	forward := arguments.First != 0 || arguments.After != ""
+	backward = arguments.Last != 0 || arguments.Before != ""
+	if forward && backward {
+		return 0, "", false, fmt.Errorf(
+			"Cannot page, use first and after to read forward, or last and before to read backward",
+		)
+	}
+
+	if arguments.First < 0 || arguments.Last < 0 {
+		return 0, "", false, fmt.Errorf("Cannot page, first and last must be 1 or more")
+	}
+
+	switch {
+	case forward:
+		if arguments.First != 0 {
+			limit = arguments.First
+		}
+		cursor = arguments.After
+	case backward:
+		if arguments.Last != 0 {
+			limit = arguments.Last
+		}
+		cursor = arguments.Before
+	}
+	return
+}
+
+// inEdgeOrder puts the hydrated nodes back in the order of their edges,
+// with the cursor of each. Edges to nodes that were not found are left
+// out.
+func inEdgeOrder(
+	edgeKeys []database.EdgeKey,
+	hydratedNodes []types.Node,
+) (
+	nodes []types.Node,
+	cursors []string,
+) {
+	nodesByID := make(map[string]types.Node)
+	for _, node := range hydratedNodes {
+		if id, ok := node["id"].(string); ok {
+			nodesByID[id] = node
+		}
+	}
+
+	for _, edgeKey := range edgeKeys {
+		if node, ok := nodesByID[edgeKey.ID]; ok {
+			nodes = append(nodes, node)
+			cursors = append(cursors, edgeKey.Cursor)
+		}
+	}
+	return
 }
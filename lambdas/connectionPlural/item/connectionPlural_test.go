@@ -0,0 +1,91 @@
+package item
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageArguments(t *testing.T) {
+	tests := []struct {
+		arguments types.ConnectionPluralLambdaArguments
+		limit     int64
+		cursor    string
+		backward  bool
+		err       string
+	}{
+		{
+			arguments: types.ConnectionPluralLambdaArguments{},
+			limit:     10,
+		},
+		{
+			arguments: types.ConnectionPluralLambdaArguments{Limit: 5, Cursor: "abc"},
+			limit:     5,
+			cursor:    "abc",
+		},
+		{
+			arguments: types.ConnectionPluralLambdaArguments{First: 3, After: "abc"},
+			limit:     3,
+			cursor:    "abc",
+		},
+		{
+			arguments: types.ConnectionPluralLambdaArguments{Last: 2, Before: "abc"},
+			limit:     2,
+			cursor:    "abc",
+			backward:  true,
+		},
+		{
+			// last without before reads from the end
+			arguments: types.ConnectionPluralLambdaArguments{Last: 2},
+			limit:     2,
+			backward:  true,
+		},
+		{
+			arguments: types.ConnectionPluralLambdaArguments{First: 2, Before: "abc"},
+			err:       "Cannot page, use first and after to read forward, or last and before to read backward",
+		},
+		{
+			arguments: types.ConnectionPluralLambdaArguments{First: -1},
+			err:       "Cannot page, first and last must be 1 or more",
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		limit, cursor, backward, err := pageArguments(test.arguments)
+		if test.err != "" {
+			assert.EqualError(err, test.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.limit, limit, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.cursor, cursor, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.backward, backward, fmt.Sprintf("Test %d", i))
+	}
+}
+
+func TestInEdgeOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes, cursors := inEdgeOrder(
+		[]database.EdgeKey{
+			database.EdgeKey{ID: "order-1", Cursor: "cursor-1"},
+			database.EdgeKey{ID: "order-2", Cursor: "cursor-2"},
+			database.EdgeKey{ID: "order-3", Cursor: "cursor-3"},
+		},
+		// order-2 was not found, and BatchGetItem returns nodes in any order
+		[]types.Node{
+			types.Node{"id": "order-3"},
+			types.Node{"id": "order-1"},
+		},
+	)
+	assert.Equal([]types.Node{
+		types.Node{"id": "order-1"},
+		types.Node{"id": "order-3"},
+	}, nodes)
+	assert.Equal([]string{"cursor-1", "cursor-3"}, cursors)
+}
@@ -0,0 +1,138 @@
+package item
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// A filtered or ordered connection is paged after it is sorted, so its
+// cursor cannot be a DynamoDB key. Instead it holds the value of each sort
+// key on the last Node of the page, and the next page starts at the first
+// Node that sorts after them. A Node added or removed between pages does
+// not move the page boundary.
+
+// pageCursor is the position after the last Node of a page
+type pageCursor struct {
+	// Query is a hash of the filter and orderBy the page was read with
+	Query string `json:"query"`
+	// After is the value of each sort key on the last Node of the page
+	After map[string]interface{} `json:"after"`
+}
+
+// queryKey is a hash of filter and orderBy, so a cursor is only used with
+// the filter and orderBy it was made with
+func queryKey(
+	filter types.Filter,
+	orderBy []types.OrderBy,
+) (
+	key string,
+	err error,
+) {
+	query, err := json.Marshal(map[string]interface{}{
+		"filter":  filter,
+		"orderBy": orderBy,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(query)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// encodeCursor for the page that ends with node
+func encodeCursor(
+	query string,
+	order nodeOrder,
+	node types.Node,
+) (
+	cursor string,
+	err error,
+) {
+	after := make(map[string]interface{})
+	for _, key := range order {
+		after[key.field] = node[key.field]
+	}
+
+	cursorJSON, err := json.Marshal(pageCursor{
+		Query: query,
+		After: after,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(cursorJSON), nil
+}
+
+// decodeCursor into the sort key values of the last Node of the previous
+// page. Numbers are kept as a types.Number, so they compare exactly.
+func decodeCursor(
+	cursor string,
+	query string,
+) (
+	after types.Node,
+	err error,
+) {
+	cursorJSON, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read cursor, it is not a cursor from this connection")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(cursorJSON))
+	decoder.UseNumber()
+
+	var c pageCursor
+	if err = decoder.Decode(&c); err != nil || c.After == nil {
+		return nil, fmt.Errorf("Cannot read cursor, it is not a cursor from this connection")
+	}
+	if c.Query != query {
+		return nil, fmt.Errorf("Cannot read cursor, it was made with a different filter or orderBy")
+	}
+
+	types.ToNumbers(c.After)
+	return types.Node(c.After), nil
+}
+
+// pageNodes returns up to limit of the sorted nodes after the position,
+// or before it when reading backward, and whether there are nodes before
+// and after the page
+func pageNodes(
+	nodes []types.Node,
+	order nodeOrder,
+	position types.Node,
+	backward bool,
+	limit int64,
+) (
+	page []types.Node,
+	hasPreviousPage bool,
+	hasNextPage bool,
+) {
+	start := 0
+	end := len(nodes)
+	if position != nil {
+		if backward {
+			end = sort.Search(len(nodes), func(i int) bool {
+				return order.compare(nodes[i], position) >= 0
+			})
+		} else {
+			start = sort.Search(len(nodes), func(i int) bool {
+				return order.compare(nodes[i], position) > 0
+			})
+		}
+	}
+
+	if limit > 0 && int64(end-start) > limit {
+		if backward {
+			start = end - int(limit)
+		} else {
+			end = start + int(limit)
+		}
+	}
+	return nodes[start:end], start > 0, end < len(nodes)
+}
@@ -0,0 +1,178 @@
+package item
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageNodes(t *testing.T) {
+	nodes := []types.Node{
+		types.Node{"id": "order-1", "total": types.Number("30.5")},
+		types.Node{"id": "order-2", "total": types.Number("120")},
+		types.Node{"id": "order-3", "total": types.Number("30.5")},
+		types.Node{"id": "order-4", "total": nil},
+		types.Node{"id": "order-5", "total": types.Number("75")},
+	}
+	orderBy := []types.OrderBy{
+		types.OrderBy{Field: "total", Direction: "DESC"},
+	}
+
+	tests := []struct {
+		limit int64
+		// The ids on each page, read with the cursor from the page before
+		pages [][]string
+	}{
+		{
+			limit: 2,
+			pages: [][]string{
+				[]string{"order-4", "order-2"},
+				[]string{"order-5", "order-1"},
+				[]string{"order-3"},
+			},
+		},
+		{
+			limit: 5,
+			pages: [][]string{
+				[]string{"order-4", "order-2", "order-5", "order-1", "order-3"},
+			},
+		},
+		{
+			limit: 4,
+			pages: [][]string{
+				[]string{"order-4", "order-2", "order-5", "order-1"},
+				[]string{"order-3"},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		order, err := compileOrder(orderBy)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		query, err := queryKey(nil, orderBy)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+
+		sorted := order.sort(nodes)
+
+		var cursor string
+		for p, pageIDs := range test.pages {
+			var after types.Node
+			if cursor != "" {
+				after, err = decodeCursor(cursor, query)
+				assert.NoError(err, fmt.Sprintf("Test %d page %d", i, p))
+			}
+
+			page, hasPreviousPage, more := pageNodes(sorted, order, after, false, test.limit)
+			assert.Equal(p > 0, hasPreviousPage, fmt.Sprintf("Test %d page %d", i, p))
+
+			var ids []string
+			for _, node := range page {
+				ids = append(ids, node["id"].(string))
+			}
+			assert.Equal(pageIDs, ids, fmt.Sprintf("Test %d page %d", i, p))
+			assert.Equal(p < len(test.pages)-1, more, fmt.Sprintf("Test %d page %d", i, p))
+
+			if more {
+				cursor, err = encodeCursor(query, order, page[len(page)-1])
+				assert.NoError(err, fmt.Sprintf("Test %d page %d", i, p))
+			}
+		}
+	}
+}
+
+func TestPageNodesAfterChange(t *testing.T) {
+	assert := assert.New(t)
+
+	order, _ := compileOrder(nil)
+	query, _ := queryKey(nil, nil)
+
+	cursor, err := encodeCursor(query, order, types.Node{"id": "order-2"})
+	assert.NoError(err)
+	after, err := decodeCursor(cursor, query)
+	assert.NoError(err)
+
+	// order-2 was removed, and order-0 added, since the first page was read
+	nodes := []types.Node{
+		types.Node{"id": "order-0"},
+		types.Node{"id": "order-1"},
+		types.Node{"id": "order-3"},
+		types.Node{"id": "order-4"},
+	}
+	page, _, more := pageNodes(nodes, order, after, false, 2)
+	assert.Equal([]types.Node{
+		types.Node{"id": "order-3"},
+		types.Node{"id": "order-4"},
+	}, page)
+	assert.False(more)
+
+	// Reading backward from the same cursor
+	page, hasPreviousPage, hasNextPage := pageNodes(nodes, order, after, true, 1)
+	assert.Equal([]types.Node{
+		types.Node{"id": "order-1"},
+	}, page)
+	assert.True(hasPreviousPage)
+	assert.True(hasNextPage)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	orderBy := []types.OrderBy{types.OrderBy{Field: "total"}}
+	query, _ := queryKey(nil, orderBy)
+	order, _ := compileOrder(orderBy)
+	cursor, _ := encodeCursor(query, order, types.Node{
+		"id":    "order-1",
+		"total": types.Number("9007199254740993"),
+	})
+
+	otherQuery, _ := queryKey(types.Filter{
+		"total": types.FilterConfigValue{"greaterThan": types.Number("10")},
+	}, orderBy)
+
+	tests := []struct {
+		cursor string
+		query  string
+		after  types.Node
+		err    string
+	}{
+		{
+			// Numbers are kept exactly
+			cursor: cursor,
+			query:  query,
+			after: types.Node{
+				"id":    "order-1",
+				"total": types.Number("9007199254740993"),
+			},
+		},
+		{
+			cursor: cursor,
+			query:  otherQuery,
+			err:    "Cannot read cursor, it was made with a different filter or orderBy",
+		},
+		{
+			// A cursor from a connection without a filter or orderBy
+			cursor: "eyJpZCI6ImN1c3RvbWVyLTEifQ==",
+			query:  query,
+			err:    "Cannot read cursor, it is not a cursor from this connection",
+		},
+		{
+			cursor: "not a cursor",
+			query:  query,
+			err:    "Cannot read cursor, it is not a cursor from this connection",
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		after, err := decodeCursor(test.cursor, test.query)
+		if test.err != "" {
+			assert.EqualError(err, test.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.after, after, fmt.Sprintf("Test %d", i))
+	}
+}
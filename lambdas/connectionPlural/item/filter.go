@@ -3,18 +3,75 @@ package item
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"reflect"
+	"regexp"
 	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
-// FilterNodes -
+// A filter selects Nodes by the values of their fields. Each key of a
+// filter is a field with the comparisons its value must pass, or one of
+//
+//   AND  a list of filters that must all match
+//   OR   a list of filters where at least one must match
+//   NOT  a filter that must not match
+//
+// Every key of a filter, and every comparison on a field, must pass for a
+// Node to match. An empty filter matches every Node.
+//
+// The comparisons are
+//
+//   equalTo, notEqualTo                 strings, numbers and booleans
+//   lessThan, lessThanOrEqualTo,
+//   greaterThan, greaterThanOrEqualTo   strings, numbers and timestamps
+//   between                             [low, high], including both
+//   in, notIn                           a list of values
+//   contains, notContains               part of a string, or an item of a list
+//   beginsWith, endsWith                strings
+//   matches                             a regular expression, in RE2 syntax
+//   isNull                              true for a field that is missing or
+//                                       null, false for one that is set
+//   exists                              the opposite of isNull
+//
+// caseInsensitive: true compares strings on the field without case.
+//
+// A field that is missing or null only passes isNull: true and
+// exists: false, every other comparison fails, as it would in a DynamoDB
+// condition. Values of different types are never equal and cannot be
+// ordered, except for a timestamp string and a number of seconds since
+// 1970. Two timestamp strings are compared as times, so their time zones
+// can differ.
+
+// filterOperators take one value
+var filterOperators = map[string]bool{
+	"equalTo":              true,
+	"notEqualTo":           true,
+	"lessThan":             true,
+	"lessThanOrEqualTo":    true,
+	"greaterThan":          true,
+	"greaterThanOrEqualTo": true,
+	"contains":             true,
+	"notContains":          true,
+	"beginsWith":           true,
+	"endsWith":             true,
+}
+
+// filterListOperators take a list of values
+var filterListOperators = map[string]bool{
+	"between": true,
+	"in":      true,
+	"notIn":   true,
+}
+
+// FilterNodes returns the nodes that match filter, in the order they
+// were passed
 func FilterNodes(
 	ctx context.Context,
-	filterConfig map[string]types.FilterConfigValue,
+	filter types.Filter,
 	nodes []types.Node,
 ) (
 	nodesFiltered []types.Node,
@@ -23,386 +80,492 @@ func FilterNodes(
 	ctx, segment := xray.BeginSubsegment(ctx, "FilterNodes")
 	defer segment.Close(err)
 
-	// check each node passes the filter condition
-	for _, node := range nodes {
-		nodeValid := false
-
-		// Run the filter over every field on the node
-		for fieldKey, fieldValue := range node {
-			if fieldKey != "" &&
-				fieldValue != nil {
-
-				// Run only on the fields with criteria
-				for filterConfigKey, filterConfigValue := range filterConfig {
-					if fieldKey == filterConfigKey {
-						fmt.Println("fieldKey, filterConfigKey, filterConfigValue", fieldKey, filterConfigKey, filterConfigValue)
-						fmt.Println("fieldValue type", reflect.TypeOf(fieldValue))
-
-						switch fieldValue.(type) {
-						case bool:
-							var notEqualTo *bool
-							var equalTo *bool
-
-							if filterConfigValue["notEqualTo"] != nil {
-								notEqualTo = aws.Bool(filterConfigValue["notEqualTo"].(bool))
-							}
-							if filterConfigValue["equalTo"] != nil {
-								equalTo = aws.Bool(filterConfigValue["equalTo"].(bool))
-							}
-
-							nodeValid = filterBoolean(
-								fieldValue.(bool),
-								notEqualTo,
-								equalTo,
-							)
-						case string:
-							var notEqualTo *string
-							var equalTo *string
-							var lessThanOrEqualTo *string
-							var lessThan *string
-							var greaterThanOrEqualTo *string
-							var greaterThan *string
-							var contains *string
-							var notContains *string
-							var beginsWith *string
-							var endsWith *string
-
-							if filterConfigValue["notEqualTo"] != nil {
-								notEqualTo = aws.String(filterConfigValue["notEqualTo"].(string))
-							}
-							if filterConfigValue["equalTo"] != nil {
-								equalTo = aws.String(filterConfigValue["equalTo"].(string))
-							}
-							if filterConfigValue["lessThanOrEqualTo"] != nil {
-								lessThanOrEqualTo = aws.String(filterConfigValue["lessThanOrEqualTo"].(string))
-							}
-							if filterConfigValue["lessThan"] != nil {
-								lessThan = aws.String(filterConfigValue["lessThan"].(string))
-							}
-							if filterConfigValue["greaterThanOrEqualTo"] != nil {
-								greaterThanOrEqualTo = aws.String(filterConfigValue["greaterThanOrEqualTo"].(string))
-							}
-							if filterConfigValue["greaterThan"] != nil {
-								greaterThan = aws.String(filterConfigValue["greaterThan"].(string))
-							}
-							if filterConfigValue["contains"] != nil {
-								contains = aws.String(filterConfigValue["contains"].(string))
-							}
-							if filterConfigValue["notContains"] != nil {
-								notContains = aws.String(filterConfigValue["notContains"].(string))
-							}
-							if filterConfigValue["beginsWith"] != nil {
-								beginsWith = aws.String(filterConfigValue["beginsWith"].(string))
-							}
-							if filterConfigValue["endsWith"] != nil {
-								endsWith = aws.String(filterConfigValue["endsWith"].(string))
-							}
-
-							nodeValid = filterString(
-								fieldValue.(string),
-								notEqualTo,
-								equalTo,
-								lessThanOrEqualTo,
-								lessThan,
-								greaterThanOrEqualTo,
-								greaterThan,
-								contains,
-								notContains,
-								beginsWith,
-								endsWith,
-							)
-
-						case int:
-							var notEqualTo *int
-							var equalTo *int
-							var lessThanOrEqualTo *int
-							var lessThan *int
-							var greaterThanOrEqualTo *int
-							var greaterThan *int
-							var contains *int
-							var notContains *int
-							var between []*int
-
-							if filterConfigValue["notEqualTo"] != nil {
-								notEqualTo = aws.Int(filterConfigValue["notEqualTo"].(int))
-							}
-							if filterConfigValue["equalTo"] != nil {
-								equalTo = aws.Int(filterConfigValue["equalTo"].(int))
-							}
-							if filterConfigValue["lessThanOrEqualTo"] != nil {
-								lessThanOrEqualTo = aws.Int(filterConfigValue["lessThanOrEqualTo"].(int))
-							}
-							if filterConfigValue["lessThan"] != nil {
-								lessThan = aws.Int(filterConfigValue["lessThan"].(int))
-							}
-							if filterConfigValue["greaterThanOrEqualTo"] != nil {
-								greaterThanOrEqualTo = aws.Int(filterConfigValue["greaterThanOrEqualTo"].(int))
-							}
-							if filterConfigValue["greaterThan"] != nil {
-								greaterThan = aws.Int(filterConfigValue["greaterThan"].(int))
-							}
-							if filterConfigValue["contains"] != nil {
-								contains = aws.Int(filterConfigValue["contains"].(int))
-							}
-							if filterConfigValue["notContains"] != nil {
-								notContains = aws.Int(filterConfigValue["notContains"].(int))
-							}
-							if filterConfigValue["between"] != nil {
-								between = aws.IntSlice(filterConfigValue["between"].([]int))
-							}
-
-							nodeValid = filterInt(
-								fieldValue.(int),
-								notEqualTo,
-								equalTo,
-								lessThanOrEqualTo,
-								lessThan,
-								greaterThanOrEqualTo,
-								greaterThan,
-								contains,
-								notContains,
-								between,
-							)
-
-						case float64:
-							var notEqualTo *float64
-							var equalTo *float64
-							var lessThanOrEqualTo *float64
-							var lessThan *float64
-							var greaterThanOrEqualTo *float64
-							var greaterThan *float64
-							var contains *float64
-							var notContains *float64
-							var between []*float64
-
-							if filterConfigValue["notEqualTo"] != nil {
-								notEqualTo = aws.Float64(filterConfigValue["notEqualTo"].(float64))
-							}
-							if filterConfigValue["equalTo"] != nil {
-								equalTo = aws.Float64(filterConfigValue["equalTo"].(float64))
-							}
-							if filterConfigValue["lessThanOrEqualTo"] != nil {
-								lessThanOrEqualTo = aws.Float64(filterConfigValue["lessThanOrEqualTo"].(float64))
-							}
-							if filterConfigValue["lessThan"] != nil {
-								lessThan = aws.Float64(filterConfigValue["lessThan"].(float64))
-							}
-							if filterConfigValue["greaterThanOrEqualTo"] != nil {
-								greaterThanOrEqualTo = aws.Float64(filterConfigValue["greaterThanOrEqualTo"].(float64))
-							}
-							if filterConfigValue["greaterThan"] != nil {
-								greaterThan = aws.Float64(filterConfigValue["greaterThan"].(float64))
-							}
-							if filterConfigValue["contains"] != nil {
-								contains = aws.Float64(filterConfigValue["contains"].(float64))
-							}
-							if filterConfigValue["notContains"] != nil {
-								notContains = aws.Float64(filterConfigValue["notContains"].(float64))
-							}
-							if filterConfigValue["between"] != nil {
-								between = aws.Float64Slice(filterConfigValue["between"].([]float64))
-							}
-
-							nodeValid = filterFloat64(
-								fieldValue.(float64),
-								notEqualTo,
-								equalTo,
-								lessThanOrEqualTo,
-								lessThan,
-								greaterThanOrEqualTo,
-								greaterThan,
-								contains,
-								notContains,
-								between,
-							)
-
-						}
-					}
-				}
-			}
-		}
+	compiled, err := compileFilter(filter)
+	if err != nil {
+		return
+	}
 
-		if nodeValid {
+	for _, node := range nodes {
+		if compiled.matches(node) {
 			nodesFiltered = append(nodesFiltered, node)
 		}
 	}
-
 	return nodesFiltered, err
 }
 
-func filterBoolean(
-	value bool,
-	notEqualTo *bool,
-	equalTo *bool,
+// nodeFilter is a filter checked to be valid, ready to match Nodes with
+type nodeFilter struct {
+	fields []fieldFilter
+	and    []nodeFilter
+	or     []nodeFilter
+	not    *nodeFilter
+}
+
+// fieldFilter is every comparison on one field
+type fieldFilter struct {
+	field           string
+	caseInsensitive bool
+	comparisons     []comparison
+}
+
+// comparison is one operator, and the value or values it compares with
+type comparison struct {
+	operator string
+	value    interface{}
+	values   []interface{}
+	pattern  *regexp.Regexp
+	want     bool
+}
+
+// compileFilter checks each key and comparison of filter
+func compileFilter(
+	filter map[string]interface{},
 ) (
-	valid bool,
+	compiled nodeFilter,
+	err error,
 ) {
-	if notEqualTo != nil {
-		if value != *notEqualTo {
-			valid = true
+	for key, value := range filter {
+		if value == nil {
+			continue
 		}
-	}
 
-	if equalTo != nil {
-		if value == *equalTo {
-			valid = true
+		switch key {
+		case "AND", "OR":
+			filters, ok := asList(value)
+			if !ok {
+				err = fmt.Errorf("Cannot filter, %s must be a list of filters", key)
+				return
+			}
+			for _, item := range filters {
+				itemFilter, ok := asMap(item)
+				if !ok {
+					err = fmt.Errorf("Cannot filter, %s must be a list of filters", key)
+					return
+				}
+
+				var compiledItem nodeFilter
+				compiledItem, err = compileFilter(itemFilter)
+				if err != nil {
+					return
+				}
+				if key == "AND" {
+					compiled.and = append(compiled.and, compiledItem)
+				} else {
+					compiled.or = append(compiled.or, compiledItem)
+				}
+			}
+			if key == "OR" && len(filters) == 0 {
+				err = fmt.Errorf("Cannot filter, OR must have at least one filter")
+				return
+			}
+
+		case "NOT":
+			notFilter, ok := asMap(value)
+			if !ok {
+				err = fmt.Errorf("Cannot filter, NOT must be a filter")
+				return
+			}
+
+			var compiledNot nodeFilter
+			compiledNot, err = compileFilter(notFilter)
+			if err != nil {
+				return
+			}
+			compiled.not = &compiledNot
+
+		default:
+			comparisons, ok := asMap(value)
+			if !ok {
+				err = fmt.Errorf("Cannot filter %s, it must have comparisons", key)
+				return
+			}
+
+			var compiledField fieldFilter
+			compiledField, err = compileFieldFilter(key, comparisons)
+			if err != nil {
+				return
+			}
+			compiled.fields = append(compiled.fields, compiledField)
 		}
 	}
-
-	return valid
+	return
 }
 
-func filterString(
-	value string,
-	notEqualTo *string,
-	equalTo *string,
-	lessThanOrEqualTo *string,
-	lessThan *string,
-	greaterThanOrEqualTo *string,
-	greaterThan *string,
-	contains *string,
-	notContains *string,
-	beginsWith *string,
-	endsWith *string,
+// compileFieldFilter checks each comparison on field
+func compileFieldFilter(
+	field string,
+	comparisons map[string]interface{},
 ) (
-	valid bool,
+	compiled fieldFilter,
+	err error,
 ) {
+	compiled.field = field
 
-	if notEqualTo != nil {
-		if value != *notEqualTo {
-			valid = true
+	if caseInsensitive, ok := comparisons["caseInsensitive"]; ok && caseInsensitive != nil {
+		compiled.caseInsensitive, ok = caseInsensitive.(bool)
+		if !ok {
+			err = fmt.Errorf("Cannot filter %s, caseInsensitive must be true or false", field)
+			return
 		}
 	}
-	if equalTo != nil {
-		if value == *equalTo {
-			valid = true
+
+	for operator, value := range comparisons {
+		if value == nil || operator == "caseInsensitive" {
+			continue
 		}
-	}
-	if lessThanOrEqualTo != nil {
-		if value <= *lessThanOrEqualTo {
-			valid = true
+
+		compiledComparison := comparison{operator: operator}
+
+		switch {
+		case filterOperators[operator]:
+			if !isScalar(value) {
+				err = fmt.Errorf(
+					"Cannot filter %s, %s must be a String, Int, Float or Boolean",
+					field,
+					operator,
+				)
+				return
+			}
+			compiledComparison.value = value
+
+		case filterListOperators[operator]:
+			values, ok := asList(value)
+			if !ok {
+				err = fmt.Errorf("Cannot filter %s, %s must be a list", field, operator)
+				return
+			}
+			for _, item := range values {
+				if !isScalar(item) {
+					err = fmt.Errorf(
+						"Cannot filter %s, %s must be a list of String, Int, Float or Boolean",
+						field,
+						operator,
+					)
+					return
+				}
+			}
+			if operator == "between" && len(values) != 2 {
+				err = fmt.Errorf(
+					"Cannot filter %s, between must be a list of 2 values, it has %d",
+					field,
+					len(values),
+				)
+				return
+			}
+			compiledComparison.values = values
+
+		case operator == "matches":
+			expression, ok := value.(string)
+			if !ok {
+				err = fmt.Errorf("Cannot filter %s, matches must be a String", field)
+				return
+			}
+			if compiled.caseInsensitive {
+				expression = "(?i)" + expression
+			}
+			compiledComparison.pattern, err = regexp.Compile(expression)
+			if err != nil {
+				err = fmt.Errorf(
+					"Cannot filter %s, matches is not a valid regular expression: %v",
+					field,
+					err,
+				)
+				return
+			}
+
+		case operator == "isNull" || operator == "exists":
+			want, ok := value.(bool)
+			if !ok {
+				err = fmt.Errorf("Cannot filter %s, %s must be true or false", field, operator)
+				return
+			}
+			compiledComparison.want = want
+
+		default:
+			err = fmt.Errorf("Cannot filter %s, %s is not a comparison", field, operator)
+			return
 		}
+
+		compiled.comparisons = append(compiled.comparisons, compiledComparison)
 	}
-	if lessThan != nil {
-		if value < *lessThan {
-			valid = true
+	return
+}
+
+// matches is true when node passes every part of the filter
+func (f nodeFilter) matches(node types.Node) bool {
+	for _, field := range f.fields {
+		if !field.matches(node[field.field]) {
+			return false
 		}
 	}
-	if greaterThanOrEqualTo != nil {
-		if value >= *greaterThanOrEqualTo {
-			valid = true
+	for _, and := range f.and {
+		if !and.matches(node) {
+			return false
 		}
 	}
-	if greaterThan != nil {
-		if value > *greaterThan {
-			valid = true
+	if len(f.or) > 0 {
+		matchedOne := false
+		for _, or := range f.or {
+			if or.matches(node) {
+				matchedOne = true
+				break
+			}
 		}
-	}
-	if contains != nil {
-		if strings.Contains(value, *contains) {
-			valid = true
+		if !matchedOne {
+			return false
 		}
 	}
-	if notContains != nil {
-		if !strings.Contains(value, *contains) {
-			valid = true
-		}
+	if f.not != nil && f.not.matches(node) {
+		return false
 	}
-	if beginsWith != nil {
-		if strings.HasPrefix(value, *beginsWith) {
-			valid = true
+	return true
+}
+
+// matches is true when value passes every comparison on the field
+func (f fieldFilter) matches(value interface{}) bool {
+	for _, comparison := range f.comparisons {
+		if !comparison.passes(value, f.caseInsensitive) {
+			return false
 		}
 	}
+	return true
+}
+
+// passes is true when value passes the comparison
+func (c comparison) passes(value interface{}, caseInsensitive bool) bool {
+	switch c.operator {
+	case "isNull":
+		return (value == nil) == c.want
+	case "exists":
+		return (value != nil) == c.want
+	}
+
+	// Every other comparison needs a value to compare
+	if value == nil {
+		return false
+	}
 
-	if endsWith != nil {
-		if strings.HasSuffix(value, *endsWith) {
-			valid = true
+	switch c.operator {
+	case "equalTo":
+		return equalValues(value, c.value, caseInsensitive)
+	case "notEqualTo":
+		return !equalValues(value, c.value, caseInsensitive)
+	case "lessThan":
+		order, ok := compareValues(value, c.value, caseInsensitive)
+		return ok && order < 0
+	case "lessThanOrEqualTo":
+		order, ok := compareValues(value, c.value, caseInsensitive)
+		return ok && order <= 0
+	case "greaterThan":
+		order, ok := compareValues(value, c.value, caseInsensitive)
+		return ok && order > 0
+	case "greaterThanOrEqualTo":
+		order, ok := compareValues(value, c.value, caseInsensitive)
+		return ok && order >= 0
+	case "between":
+		low, lowOK := compareValues(value, c.values[0], caseInsensitive)
+		high, highOK := compareValues(value, c.values[1], caseInsensitive)
+		return lowOK && highOK && low >= 0 && high <= 0
+	case "in":
+		for _, item := range c.values {
+			if equalValues(value, item, caseInsensitive) {
+				return true
+			}
+		}
+		return false
+	case "notIn":
+		for _, item := range c.values {
+			if equalValues(value, item, caseInsensitive) {
+				return false
+			}
 		}
+		return true
+	case "contains":
+		contains, ok := containsValue(value, c.value, caseInsensitive)
+		return ok && contains
+	case "notContains":
+		contains, ok := containsValue(value, c.value, caseInsensitive)
+		return ok && !contains
+	case "beginsWith", "endsWith":
+		text, ok := value.(string)
+		part, partOK := c.value.(string)
+		if !ok || !partOK {
+			return false
+		}
+		if caseInsensitive {
+			text = strings.ToLower(text)
+			part = strings.ToLower(part)
+		}
+		if c.operator == "beginsWith" {
+			return strings.HasPrefix(text, part)
+		}
+		return strings.HasSuffix(text, part)
+	case "matches":
+		text, ok := value.(string)
+		return ok && c.pattern.MatchString(text)
+	}
+	return false
+}
+
+// equalValues is true when a and b are the same value. Numbers of any type
+// are compared by their exact value, so 0.1 from an event equals 0.1 read
+// from DynamoDB.
+func equalValues(a interface{}, b interface{}, caseInsensitive bool) bool {
+	if aBool, ok := a.(bool); ok {
+		bBool, ok := b.(bool)
+		return ok && aBool == bBool
+	}
+
+	aString, aIsString := a.(string)
+	bString, bIsString := b.(string)
+	if aIsString && bIsString && caseInsensitive {
+		return strings.EqualFold(aString, bString)
+	}
+	if aIsString && bIsString && aString == bString {
+		return true
 	}
 
-	return valid
+	order, ok := compareValues(a, b, caseInsensitive)
+	return ok && order == 0
 }
 
-func filterInt(
-	value int,
-	notEqualTo *int,
-	equalTo *int,
-	lessThanOrEqualTo *int,
-	lessThan *int,
-	greaterThanOrEqualTo *int,
-	greaterThan *int,
-	contains *int,
-	notContains *int,
-	between []*int,
+// compareValues orders a and b, returning -1, 0 or 1. ok is false when
+// they cannot be ordered.
+func compareValues(
+	a interface{},
+	b interface{},
+	caseInsensitive bool,
 ) (
-	valid bool,
+	order int,
+	ok bool,
 ) {
-	if notEqualTo != nil {
-		if value != *notEqualTo {
-			valid = true
+	aNumber, aIsNumber := types.NumberValue(a)
+	bNumber, bIsNumber := types.NumberValue(b)
+	aString, aIsString := a.(string)
+	bString, bIsString := b.(string)
+
+	switch {
+	case aIsNumber && bIsNumber:
+		return aNumber.Cmp(bNumber), true
+
+	case aIsString && bIsString:
+		aTime, aIsTime := asTime(aString)
+		bTime, bIsTime := asTime(bString)
+		if aIsTime && bIsTime {
+			return compareTimes(aTime, bTime), true
 		}
-	}
-	if equalTo != nil {
-		if value == *equalTo {
-			valid = true
+		if caseInsensitive {
+			aString = strings.ToLower(aString)
+			bString = strings.ToLower(bString)
 		}
-	}
-	if lessThanOrEqualTo != nil {
-		if value <= *lessThanOrEqualTo {
-			valid = true
+		return strings.Compare(aString, bString), true
+
+	case aIsString && bIsNumber:
+		aTime, aIsTime := asTime(aString)
+		if !aIsTime {
+			return 0, false
 		}
-	}
-	if lessThan != nil {
-		if value < *lessThan {
-			valid = true
+		return timeToSeconds(aTime).Cmp(bNumber), true
+
+	case aIsNumber && bIsString:
+		bTime, bIsTime := asTime(bString)
+		if !bIsTime {
+			return 0, false
 		}
+		return aNumber.Cmp(timeToSeconds(bTime)), true
 	}
-	if greaterThanOrEqualTo != nil {
-		if value >= *greaterThanOrEqualTo {
-			valid = true
+	return 0, false
+}
+
+// containsValue is true when part is in the string value, or is an item of
+// the list value. ok is false when value is neither.
+func containsValue(
+	value interface{},
+	part interface{},
+	caseInsensitive bool,
+) (
+	contains bool,
+	ok bool,
+) {
+	if text, isString := value.(string); isString {
+		partString, partIsString := part.(string)
+		if !partIsString {
+			return false, false
 		}
-	}
-	if greaterThan != nil {
-		if value > *greaterThan {
-			valid = true
+		if caseInsensitive {
+			text = strings.ToLower(text)
+			partString = strings.ToLower(partString)
 		}
+		return strings.Contains(text, partString), true
 	}
-	if contains != nil {
-		if value == *contains {
-			valid = true
-		}
+
+	items, isList := asList(value)
+	if !isList {
+		return false, false
 	}
-	if notContains != nil {
-		if value == *notContains {
-			valid = true
+	for _, item := range items {
+		if equalValues(item, part, caseInsensitive) {
+			return true, true
 		}
 	}
-	if between != nil {
-		if len(between) == 2 {
-			if value >= *between[0] && value <= *between[1] {
-				valid = true
-			}
-		} else {
-			// TODO: handle error??
-		}
+	return false, true
+}
+
+func compareTimes(a time.Time, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
 	}
+	return 0
+}
 
-	return valid
+func timeToSeconds(t time.Time) *big.Rat {
+	return big.NewRat(t.UnixNano(), int64(time.Second))
 }
 
-func filterFloat64(
-	value float64,
-	notEqualTo *float64,
-	equalTo *float64,
-	lessThanOrEqualTo *float64,
-	lessThan *float64,
-	greaterThanOrEqualTo *float64,
-	greaterThan *float64,
-	contains *float64,
-	notContains *float64,
-	between []*float64,
-) (
-	valid bool,
-) {
-	return valid
+// asTime parses an AWSDateTime, which is RFC 3339
+func asTime(value string) (t time.Time, ok bool) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	return t, err == nil
+}
+
+// isScalar is a value a comparison can be made with
+func isScalar(value interface{}) bool {
+	if _, ok := types.NumberValue(value); ok {
+		return true
+	}
+	switch value.(type) {
+	case string, bool:
+		return true
+	}
+	return false
+}
+
+// asList gets the items of a slice of any type
+func asList(value interface{}) (items []interface{}, ok bool) {
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice {
+		return nil, false
+	}
+	items = make([]interface{}, list.Len())
+	for i := range items {
+		items[i] = list.Index(i).Interface()
+	}
+	return items, true
+}
+
+// asMap gets a filter or the comparisons on a field, which are decoded
+// from JSON as maps
+func asMap(value interface{}) (fields map[string]interface{}, ok bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return value, true
+	case types.Filter:
+		return value, true
+	case types.FilterConfigValue:
+		return value, true
+	}
+	return nil, false
 }
@@ -0,0 +1,316 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterNodes(t *testing.T) {
+	nodes := []types.Node{
+		types.Node{
+			"id":        "order-1",
+			"status":    "PAID",
+			"total":     float64(120),
+			"express":   true,
+			"createdAt": "2018-02-01T10:00:00Z",
+			"tags":      []interface{}{"gift", "fragile"},
+		},
+		types.Node{
+			"id":        "order-2",
+			"status":    "Cancelled",
+			"total":     float64(30.5),
+			"express":   false,
+			"createdAt": "2018-02-02T10:00:00+11:00",
+			"tags":      []interface{}{"fragile"},
+		},
+		types.Node{
+			"id":        "order-3",
+			"status":    "PENDING",
+			"total":     float64(75),
+			"createdAt": "2018-02-03T10:00:00Z",
+			"note":      "leave at the door",
+		},
+		types.Node{
+			"id":     "order-4",
+			"status": nil,
+			"total":  int64(200),
+		},
+	}
+
+	tests := []struct {
+		filter types.Filter
+		ids    []string
+		err    string
+	}{
+		{
+			filter: types.Filter{},
+			ids:    []string{"order-1", "order-2", "order-3", "order-4"},
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"equalTo": "PAID"},
+			},
+			ids: []string{"order-1"},
+		},
+		{
+			// Every field must match
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"notEqualTo": "PAID"},
+				"total":  types.FilterConfigValue{"greaterThan": float64(50)},
+			},
+			ids: []string{"order-3"},
+		},
+		{
+			// Every comparison on a field must pass
+			filter: types.Filter{
+				"total": types.FilterConfigValue{
+					"greaterThanOrEqualTo": float64(30.5),
+					"lessThan":             float64(120),
+				},
+			},
+			ids: []string{"order-2", "order-3"},
+		},
+		{
+			filter: types.Filter{
+				"total": types.FilterConfigValue{"between": []interface{}{float64(75), float64(200)}},
+			},
+			ids: []string{"order-1", "order-3", "order-4"},
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"between": []interface{}{"A", "P"}},
+			},
+			ids: []string{"order-2"},
+		},
+		{
+			filter: types.Filter{
+				"OR": []interface{}{
+					map[string]interface{}{
+						"status": map[string]interface{}{"equalTo": "PAID"},
+					},
+					map[string]interface{}{
+						"total": map[string]interface{}{"lessThan": float64(50)},
+					},
+				},
+			},
+			ids: []string{"order-1", "order-2"},
+		},
+		{
+			filter: types.Filter{
+				"AND": []interface{}{
+					map[string]interface{}{
+						"total": map[string]interface{}{"greaterThan": float64(50)},
+					},
+					map[string]interface{}{
+						"NOT": map[string]interface{}{
+							"express": map[string]interface{}{"equalTo": true},
+						},
+					},
+				},
+			},
+			ids: []string{"order-3", "order-4"},
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"in": []interface{}{"PAID", "PENDING"}},
+			},
+			ids: []string{"order-1", "order-3"},
+		},
+		{
+			// A field that is not set is not in or out of a list
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"notIn": []interface{}{"PAID", "PENDING"}},
+			},
+			ids: []string{"order-2"},
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"isNull": true},
+			},
+			ids: []string{"order-4"},
+		},
+		{
+			filter: types.Filter{
+				"note": types.FilterConfigValue{"exists": false},
+			},
+			ids: []string{"order-1", "order-2", "order-4"},
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{
+					"equalTo":         "cancelled",
+					"caseInsensitive": true,
+				},
+			},
+			ids: []string{"order-2"},
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"beginsWith": "P"},
+			},
+			ids: []string{"order-1", "order-3"},
+		},
+		{
+			filter: types.Filter{
+				"note": types.FilterConfigValue{"endsWith": "DOOR", "caseInsensitive": true},
+			},
+			ids: []string{"order-3"},
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"matches": "^p[a-z]+$", "caseInsensitive": true},
+			},
+			ids: []string{"order-1", "order-3"},
+		},
+		{
+			filter: types.Filter{
+				"note": types.FilterConfigValue{"contains": "the"},
+			},
+			ids: []string{"order-3"},
+		},
+		{
+			// contains on a list field checks its items
+			filter: types.Filter{
+				"tags": types.FilterConfigValue{"contains": "gift"},
+			},
+			ids: []string{"order-1"},
+		},
+		{
+			filter: types.Filter{
+				"tags": types.FilterConfigValue{"notContains": "gift"},
+			},
+			ids: []string{"order-2"},
+		},
+		{
+			// Timestamps are compared as times, 2018-02-02T10:00:00+11:00
+			// is 2018-02-01T23:00:00Z
+			filter: types.Filter{
+				"createdAt": types.FilterConfigValue{
+					"lessThan": "2018-02-02T00:00:00Z",
+				},
+			},
+			ids: []string{"order-1", "order-2"},
+		},
+		{
+			// A timestamp can be compared with seconds since 1970
+			filter: types.Filter{
+				"createdAt": types.FilterConfigValue{
+					"greaterThan": float64(1517616000),
+				},
+			},
+			ids: []string{"order-3"},
+		},
+		{
+			// Values of different types are never equal
+			filter: types.Filter{
+				"total": types.FilterConfigValue{"equalTo": "120"},
+			},
+		},
+		{
+			filter: types.Filter{
+				"total": types.FilterConfigValue{"between": []interface{}{float64(1)}},
+			},
+			err: "Cannot filter total, between must be a list of 2 values, it has 1",
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"like": "PAID"},
+			},
+			err: "Cannot filter status, like is not a comparison",
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"matches": "("},
+			},
+			err: "Cannot filter status, matches is not a valid regular expression: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			filter: types.Filter{
+				"OR": map[string]interface{}{},
+			},
+			err: "Cannot filter, OR must be a list of filters",
+		},
+		{
+			filter: types.Filter{
+				"status": types.FilterConfigValue{"isNull": "yes"},
+			},
+			err: "Cannot filter status, isNull must be true or false",
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestFilterNodes")
+		assert := assert.New(t)
+
+		filtered, err := FilterNodes(ctx, test.filter, nodes)
+		if test.err != "" {
+			assert.EqualError(err, test.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+
+		var ids []string
+		for _, node := range filtered {
+			ids = append(ids, node["id"].(string))
+		}
+		assert.Equal(test.ids, ids, fmt.Sprintf("Test %d", i))
+	}
+}
+
+func TestFilterNodesNumbers(t *testing.T) {
+	nodes := []types.Node{
+		types.Node{"id": "item-1", "price": types.Number("19.99")},
+		types.Node{"id": "item-2", "price": types.Number("9007199254740993")},
+		types.Node{"id": "item-3", "price": float64(5)},
+	}
+
+	tests := []struct {
+		filter types.Filter
+		ids    []string
+	}{
+		{
+			filter: types.Filter{
+				"price": types.FilterConfigValue{"equalTo": float64(19.99)},
+			},
+			ids: []string{"item-1"},
+		},
+		{
+			filter: types.Filter{
+				"price": types.FilterConfigValue{"equalTo": types.Number("5.0")},
+			},
+			ids: []string{"item-3"},
+		},
+		{
+			// 9007199254740993 is 9007199254740992 as a float64
+			filter: types.Filter{
+				"price": types.FilterConfigValue{"greaterThan": types.Number("9007199254740992")},
+			},
+			ids: []string{"item-2"},
+		},
+		{
+			filter: types.Filter{
+				"price": types.FilterConfigValue{"in": []interface{}{types.Number("19.990"), int64(5)}},
+			},
+			ids: []string{"item-1", "item-3"},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestFilterNodesNumbers")
+		assert := assert.New(t)
+
+		filtered, err := FilterNodes(ctx, test.filter, nodes)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+
+		var ids []string
+		for _, node := range filtered {
+			ids = append(ids, node["id"].(string))
+		}
+		assert.Equal(test.ids, ids, fmt.Sprintf("Test %d", i))
+	}
+}
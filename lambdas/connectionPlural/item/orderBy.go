@@ -0,0 +1,223 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// Nodes are sorted by each OrderBy in turn, then by id, so Nodes that are
+// equal on every OrderBy are always in the same order, and a page ends on
+// the same Node each time it is read.
+//
+// Values are compared by their type
+//
+//   numbers     by their exact value
+//   strings     by their bytes, or as times when both are timestamps
+//   booleans    false before true
+//
+// A field that is missing or null is a null. Nulls sort as the largest
+// value unless nulls is FIRST or LAST. Values of different types are
+// sorted booleans, then numbers, then strings, then anything else.
+
+// sortKey is one OrderBy checked to be valid
+type sortKey struct {
+	field      string
+	descending bool
+	nullsFirst bool
+}
+
+// nodeOrder is every sortKey, ending with id
+type nodeOrder []sortKey
+
+// SortNodes by orderBy. The sort is stable.
+func SortNodes(
+	ctx context.Context,
+	nodes []types.Node,
+	orderBy []types.OrderBy,
+) (
+	nodesSorted []types.Node,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "SortNodes")
+	defer segment.Close(err)
+
+	order, err := compileOrder(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return order.sort(nodes), err
+}
+
+// compileOrder checks each OrderBy, and adds id as the last sortKey when
+// it is not already one
+func compileOrder(orderBy []types.OrderBy) (order nodeOrder, err error) {
+	haveID := false
+	seen := make(map[string]bool)
+
+	for _, by := range orderBy {
+		if by.Field == "" {
+			return nil, fmt.Errorf("Cannot order by, field is required")
+		}
+		if seen[by.Field] {
+			return nil, fmt.Errorf(
+				"Cannot order by %s, it is in orderBy more than once",
+				by.Field,
+			)
+		}
+		seen[by.Field] = true
+
+		key := sortKey{field: by.Field}
+
+		switch by.Direction {
+		case "", "ASC":
+		case "DESC":
+			key.descending = true
+		default:
+			return nil, fmt.Errorf(
+				"Cannot order by %s, direction must be ASC or DESC",
+				by.Field,
+			)
+		}
+
+		switch by.Nulls {
+		case "":
+			// Nulls are the largest value
+			key.nullsFirst = key.descending
+		case "FIRST":
+			key.nullsFirst = true
+		case "LAST":
+		default:
+			return nil, fmt.Errorf(
+				"Cannot order by %s, nulls must be FIRST or LAST",
+				by.Field,
+			)
+		}
+
+		if by.Field == "id" {
+			haveID = true
+		}
+		order = append(order, key)
+	}
+
+	if !haveID {
+		order = append(order, sortKey{field: "id"})
+	}
+	return order, nil
+}
+
+// sort a copy of nodes, keeping the order of nodes that are equal
+func (o nodeOrder) sort(nodes []types.Node) (nodesSorted []types.Node) {
+	nodesSorted = make([]types.Node, len(nodes))
+	copy(nodesSorted, nodes)
+	sort.SliceStable(nodesSorted, func(i, j int) bool {
+		return o.compare(nodesSorted[i], nodesSorted[j]) < 0
+	})
+	return nodesSorted
+}
+
+// compare orders a and b, returning -1, 0 or 1
+func (o nodeOrder) compare(a types.Node, b types.Node) int {
+	for _, key := range o {
+		if c := key.compare(a[key.field], b[key.field]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compare orders the values of the field on two Nodes
+func (k sortKey) compare(a interface{}, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		if k.nullsFirst {
+			return -1
+		}
+		return 1
+	case b == nil:
+		if k.nullsFirst {
+			return 1
+		}
+		return -1
+	}
+
+	c := compareSortValues(a, b)
+	if k.descending {
+		return -c
+	}
+	return c
+}
+
+// compareSortValues orders two values that are not null. Unlike
+// compareValues, every pair of values has an order.
+func compareSortValues(a interface{}, b interface{}) int {
+	aRank := sortRank(a)
+	bRank := sortRank(b)
+	if aRank != bRank {
+		if aRank < bRank {
+			return -1
+		}
+		return 1
+	}
+
+	switch aRank {
+	case sortRankBool:
+		aBool := a.(bool)
+		bBool := b.(bool)
+		switch {
+		case aBool == bBool:
+			return 0
+		case bBool:
+			return -1
+		}
+		return 1
+
+	case sortRankNumber:
+		aNumber, _ := types.NumberValue(a)
+		bNumber, _ := types.NumberValue(b)
+		return aNumber.Cmp(bNumber)
+
+	case sortRankString:
+		aString := a.(string)
+		bString := b.(string)
+		aTime, aIsTime := asTime(aString)
+		bTime, bIsTime := asTime(bString)
+		if aIsTime && bIsTime {
+			if c := compareTimes(aTime, bTime); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(aString, bString)
+	}
+
+	// Lists and maps have no order of their own
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// The order of values of different types
+const (
+	sortRankBool = iota
+	sortRankNumber
+	sortRankString
+	sortRankOther
+)
+
+func sortRank(value interface{}) int {
+	if _, ok := value.(bool); ok {
+		return sortRankBool
+	}
+	if _, ok := types.NumberValue(value); ok {
+		return sortRankNumber
+	}
+	if _, ok := value.(string); ok {
+		return sortRankString
+	}
+	return sortRankOther
+}
@@ -0,0 +1,153 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortNodes(t *testing.T) {
+	nodes := []types.Node{
+		types.Node{
+			"id":        "order-3",
+			"status":    "PAID",
+			"total":     types.Number("120"),
+			"express":   true,
+			"createdAt": "2018-02-01T10:00:00Z",
+		},
+		types.Node{
+			"id":        "order-1",
+			"status":    "PENDING",
+			"total":     float64(30.5),
+			"express":   false,
+			"createdAt": "2018-02-02T10:00:00+11:00",
+		},
+		types.Node{
+			"id":        "order-4",
+			"status":    "PAID",
+			"total":     int64(75),
+			"createdAt": "2018-02-03T10:00:00Z",
+		},
+		types.Node{
+			"id":     "order-2",
+			"status": nil,
+			"total":  types.Number("9.99"),
+		},
+	}
+
+	tests := []struct {
+		orderBy []types.OrderBy
+		ids     []string
+		err     string
+	}{
+		{
+			// Sorted by id when there is no orderBy
+			ids: []string{"order-1", "order-2", "order-3", "order-4"},
+		},
+		{
+			// Numbers of any type are compared by their value
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "total"},
+			},
+			ids: []string{"order-2", "order-1", "order-4", "order-3"},
+		},
+		{
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "total", Direction: "DESC"},
+			},
+			ids: []string{"order-3", "order-4", "order-1", "order-2"},
+		},
+		{
+			// Nodes with the same status are sorted by the next key, then id
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "status"},
+			},
+			ids: []string{"order-3", "order-4", "order-1", "order-2"},
+		},
+		{
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "status"},
+				types.OrderBy{Field: "total", Direction: "DESC"},
+			},
+			ids: []string{"order-3", "order-4", "order-1", "order-2"},
+		},
+		{
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "status", Nulls: "FIRST"},
+				types.OrderBy{Field: "id", Direction: "DESC"},
+			},
+			ids: []string{"order-2", "order-4", "order-3", "order-1"},
+		},
+		{
+			// Nulls sort first for DESC, unless nulls is LAST
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "createdAt", Direction: "DESC"},
+			},
+			ids: []string{"order-2", "order-4", "order-1", "order-3"},
+		},
+		{
+			// Timestamps are compared as times, 2018-02-02T10:00:00+11:00
+			// is 2018-02-01T23:00:00Z
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "createdAt", Direction: "DESC", Nulls: "LAST"},
+			},
+			ids: []string{"order-4", "order-1", "order-3", "order-2"},
+		},
+		{
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "express"},
+			},
+			ids: []string{"order-1", "order-3", "order-2", "order-4"},
+		},
+		{
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "total", Direction: "UP"},
+			},
+			err: "Cannot order by total, direction must be ASC or DESC",
+		},
+		{
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "total", Nulls: "NEVER"},
+			},
+			err: "Cannot order by total, nulls must be FIRST or LAST",
+		},
+		{
+			orderBy: []types.OrderBy{
+				types.OrderBy{Field: "total"},
+				types.OrderBy{Field: "total", Direction: "DESC"},
+			},
+			err: "Cannot order by total, it is in orderBy more than once",
+		},
+		{
+			orderBy: []types.OrderBy{
+				types.OrderBy{Direction: "DESC"},
+			},
+			err: "Cannot order by, field is required",
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestSortNodes")
+		assert := assert.New(t)
+
+		sorted, err := SortNodes(ctx, nodes, test.orderBy)
+		if test.err != "" {
+			assert.EqualError(err, test.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+
+		var ids []string
+		for _, node := range sorted {
+			ids = append(ids, node["id"].(string))
+		}
+		assert.Equal(test.ids, ids, fmt.Sprintf("Test %d", i))
+
+		// The nodes passed in are not changed
+		assert.Equal("order-3", nodes[0]["id"], fmt.Sprintf("Test %d", i))
+	}
+}
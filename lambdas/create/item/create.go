@@ -3,7 +3,6 @@ package item
 import (
 	"context"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -13,13 +12,16 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	nodeUtil "github.com/ojkelly/linnet/lambdas/util/node"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 	"github.com/satori/go.uuid"
 )
 
 var MAX_RETRIES = 5
 
-// Create object(s) in DynamoDB
+// Create object(s) in DynamoDB. The rootNode is only returned when every
+// item was written, errors can still be returned with it.
 func Create(
 	ctx context.Context,
 	event *types.LambdaEvent,
@@ -36,6 +38,15 @@ func Create(
 
 	// Setup some initial vars
 	rootNodeID := uuid.NewV4().String()
+
+	// An id passed for the root Node is used instead, createItems checks
+	// its format
+	if data, ok := event.Context.Arguments["data"].(map[string]interface{}); ok {
+		if clientID, ok := data["id"].(string); ok {
+			rootNodeID = clientID
+		}
+	}
+
 	createdAt := now
 	updatedAt := now
 
@@ -46,8 +57,7 @@ func Create(
 	// TODO: Write data as put's, and connections as updates
 
 	// Transform the createInput into an object ready for Dynamodb
-	// TODO: add https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.ConditionExpressions.html#Expressions.ConditionExpressions.PreventingOverwrites
-	items, err := createItems(
+	items, connections, err := createItems(
 		ctx,
 		event.LinnetFields,
 		event.DataSource,
@@ -65,38 +75,188 @@ func Create(
 		return
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(items))
-
-	for _, item := range items {
-		go func(
-			ctx context.Context,
-			item types.Node,
-			tableName *string,
-			dynamo dynamodbiface.DynamoDBAPI,
-		) {
-			putRequest, err := marshallPutItem(ctx, item, tableName)
-			if err != nil {
-				wg.Done()
-				return
+	// Before writing anything, check the edges match their Cardinality
+	// and Required rules, and the nodes we're connecting to exist
+	errors = nodeUtil.ValidateEdges(event.EdgeTypes, items)
+	if errors != nil {
+		return
+	}
+
+	errors = database.VerifyConnections(
+		ctx,
+		dynamo,
+		*tableName,
+		connections,
+	)
+	if errors != nil {
+		return
+	}
+
+	itemMaps := make([]map[string]*dynamodb.AttributeValue, len(items))
+	for i, item := range items {
+		itemMaps[i], err = dynamodbattribute.MarshalMap(item)
+		if err != nil {
+			errors = append(errors, database.NewWriteError(item, err))
+		}
+	}
+	if errors != nil {
+		return
+	}
+
+	// Claim the guard of every @unique value before anything is written,
+	// so a value held by another Node fails the create first
+	claimed, err := database.ClaimUniqueValues(
+		ctx,
+		dynamo,
+		*tableName,
+		database.UniqueValues(event.UniqueFields, items),
+		now,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	// Keep track of every item that was written, so they can be removed
+	// if any other write failed
+	var written []types.Node
+	var failed []error
+
+	// Nodes are put one at a time with attribute_not_exists(id), which
+	// BatchWriteItem cannot take, so an id that is already used fails
+	// instead of overwriting another Node
+	var edgeItems []types.Node
+	var requests []*dynamodb.WriteRequest
+	for i, item := range items {
+		if item["linnet:dataType"] != "Node" {
+			edgeItems = append(edgeItems, item)
+			requests = append(requests, &dynamodb.WriteRequest{
+				PutRequest: &dynamodb.PutRequest{
+					Item: itemMaps[i],
+				},
+			})
+			continue
+		}
+
+		err = putNode(ctx, dynamo, tableName, itemMaps[i])
+		if err != nil {
+			failed = append(failed, database.NewWriteError(item, err))
+			continue
+		}
+		written = append(written, item)
+	}
+
+	// The edges are only written once every Node they connect exists
+	if failed == nil && len(requests) > 0 {
+		result, batchErr := database.BatchWriteRequests(
+			ctx,
+			dynamo,
+			*tableName,
+			requests,
+			MAX_RETRIES,
+		)
+
+		// Written holds the requests as they were passed, so they are
+		// matched to their item
+		writtenRequests := make(map[*dynamodb.WriteRequest]bool)
+		for _, request := range result.Written {
+			writtenRequests[request] = true
+		}
+
+		for i, item := range edgeItems {
+			if writtenRequests[requests[i]] {
+				written = append(written, item)
+				continue
 			}
 
-			err = putItem(
-				ctx,
-				putRequest,
-				dynamo,
-			)
-			if err != nil {
-				fmt.Println(err)
-				wg.Done()
-				return
+			writeErr := batchErr
+			if writeErr == nil {
+				// DynamoDB left it unprocessed after every retry
+				writeErr = awserr.New(
+					dynamodb.ErrCodeProvisionedThroughputExceededException,
+					fmt.Sprintf("DynamoDB did not process the item after %d retries", MAX_RETRIES),
+					nil,
+				)
 			}
-			wg.Done()
-		}(ctx, item, tableName, dynamo)
+			failed = append(failed, database.NewWriteError(item, writeErr))
+		}
+	}
+
+	// Nodes that were not written cannot be returned as created, and the
+	// rest of the graph is removed so the create fails as a whole
+	if failed != nil {
+		removed, rollbackErr := rollback(
+			ctx,
+			dynamo,
+			*tableName,
+			written,
+		)
+		errors = append(errors, types.RollbackError{
+			NamedType:   event.NamedType,
+			Items:       len(items),
+			Failed:      failed,
+			Written:     len(written),
+			Removed:     removed,
+			RollbackErr: rollbackErr,
+		})
+		err = database.ReleaseUniqueValues(
+			ctx,
+			dynamo,
+			*tableName,
+			claimed,
+		)
+		if err != nil {
+			errors = append(errors, err)
+		}
+		return
 	}
 
-	// Wait for our put requests to complete
-	wg.Wait()
+	// A guard left pending is removed by DynamoDB once its ttl passes, so
+	// the create is removed too when a guard cannot be confirmed
+	err = database.ConfirmUniqueValues(
+		ctx,
+		dynamo,
+		*tableName,
+		claimed,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		_, rollbackErr := rollback(
+			ctx,
+			dynamo,
+			*tableName,
+			written,
+		)
+		if rollbackErr != nil {
+			errors = append(errors, rollbackErr)
+		}
+		err = database.ReleaseUniqueValues(
+			ctx,
+			dynamo,
+			*tableName,
+			claimed,
+		)
+		if err != nil {
+			errors = append(errors, err)
+		}
+		return
+	}
+
+	// Existing nodes connected on a ONE edge lose the node they were
+	// connected to. This only happens once every item is written, so a
+	// rollback never has to restore them. When it fails the created Node
+	// is still returned with the error, so the create is not tried again.
+	err = database.ReplaceCounterpartEdges(
+		ctx,
+		dynamo,
+		*tableName,
+		event.EdgeTypes,
+		connections,
+		now,
+	)
+	if err != nil {
+		errors = append(errors, err)
+	}
 
 	// Clean up the rootNode for return
 	rootNode = util.CleanRootNode(
@@ -109,62 +269,23 @@ func Create(
 	return
 }
 
-func marshallPutItem(
+// putNode writes a Node, unless an item already exists with its id
+func putNode(
 	ctx context.Context,
-	item types.Node,
-	tableName *string,
-) (
-	putItem *dynamodb.PutItemInput,
-	err error,
-) {
-	ctx, segment := xray.BeginSubsegment(ctx, "Create")
-	defer segment.Close(err)
-
-	// Marshall our Node into dynamodb's format
-	itemMap, err := dynamodbattribute.MarshalMap(item)
-	if err != nil {
-		return
-	}
-
-	// create the put request
-	putItem = &dynamodb.PutItemInput{
-		Item:                itemMap,
-		ConditionExpression: aws.String("attribute_not_exists(id)"),
-		TableName:           tableName,
-	}
-
-	return putItem, nil
-}
-
-func putItem(
-	ctx context.Context,
-	putRequest *dynamodb.PutItemInput,
 	dynamo dynamodbiface.DynamoDBAPI,
+	tableName *string,
+	itemMap map[string]*dynamodb.AttributeValue,
 ) (err error) {
-	ctx, segment := xray.BeginSubsegment(ctx, "Create")
+	ctx, segment := xray.BeginSubsegment(ctx, "putNode")
 	defer segment.Close(err)
 
 	_, err = dynamo.PutItemWithContext(
 		ctx,
-		putRequest,
+		&dynamodb.PutItemInput{
+			Item:                itemMap,
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+			TableName:           tableName,
+		},
 	)
-	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
-			case dynamodb.ErrCodeProvisionedThroughputExceededException:
-				fmt.Println(dynamodb.ErrCodeProvisionedThroughputExceededException, aerr.Error())
-			case dynamodb.ErrCodeResourceNotFoundException:
-				fmt.Println(dynamodb.ErrCodeResourceNotFoundException, aerr.Error())
-			case dynamodb.ErrCodeInternalServerError:
-				fmt.Println(dynamodb.ErrCodeInternalServerError, aerr.Error())
-			default:
-				fmt.Println(aerr.Error())
-			}
-		} else {
-			// Print the error, cast err to awserr.Error to get the Code and
-			// Message from an error.
-			fmt.Println(err.Error())
-		}
-	}
 	return
 }
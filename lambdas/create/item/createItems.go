@@ -2,6 +2,8 @@ package item
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
@@ -27,6 +29,7 @@ func createItems(
 	createInput map[string]interface{},
 ) (
 	items []types.Node,
+	connections []types.Connection,
 	err error,
 ) {
 	ctx, segment := xray.BeginSubsegment(ctx, "createItems")
@@ -43,6 +46,9 @@ func createItems(
 		return
 	}
 
+	// Keep track of the nodes created at this level, so they can be connected
+	var nodeIDs []string
+
 	// Next check if there is any data on our input
 	if createInput["data"] != nil {
 		nodesToCreate := util.ExtractDataFromInput(createInput)
@@ -57,11 +63,23 @@ func createItems(
 				nodeID = rootNodeID
 			}
 
+			// A client can pass the id, so a retried create writes the same
+			// Node, and is refused by attribute_not_exists(id)
+			if clientID, ok := createNode["id"]; ok && clientID != nil {
+				nodeID, err = validateClientID(namedType, clientID)
+				if err != nil {
+					return
+				}
+			}
+
+			nodeIDs = append(nodeIDs, nodeID)
+
 			// Create the base of the node
 			node := map[string]interface{}{
 				"id":               nodeID,
 				"linnet:dataType":  "Node",
 				"linnet:namedType": namedType,
+				"linnet:version":   1,
 				"createdAt":        createdAt,
 				"updatedAt":        updatedAt,
 				"createdBy":        createdBy,
@@ -69,6 +87,9 @@ func createItems(
 
 			// Now iterate over the values from the user
 			for fieldName, fieldValue := range createNode {
+				if fieldName == "id" {
+					continue
+				}
 				field := createNode[fieldName]
 
 				// Check if this is an edge
@@ -80,10 +101,16 @@ func createItems(
 				if foundEdge == false { // If this is not an edge, add the field to our new Node
 					node[fieldName] = field
 				} else { // This field IS an edge
+					edgeInput, ok := fieldValue.(map[string]interface{})
+					if !ok {
+						continue
+					}
+
 					var nestedItems []types.Node
+					var nestedConnections []types.Connection
 
 					// Create any and all nested Nodes, by recursing the current function
-					nestedItems, err = createItems(
+					nestedItems, nestedConnections, err = createItems(
 						ctx,
 						linnetFields,
 						dataSource,
@@ -94,8 +121,12 @@ func createItems(
 						createdAt,
 						updatedAt,
 						createdBy,
-						fieldValue.(map[string]interface{}),
+						edgeInput,
 					)
+					if err != nil {
+						return
+					}
+					connections = append(connections, nestedConnections...)
 
 					// Now process all the edges
 					for _, nestedItem := range nestedItems {
@@ -116,6 +147,21 @@ func createItems(
 						// Add the new nestedItem to our items
 						items = append(items, nestedItem)
 					}
+
+					// Connect any existing nodes passed by id
+					edgeItems, connection := connectNodes(
+						ctx,
+						edge,
+						nodeID,
+						nodeUtil.ExtractConnectionIDs(edgeInput["connection"]),
+						createdAt,
+						updatedAt,
+						createdBy,
+					)
+					if edgeItems != nil {
+						items = append(items, edgeItems...)
+						connections = append(connections, connection)
+					}
 				}
 			}
 
@@ -124,11 +170,102 @@ func createItems(
 		}
 	}
 
-	// Check for any connections
-	if createInput["connections"] != nil {
-		// TODO: add connections (create a connection from the id, but dont create a node)
-		// TODO: Check the Node we're connecting to exists
+	// Check for any connections, these are keyed by the edge field
+	// and connect every node created above to existing nodes
+	if connectionsInput, ok := createInput["connections"].(map[string]interface{}); ok {
+		for fieldName, connectionValue := range connectionsInput {
+			foundEdge, edge := util.GetEdgeFromEdgeTypes(
+				fieldName,
+				edgesOnType,
+			)
+			if foundEdge == false {
+				err = fmt.Errorf(
+					"Cannot connect %s.%s, it is not an edge",
+					namedType,
+					fieldName,
+				)
+				return
+			}
+
+			for _, nodeID := range nodeIDs {
+				edgeItems, connection := connectNodes(
+					ctx,
+					edge,
+					nodeID,
+					nodeUtil.ExtractConnectionIDs(connectionValue),
+					createdAt,
+					updatedAt,
+					createdBy,
+				)
+				if edgeItems != nil {
+					items = append(items, edgeItems...)
+					connections = append(connections, connection)
+				}
+			}
+		}
 	}
 
 	return
 }
+
+// CLIENT_ID_PATTERN is the format of an id passed to create. The id is
+// part of the linnet:dataType of edges, so it cannot contain "::".
+var CLIENT_ID_PATTERN = regexp.MustCompile("^[A-Za-z0-9_-]{1,128}$")
+
+// validateClientID returns the id passed in a create's data
+func validateClientID(
+	namedType string,
+	clientID interface{},
+) (
+	id string,
+	err error,
+) {
+	id, ok := clientID.(string)
+	if !ok || !CLIENT_ID_PATTERN.MatchString(id) {
+		err = fmt.Errorf(
+			"Cannot create %s, id %v must be 1 to 128 letters, numbers, - or _",
+			namedType,
+			clientID,
+		)
+	}
+	return
+}
+
+// connectNodes creates the edge items between a node and existing nodes.
+// The existing nodes are not written, so they are returned as a Connection
+// to be checked before anything is saved.
+func connectNodes(
+	ctx context.Context,
+	edge types.Edge,
+	nodeID string,
+	connectionIDs []string,
+	createdAt time.Time,
+	updatedAt time.Time,
+	createdBy string,
+) (
+	edgeItems []types.Node,
+	connection types.Connection,
+) {
+	if len(connectionIDs) == 0 {
+		return
+	}
+
+	for _, connectionID := range connectionIDs {
+		edgeItems = append(edgeItems, nodeUtil.CreateEdgeItem(
+			ctx,
+			edge,
+			nodeID,
+			connectionID,
+			createdAt,
+			updatedAt,
+			createdBy,
+		))
+	}
+
+	connection = types.Connection{
+		Edge:   edge,
+		NodeID: nodeID,
+		IDs:    connectionIDs,
+	}
+	return
+}
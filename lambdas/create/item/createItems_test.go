@@ -129,6 +129,7 @@ func TestCreateItems(t *testing.T) {
 						"phoneNumber":      "239487y789234",
 						"linnet:dataType":  "Node",
 						"linnet:namedType": "Customer",
+						"linnet:version":   1,
 						"createdAt":        currentTime,
 						"updatedAt":        currentTime,
 						"createdBy":        "linnet",
@@ -455,6 +456,116 @@ func TestCreateItems(t *testing.T) {
 			},
 			throws: false,
 		},
+		{
+			input: Input{
+				linnetFields: constants.LinnetFields,
+				dataSource: types.DataSourceDynamoDBConfig{
+					UseCallerCredentials: false,
+					AwsRegion:            "us-east-1",
+					TableName:            "DynamoDBTestTable",
+				},
+				namedType: "Order",
+				edgeTypes: []types.Edge{
+					types.Edge{
+						TypeName:    "Order",
+						Field:       "customer",
+						FieldType:   "Customer",
+						EdgeName:    "OrdersOnCustomer",
+						Required:    true,
+						Cardinality: "ONE",
+						Principal:   "FALSE",
+						Counterpart: types.EdgeCounterpart{
+							TypeName: "Customer",
+							Field:    "orders",
+						},
+					},
+					types.Edge{
+						TypeName:    "Order",
+						Field:       "products",
+						FieldType:   "Product",
+						EdgeName:    "ProductsOnOrders",
+						Required:    true,
+						Cardinality: "MANY",
+						Principal:   "TRUE",
+						Counterpart: types.EdgeCounterpart{
+							TypeName: "Product",
+							Field:    "orders",
+						},
+					},
+				},
+				rootNodeID:   "",
+				parentNodeID: "",
+				createdAt:    currentTime,
+				updatedAt:    currentTime,
+				createdBy:    "linnet",
+				createInput: map[string]interface{}{
+					"data": map[string]interface{}{
+						"status": "COMPLETE",
+						"customer": map[string]interface{}{
+							"connection": "43a67e91-c1b8-4da3-bc32-51e763bb5596",
+						},
+						"products": map[string]interface{}{
+							"connection": []interface{}{
+								"c82c8ee5-5457-4f6b-a516-390662230bb0",
+							},
+						},
+					},
+				},
+			},
+			output: Output{
+				items: []types.Node{
+					types.Node{
+						"id":               "",
+						"status":           "COMPLETE",
+						"linnet:dataType":  "Node",
+						"linnet:namedType": "Order",
+						"createdAt":        currentTime,
+						"updatedAt":        currentTime,
+						"createdBy":        "linnet",
+					},
+					types.Node{
+						"id":               "43a67e91-c1b8-4da3-bc32-51e763bb5596",
+						"linnet:dataType":  "OrdersOnCustomer::",
+						"linnet:namedType": "Customer",
+						"linnet:edge":      "",
+					},
+					types.Node{
+						"id":               "",
+						"linnet:dataType":  "ProductsOnOrders::c82c8ee5-5457-4f6b-a516-390662230bb0",
+						"linnet:namedType": "Product",
+						"linnet:edge":      "c82c8ee5-5457-4f6b-a516-390662230bb0",
+					},
+				},
+				err: nil,
+			},
+			throws: false,
+		},
+		{
+			input: Input{
+				linnetFields: constants.LinnetFields,
+				dataSource: types.DataSourceDynamoDBConfig{
+					UseCallerCredentials: false,
+					AwsRegion:            "us-east-1",
+					TableName:            "DynamoDBTestTable",
+				},
+				namedType:    "Order",
+				edgeTypes:    []types.Edge{},
+				rootNodeID:   "",
+				parentNodeID: "",
+				createdAt:    currentTime,
+				updatedAt:    currentTime,
+				createdBy:    "linnet",
+				createInput: map[string]interface{}{
+					"data": map[string]interface{}{
+						"status": "COMPLETE",
+					},
+					"connections": map[string]interface{}{
+						"status": "43a67e91-c1b8-4da3-bc32-51e763bb5596",
+					},
+				},
+			},
+			throws: true,
+		},
 	}
 
 	for i, test := range tests {
@@ -462,7 +573,7 @@ func TestCreateItems(t *testing.T) {
 
 		assert := assert.New(t)
 
-		output, err := createItems(
+		output, _, err := createItems(
 			ctx,
 			test.input.linnetFields,
 			test.input.dataSource,
@@ -549,3 +660,122 @@ func TestCreateItems(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateItemsClientIDs(t *testing.T) {
+	edgeTypes := []types.Edge{
+		types.Edge{
+			TypeName:    "Customer",
+			Field:       "orders",
+			FieldType:   "Order",
+			EdgeName:    "OrdersOnCustomer",
+			Cardinality: "MANY",
+			Principal:   "TRUE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Order",
+				Field:    "customer",
+			},
+		},
+		types.Edge{
+			TypeName:    "Order",
+			Field:       "customer",
+			FieldType:   "Customer",
+			EdgeName:    "OrdersOnCustomer",
+			Cardinality: "ONE",
+			Principal:   "FALSE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Customer",
+				Field:    "orders",
+			},
+		},
+	}
+
+	type Output struct {
+		// ids of the Nodes created
+		ids []string
+		err string
+	}
+
+	tests := []struct {
+		createInput map[string]interface{}
+		output      Output
+	}{
+		{
+			createInput: map[string]interface{}{
+				"data": map[string]interface{}{
+					"id":   "customer-1",
+					"name": "customer name",
+					"orders": map[string]interface{}{
+						"data": []interface{}{
+							map[string]interface{}{"id": "order-1"},
+							map[string]interface{}{"status": "PENDING"},
+						},
+					},
+				},
+			},
+			output: Output{
+				ids: []string{"customer-1", "order-1", ""},
+			},
+		},
+		{
+			createInput: map[string]interface{}{
+				"data": map[string]interface{}{
+					"id": "customer::1",
+				},
+			},
+			output: Output{
+				err: "Cannot create Customer, id customer::1 must be 1 to 128 letters, numbers, - or _",
+			},
+		},
+		{
+			createInput: map[string]interface{}{
+				"data": map[string]interface{}{
+					"orders": map[string]interface{}{
+						"data": map[string]interface{}{"id": ""},
+					},
+				},
+			},
+			output: Output{
+				err: "Cannot create Order, id  must be 1 to 128 letters, numbers, - or _",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestCreateItemsClientIDs")
+		assert := assert.New(t)
+
+		items, _, err := createItems(
+			ctx,
+			constants.LinnetFields,
+			types.DataSourceDynamoDBConfig{},
+			"Customer",
+			edgeTypes,
+			"",
+			"",
+			time.Unix(1517446800, 10),
+			time.Unix(1517446800, 10),
+			"linnet",
+			test.createInput,
+		)
+
+		if test.output.err != "" {
+			assert.EqualError(err, test.output.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+
+		// Generated ids are not known, so they are matched as ""
+		var ids []string
+		for _, item := range items {
+			if item["linnet:dataType"] != "Node" {
+				continue
+			}
+			id := item["id"].(string)
+			if !strings.HasPrefix(id, "customer-") && !strings.HasPrefix(id, "order-") {
+				id = ""
+			}
+			ids = append(ids, id)
+		}
+		assert.ElementsMatch(test.output.ids, ids, fmt.Sprintf("Test %d", i))
+	}
+}
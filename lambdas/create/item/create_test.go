@@ -3,10 +3,13 @@ package item
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
@@ -16,8 +19,20 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// mockDynamoDBClient accepts every put, except the Nodes of failNamedType
+// which return failWith. Items are kept in stored until they are deleted.
+// Nodes in existing are in the table before the test, so they are read by
+// id with their namedType, and refused by attribute_not_exists(id). Every
+// query returns failQuery.
 type mockDynamoDBClient struct {
 	dynamodbiface.DynamoDBAPI
+	failNamedType string
+	failWith      error
+	existing      map[string]string
+	failQuery     error
+
+	mutex  sync.Mutex
+	stored map[string]bool
 }
 
 func (m *mockDynamoDBClient) PutItemWithContext(
@@ -28,14 +43,103 @@ func (m *mockDynamoDBClient) PutItemWithContext(
 	*dynamodb.PutItemOutput,
 	error,
 ) {
-	fmt.Println(input)
-	return nil, nil
+	if m.failWith != nil &&
+		*input.Item["linnet:dataType"].S == "Node" &&
+		*input.Item["linnet:namedType"].S == m.failNamedType {
+		return nil, m.failWith
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stored == nil {
+		m.stored = make(map[string]bool)
+	}
+
+	// attribute_not_exists(id)
+	if _, exists := m.existing[*input.Item["id"].S]; exists {
+		return nil, awserr.New(
+			dynamodb.ErrCodeConditionalCheckFailedException,
+			"The conditional request failed",
+			nil,
+		)
+	}
+	m.stored[*input.Item["id"].S+"|"+*input.Item["linnet:dataType"].S] = true
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchGetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchGetItemOutput,
+	error,
+) {
+	output := &dynamodb.BatchGetItemOutput{
+		Responses: make(map[string][]map[string]*dynamodb.AttributeValue),
+	}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		for _, key := range keysAndAttributes.Keys {
+			if namedType, ok := m.existing[*key["id"].S]; ok {
+				output.Responses[tableName] = append(
+					output.Responses[tableName],
+					map[string]*dynamodb.AttributeValue{
+						"id":               key["id"],
+						"linnet:dataType":  key["linnet:dataType"],
+						"linnet:namedType": &dynamodb.AttributeValue{S: aws.String(namedType)},
+					},
+				)
+			}
+		}
+	}
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	return nil, m.failQuery
+}
+
+func (m *mockDynamoDBClient) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchWriteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchWriteItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stored == nil {
+		m.stored = make(map[string]bool)
+	}
+
+	for _, requests := range input.RequestItems {
+		for _, writeRequest := range requests {
+			if writeRequest.DeleteRequest != nil {
+				key := writeRequest.DeleteRequest.Key
+				delete(m.stored, *key["id"].S+"|"+*key["linnet:dataType"].S)
+				continue
+			}
+
+			item := writeRequest.PutRequest.Item
+			m.stored[*item["id"].S+"|"+*item["linnet:dataType"].S] = true
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
 }
 
 func TestCreate(t *testing.T) {
 	type Output struct {
 		response types.Node
 		err      error
+		errors   []string
 	}
 
 	currentTime := time.Unix(1517446800, 10)
@@ -217,6 +321,37 @@ func TestCreate(t *testing.T) {
 				err: nil,
 			},
 		},
+		{
+			event: types.LambdaEvent{
+				LinnetFields: constants.LinnetFields,
+				DataSource: types.DataSourceDynamoDBConfig{
+					UseCallerCredentials: false,
+					AwsRegion:            "us-east-1",
+					TableName:            "DynamoDBTestTable",
+				},
+				NamedType: "Order",
+				EdgeTypes: edgeTypes,
+				Context: types.LinnetResolverContext{Arguments: map[string]interface{}{
+					"data": map[string]interface{}{
+						"status": "PENDING",
+						"customer": map[string]interface{}{
+							"connection": []interface{}{
+								"43a67e91-c1b8-4da3-bc32-51e763bb5596",
+								"5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c",
+							},
+						},
+					},
+				},
+				},
+			},
+			throws: true,
+			output: Output{
+				errors: []string{
+					"Order.customer can only have ONE OrdersOnCustomer edge, %s has 2",
+					"Order.products requires a ProductsOnOrders edge, %s has none",
+				},
+			},
+		},
 	}
 
 	for i, test := range tests {
@@ -236,6 +371,17 @@ func TestCreate(t *testing.T) {
 		)
 		if test.throws {
 			assert.NotNil(err)
+
+			// The root node id is generated, so it is added to the errors
+			var expected []string
+			for _, expectedError := range test.output.errors {
+				expected = append(expected, fmt.Sprintf(expectedError, err[0].(types.EdgeCardinalityError).NodeID))
+			}
+			var errors []string
+			for _, e := range err {
+				errors = append(errors, e.Error())
+			}
+			assert.Equal(expected, errors, fmt.Sprintf("Test %d", i))
 		} else {
 			assert.Nil(err)
 			fieldsToMatch := len(test.output.response)
@@ -258,3 +404,342 @@ func TestCreate(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateWriteErrors(t *testing.T) {
+	currentTime := time.Unix(1517446800, 10)
+
+	event := types.LambdaEvent{
+		LinnetFields: constants.LinnetFields,
+		DataSource: types.DataSourceDynamoDBConfig{
+			TableName: "DynamoDBTestTable",
+		},
+		NamedType: "Product",
+		Context: types.LinnetResolverContext{
+			Arguments: map[string]interface{}{
+				"data": []interface{}{
+					map[string]interface{}{
+						"title": "Test Product 1",
+					},
+					map[string]interface{}{
+						"title": "Test Product 2",
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		failWith error
+		kind     types.WriteErrorKind
+		message  string
+	}{
+		{
+			failWith: awserr.New(
+				dynamodb.ErrCodeConditionalCheckFailedException,
+				"The conditional request failed",
+				nil,
+			),
+			kind:    types.CONDITION_FAILED,
+			message: ", an item with this id already exists",
+		},
+		{
+			failWith: awserr.New(
+				dynamodb.ErrCodeProvisionedThroughputExceededException,
+				"The level of configured provisioned throughput for the table was exceeded",
+				nil,
+			),
+			kind:    types.THROTTLED,
+			message: ", DynamoDB is throttling writes, try again",
+		},
+		{
+			failWith: awserr.New(
+				"ValidationException",
+				"Item size has exceeded the maximum allowed size",
+				nil,
+			),
+			kind:    types.VALIDATION,
+			message: ", it is not valid: ValidationException: Item size has exceeded the maximum allowed size",
+		},
+		{
+			failWith: awserr.New(
+				dynamodb.ErrCodeResourceNotFoundException,
+				"Requested resource not found",
+				nil,
+			),
+			kind:    types.UNKNOWN,
+			message: ", ResourceNotFoundException: Requested resource not found",
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestCreateWriteErrors")
+		assert := assert.New(t)
+
+		output, errors := Create(
+			ctx,
+			&event,
+			&mockDynamoDBClient{
+				failNamedType: "Product",
+				failWith:      test.failWith,
+			},
+			aws.String(event.DataSource.TableName),
+			currentTime,
+		)
+
+		// Nothing is returned as created when a write failed
+		assert.Nil(output, fmt.Sprintf("Test %d", i))
+		if !assert.Len(errors, 1, fmt.Sprintf("Test %d", i)) {
+			continue
+		}
+		rollbackError, ok := errors[0].(types.RollbackError)
+		if !assert.True(ok, fmt.Sprintf("Test %d", i)) {
+			continue
+		}
+		assert.Len(rollbackError.Failed, 2, fmt.Sprintf("Test %d", i))
+
+		for _, err := range rollbackError.Failed {
+			writeError, ok := err.(types.WriteError)
+			if !assert.True(ok, fmt.Sprintf("Test %d", i)) {
+				continue
+			}
+			assert.Equal(test.kind, writeError.Kind, fmt.Sprintf("Test %d", i))
+			assert.Equal(test.failWith, writeError.Err, fmt.Sprintf("Test %d", i))
+			assert.Regexp(
+				"^Cannot write Product [0-9a-f-]{36}"+regexp.QuoteMeta(test.message)+"$",
+				err.Error(),
+				fmt.Sprintf("Test %d", i),
+			)
+		}
+	}
+}
+
+func TestCreateRollback(t *testing.T) {
+	ctx, _ := xray.BeginSegment(context.Background(), "TestCreateRollback")
+	assert := assert.New(t)
+
+	edgeTypes := []types.Edge{
+		types.Edge{
+			TypeName:    "Customer",
+			Field:       "orders",
+			FieldType:   "Order",
+			EdgeName:    "OrdersOnCustomer",
+			Cardinality: "MANY",
+			Principal:   "TRUE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Order",
+				Field:    "customer",
+			},
+		},
+		types.Edge{
+			TypeName:    "Order",
+			Field:       "customer",
+			FieldType:   "Customer",
+			EdgeName:    "OrdersOnCustomer",
+			Cardinality: "ONE",
+			Principal:   "FALSE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Customer",
+				Field:    "orders",
+			},
+		},
+	}
+
+	event := types.LambdaEvent{
+		LinnetFields: constants.LinnetFields,
+		DataSource: types.DataSourceDynamoDBConfig{
+			TableName: "DynamoDBTestTable",
+		},
+		NamedType: "Customer",
+		EdgeTypes: edgeTypes,
+		Context: types.LinnetResolverContext{
+			Arguments: map[string]interface{}{
+				"data": map[string]interface{}{
+					"name": "customer name",
+					"orders": map[string]interface{}{
+						"data": []interface{}{
+							map[string]interface{}{"status": "PENDING"},
+							map[string]interface{}{"status": "COMPLETE"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	mockDynamoDB := &mockDynamoDBClient{
+		failNamedType: "Order",
+		failWith: awserr.New(
+			dynamodb.ErrCodeProvisionedThroughputExceededException,
+			"The level of configured provisioned throughput for the table was exceeded",
+			nil,
+		),
+	}
+
+	output, errors := Create(
+		ctx,
+		&event,
+		mockDynamoDB,
+		aws.String(event.DataSource.TableName),
+		time.Unix(1517446800, 10),
+	)
+
+	// The Customer was written then removed again, and the edges were
+	// never written as the Orders failed
+	assert.Nil(output)
+	assert.Empty(mockDynamoDB.stored)
+	if assert.Len(errors, 1) {
+		rollbackError, ok := errors[0].(types.RollbackError)
+		if assert.True(ok) {
+			assert.Len(rollbackError.Failed, 2)
+			assert.Equal(1, rollbackError.Written)
+			assert.Equal(rollbackError.Written, rollbackError.Removed)
+			assert.NoError(rollbackError.RollbackErr)
+			assert.Regexp(
+				"^Cannot create Customer, 2 of [0-9]+ items were not written and the [0-9]+ written were removed: Cannot write Order ",
+				errors[0].Error(),
+			)
+		}
+	}
+}
+
+func TestCreateDuplicateClientID(t *testing.T) {
+	ctx, _ := xray.BeginSegment(context.Background(), "TestCreateDuplicateClientID")
+	assert := assert.New(t)
+
+	event := types.LambdaEvent{
+		LinnetFields: constants.LinnetFields,
+		DataSource: types.DataSourceDynamoDBConfig{
+			TableName: "DynamoDBTestTable",
+		},
+		NamedType: "Product",
+		Context: types.LinnetResolverContext{
+			Arguments: map[string]interface{}{
+				"data": map[string]interface{}{
+					"id":    "product-1",
+					"title": "Test Product 1",
+				},
+			},
+		},
+	}
+
+	mockDynamoDB := &mockDynamoDBClient{}
+
+	output, errors := Create(
+		ctx,
+		&event,
+		mockDynamoDB,
+		aws.String(event.DataSource.TableName),
+		time.Unix(1517446800, 10),
+	)
+	assert.Nil(errors)
+	if assert.NotNil(output) {
+		assert.Equal("product-1", output["id"])
+	}
+
+	// The second create finds the Node the first one wrote
+	mockDynamoDB.existing = map[string]string{"product-1": "Product"}
+
+	output, errors = Create(
+		ctx,
+		&event,
+		mockDynamoDB,
+		aws.String(event.DataSource.TableName),
+		time.Unix(1517446800, 10),
+	)
+
+	// The Node of the first create is not overwritten or removed
+	assert.Nil(output)
+	assert.True(mockDynamoDB.stored["product-1|Node"])
+	if assert.Len(errors, 1) {
+		rollbackError, ok := errors[0].(types.RollbackError)
+		if assert.True(ok) && assert.Len(rollbackError.Failed, 1) {
+			writeError, ok := rollbackError.Failed[0].(types.WriteError)
+			if assert.True(ok) {
+				assert.Equal(types.CONDITION_FAILED, writeError.Kind)
+			}
+			assert.EqualError(
+				errors[0],
+				"Cannot create Product, 1 of 1 items were not written and the 0 written were removed: Cannot write Product product-1, an item with this id already exists",
+			)
+		}
+	}
+}
+
+func TestCreateCounterpartError(t *testing.T) {
+	ctx, _ := xray.BeginSegment(context.Background(), "TestCreateCounterpartError")
+	assert := assert.New(t)
+
+	edgeTypes := []types.Edge{
+		types.Edge{
+			TypeName:    "Customer",
+			Field:       "profile",
+			FieldType:   "Profile",
+			EdgeName:    "ProfileOnCustomer",
+			Cardinality: "ONE",
+			Principal:   "TRUE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Profile",
+				Field:    "customer",
+			},
+		},
+		types.Edge{
+			TypeName:    "Profile",
+			Field:       "customer",
+			FieldType:   "Customer",
+			EdgeName:    "ProfileOnCustomer",
+			Cardinality: "ONE",
+			Principal:   "FALSE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Customer",
+				Field:    "profile",
+			},
+		},
+	}
+
+	event := types.LambdaEvent{
+		LinnetFields: constants.LinnetFields,
+		DataSource: types.DataSourceDynamoDBConfig{
+			TableName: "DynamoDBTestTable",
+		},
+		NamedType: "Customer",
+		EdgeTypes: edgeTypes,
+		Context: types.LinnetResolverContext{
+			Arguments: map[string]interface{}{
+				"data": map[string]interface{}{
+					"name": "customer name",
+					"profile": map[string]interface{}{
+						"connection": "profile-1",
+					},
+				},
+			},
+		},
+	}
+
+	failQuery := awserr.New(
+		dynamodb.ErrCodeResourceNotFoundException,
+		"Requested resource not found",
+		nil,
+	)
+	mockDynamoDB := &mockDynamoDBClient{
+		existing:  map[string]string{"profile-1": "Profile"},
+		failQuery: failQuery,
+	}
+
+	output, errors := Create(
+		ctx,
+		&event,
+		mockDynamoDB,
+		aws.String(event.DataSource.TableName),
+		time.Unix(1517446800, 10),
+	)
+
+	// Every item was written, so the Customer is returned with the error
+	// instead of being created again by a retry
+	if assert.NotNil(output) {
+		assert.Equal("customer name", output["name"])
+		assert.True(mockDynamoDB.stored[output["id"].(string)+"|Node"])
+	}
+	if assert.Len(errors, 1) {
+		assert.Equal(failQuery, errors[0])
+	}
+}
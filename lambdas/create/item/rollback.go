@@ -0,0 +1,54 @@
+package item
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// rollback removes the items a failed create wrote, so no orphaned Nodes
+// or edges are left behind. They were never returned as created, so they
+// are deleted rather than tombstoned.
+func rollback(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	written []types.Node,
+) (
+	removed int,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "rollback")
+	defer segment.Close(err)
+
+	var keys []map[string]*dynamodb.AttributeValue
+	for _, item := range written {
+		keys = append(keys, map[string]*dynamodb.AttributeValue{
+			"id": &dynamodb.AttributeValue{
+				S: aws.String(item["id"].(string)),
+			},
+			"linnet:dataType": &dynamodb.AttributeValue{
+				S: aws.String(item["linnet:dataType"].(string)),
+			},
+		})
+	}
+
+	result, err := database.DeleteItems(
+		ctx,
+		dynamo,
+		tableName,
+		keys,
+		MAX_RETRIES,
+	)
+	removed = result.Nodes + result.Edges
+	if err == nil && !result.Complete {
+		err = fmt.Errorf("the Lambda deadline was reached")
+	}
+	return
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/create/item"
+	"github.com/ojkelly/linnet/lambdas/util/database"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
@@ -26,6 +28,29 @@ func processEvent(
 
 	var errs []error
 
+	// A retried create with the same idempotencyKey replays the response
+	// of the first, instead of writing again
+	idempotencyKey, _ := event.Context.Arguments["idempotencyKey"].(string)
+	operation := fmt.Sprintf("create%s", event.NamedType)
+	if idempotencyKey != "" {
+		replay, err := database.ClaimIdempotencyKey(
+			ctx,
+			dynamo,
+			event.DataSource.TableName,
+			idempotencyKey,
+			operation,
+			event.Context.Arguments,
+			currentTime,
+		)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return
+		}
+		if replay != nil {
+			return *replay
+		}
+	}
+
 	response.Data, errs = item.Create(
 		ctx,
 		event,
@@ -40,6 +65,35 @@ func processEvent(
 		}
 	}
 
+	if idempotencyKey != "" {
+		var err error
+		if response.Data == nil {
+			// Nothing was created, so the create can be tried again
+			err = database.ReleaseIdempotencyKey(
+				ctx,
+				dynamo,
+				event.DataSource.TableName,
+				idempotencyKey,
+			)
+		} else {
+			err = database.CompleteIdempotencyKey(
+				ctx,
+				dynamo,
+				event.DataSource.TableName,
+				idempotencyKey,
+				operation,
+				event.Context.Arguments,
+				response,
+				currentTime,
+			)
+		}
+		if err != nil {
+			// The create itself succeeded or failed as reported, so this
+			// is only recorded on the trace
+			segment.AddError(err)
+		}
+	}
+
 	// If successfully created, return a cleaned Root Node
 	return response
 }
@@ -3,107 +3,118 @@ package item
 import (
 	"context"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
-// Delete item by id
-// Also delete all edges where this Node was the PRIMARY edge
+// MAX_RETRIES for the items DynamoDB leaves unprocessed in a HARD delete
+var MAX_RETRIES = 5
+
+// Result of a Delete, with the number of Nodes and Edges deleted.
+// Complete is false when the Lambda deadline was reached first, running
+// the same Delete again finishes it. ReleaseErrors are the @unique guards
+// that could not be released once the Delete finished.
+type Result struct {
+	Nodes         int     `json:"nodes"`
+	Edges         int     `json:"edges"`
+	Complete      bool    `json:"complete"`
+	ReleaseErrors []error `json:"-"`
+}
+
+// Delete item by id, and follow the delete policy on each of its Edges.
+// The edges to every connected Node are always deleted, on both sides.
 //
 // Deletion process is as follows:
-// 1. Items to delete are discoved
-// 2. Items to delete are updated with a NodeState ==
+//  1. Items to delete are discovered with database.PlanDelete, following
+//     CASCADE edges. Nothing is written if a RESTRICT edge has connected
+//     nodes, or the cascade is larger than MAX_CASCADE_DEPTH or
+//     MAX_CASCADE_NODES
+//  2. Items to delete are updated with a linnet:ttl, or removed when the
+//     mode is HARD. The connected Nodes go first, deepest first, then the
+//     edges, and the deleted Node last. Stopping part way leaves every item
+//     still to delete reachable from the deleted Node, so the same Delete
+//     can be run again.
+//
+// A HARD delete also removes items that were already soft deleted, and
+// every edge pointing to a deleted Node found through the edge-dataType
+// index.
 func Delete(
 	ctx context.Context,
 	dynamo dynamodbiface.DynamoDBAPI,
 	tableName *string,
+	namedType string,
+	edgeTypes []types.Edge,
+	uniqueFields []types.UniqueField,
 	id string,
-	ttl string,
+	mode types.DeleteMode,
+	ttl database.TTL,
 ) (
-	deletedCount int,
+	result Result,
 	err error,
 ) {
 	ctx, segment := xray.BeginSubsegment(ctx, "Delete")
 	defer segment.Close(err)
 
-	// First get a list of all the Hash and Sort keys matching this ID
-	itemsToDelete, err := database.FindAllItemsToDeleteWithHashKey(
+	plan, err := database.PlanDelete(
 		ctx,
 		dynamo,
-		tableName,
+		*tableName,
+		namedType,
+		edgeTypes,
 		id,
-		ttl,
+		mode == types.HARD,
 	)
 	if err != nil {
 		return
 	}
 
-	for _, item := range *itemsToDelete {
-		// Then delete everything in that list.
-		deleted, err := database.UpdateItemTTL(
+	// The @unique values of the deleted Nodes are read before a HARD
+	// delete removes them
+	var uniqueValues []database.UniqueValue
+	if len(uniqueFields) > 0 {
+		var nodes []types.Node
+		nodes, err = database.HydrateNodes(
 			ctx,
 			dynamo,
-			tableName,
-			item,
-			ttl,
+			*tableName,
+			plan.NodeIDs(),
+			true,
 		)
 		if err != nil {
-			return deletedCount, err
-		}
-
-		if deleted {
-			deletedCount = deletedCount + 1
+			return
 		}
+		uniqueValues = database.UniqueValues(uniqueFields, nodes)
 	}
-	return deletedCount, err
-}
 
-func findAllItemsWithHashKey(
-	ctx context.Context,
-	dynamo dynamodbiface.DynamoDBAPI,
-	tableName *string,
-	hash string,
-	ttl string,
-) (
-	itemsToDelete *[]map[string]*dynamodb.AttributeValue,
-	err error,
-) {
-	ctx, segment := xray.BeginSubsegment(ctx, "findAllItemsWithHashKey")
-	defer segment.Close(err)
-
-	// TODO: handle pages
-	queryInput := &dynamodb.QueryInput{
-		TableName: tableName,
-		ExpressionAttributeNames: map[string]*string{
-			"#dataType": aws.String("linnet:dataType"),
-			"#ttl":      aws.String("linnet:ttl"),
-		},
-		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":idValue": &dynamodb.AttributeValue{
-				S: aws.String(hash),
-			},
-			":now": &dynamodb.AttributeValue{
-				S: aws.String(ttl),
-			},
-		},
-		KeyConditionExpression: aws.String("id = :idValue"),
-		// We only want the HASH and RANGE keys returned, as that's all we're deleting atm.
-		// We can extend this to linnet:edge at some point in the future, but that
-		// could be almosst unbounded (if you have a lot of data), so we're not handling that
-		// style of cascading deletes yet.
-		ProjectionExpression: aws.String("id, #dataType"),
-		FilterExpression:     aws.String("attribute_not_exists(#ttl) OR #ttl > :now"),
+	deleted, err := database.DeletePlanned(
+		ctx,
+		dynamo,
+		*tableName,
+		plan,
+		mode,
+		ttl,
+		MAX_RETRIES,
+	)
+	result = Result{
+		Nodes:    deleted.Nodes,
+		Edges:    deleted.Edges,
+		Complete: deleted.Complete,
+	}
+	if err != nil || !result.Complete {
+		return result, err
 	}
 
-	queryResult, err := dynamo.QueryWithContext(
+	// The values are free for other Nodes once every Node is deleted
+	releaseErr := database.ReleaseUniqueValues(
 		ctx,
-		queryInput,
+		dynamo,
+		*tableName,
+		uniqueValues,
 	)
-
-	itemsToDelete = &queryResult.Items
-
-	return
+	if releaseErr != nil {
+		result.ReleaseErrors = append(result.ReleaseErrors, releaseErr)
+	}
+	return result, err
 }
@@ -0,0 +1,467 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDynamoDBClient holds every Node and Edge item, and the keys of any
+// items given a ttl are kept in tombstoned as "id|linnet:dataType", and
+// the keys of items removed in removed
+type mockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	mutex      sync.Mutex
+	items      []types.Node
+	tombstoned []string
+	removed    []string
+}
+
+func (m *mockDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	// Every item under a hash key, the edges on a node, or the edges
+	// pointing to a node
+	partitionKeyName := "id"
+	sortKeyValue := ""
+	var partitionKeyValue string
+	if name, ok := input.ExpressionAttributeNames["#partitionKeyName"]; ok {
+		partitionKeyName = *name
+		partitionKeyValue = *input.ExpressionAttributeValues[":partitionKeyValue"].S
+		sortKeyValue = *input.ExpressionAttributeValues[":sortKeyValue"].S
+	} else if value, ok := input.ExpressionAttributeValues[":edgeValue"]; ok {
+		partitionKeyName = "linnet:edge"
+		partitionKeyValue = *value.S
+	} else {
+		partitionKeyValue = *input.ExpressionAttributeValues[":idValue"].S
+	}
+	hideDeleted := input.FilterExpression != nil &&
+		strings.Contains(*input.FilterExpression, "attribute_not_exists(#ttl)")
+
+	output := &dynamodb.QueryOutput{}
+	for _, stored := range m.items {
+		if stored[partitionKeyName] == partitionKeyValue &&
+			(!hideDeleted || stored["linnet:ttl"] == nil) &&
+			strings.HasPrefix(stored["linnet:dataType"].(string), sortKeyValue) {
+			item, err := dynamodbattribute.MarshalMap(stored)
+			if err != nil {
+				return nil, err
+			}
+			output.Items = append(output.Items, item)
+		}
+	}
+	output.ScannedCount = aws.Int64(int64(len(output.Items)))
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) UpdateItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.UpdateItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.UpdateItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, stored := range m.items {
+		if stored["id"] == *input.Key["id"].S &&
+			stored["linnet:dataType"] == *input.Key["linnet:dataType"].S &&
+			stored["linnet:ttl"] == nil {
+			stored["linnet:ttl"] = *input.ExpressionAttributeValues[":ttlValue"].N
+			m.tombstoned = append(
+				m.tombstoned,
+				*input.Key["id"].S+"|"+*input.Key["linnet:dataType"].S,
+			)
+			return &dynamodb.UpdateItemOutput{}, nil
+		}
+	}
+
+	return nil, awserr.New(
+		dynamodb.ErrCodeConditionalCheckFailedException,
+		"The conditional request failed",
+		nil,
+	)
+}
+
+func (m *mockDynamoDBClient) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchWriteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchWriteItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, writeRequest := range input.RequestItems["test-table"] {
+		key := writeRequest.DeleteRequest.Key
+		var kept []types.Node
+		for _, stored := range m.items {
+			if stored["id"] == *key["id"].S &&
+				stored["linnet:dataType"] == *key["linnet:dataType"].S {
+				continue
+			}
+			kept = append(kept, stored)
+		}
+		m.items = kept
+		m.removed = append(
+			m.removed,
+			*key["id"].S+"|"+*key["linnet:dataType"].S,
+		)
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func TestDelete(t *testing.T) {
+	type Output struct {
+		result     Result
+		tombstoned []string
+		removed    []string
+		err        string
+	}
+
+	edgeTypes := func(
+		ordersOnDelete types.EdgeDeletePolicy,
+		customerOnDelete types.EdgeDeletePolicy,
+		itemsOnDelete types.EdgeDeletePolicy,
+	) []types.Edge {
+		return []types.Edge{
+			types.Edge{
+				TypeName:    "Customer",
+				Field:       "orders",
+				FieldType:   "Order",
+				EdgeName:    "OrdersOnCustomer",
+				Cardinality: "MANY",
+				Principal:   "TRUE",
+				OnDelete:    ordersOnDelete,
+				Counterpart: types.EdgeCounterpart{
+					TypeName: "Order",
+					Field:    "customer",
+				},
+			},
+			types.Edge{
+				TypeName:    "Order",
+				Field:       "customer",
+				FieldType:   "Customer",
+				EdgeName:    "OrdersOnCustomer",
+				Cardinality: "ONE",
+				Principal:   "FALSE",
+				OnDelete:    customerOnDelete,
+				Counterpart: types.EdgeCounterpart{
+					TypeName: "Customer",
+					Field:    "orders",
+				},
+			},
+			types.Edge{
+				TypeName:    "Order",
+				Field:       "items",
+				FieldType:   "Item",
+				EdgeName:    "ItemsOnOrder",
+				Cardinality: "MANY",
+				Principal:   "TRUE",
+				OnDelete:    itemsOnDelete,
+				Counterpart: types.EdgeCounterpart{
+					TypeName: "Item",
+					Field:    "order",
+				},
+			},
+		}
+	}
+
+	items := func() []types.Node {
+		node := func(id string, namedType string) types.Node {
+			return types.Node{
+				"id":               id,
+				"linnet:dataType":  "Node",
+				"linnet:namedType": namedType,
+			}
+		}
+		edge := func(edgeName string, nodeID string, edgeID string) types.Node {
+			return types.Node{
+				"id":              nodeID,
+				"linnet:dataType": edgeName + "::" + edgeID,
+				"linnet:edge":     edgeID,
+			}
+		}
+		deleted := func(item types.Node) types.Node {
+			item["linnet:ttl"] = "1517446800"
+			return item
+		}
+		return []types.Node{
+			node("customer-1", "Customer"),
+			node("order-1", "Order"),
+			node("order-2", "Order"),
+			node("item-1", "Item"),
+			deleted(node("item-2", "Item")),
+			edge("OrdersOnCustomer", "customer-1", "order-1"),
+			edge("OrdersOnCustomer", "customer-1", "order-2"),
+			edge("ItemsOnOrder", "order-1", "item-1"),
+			deleted(edge("ItemsOnOrder", "order-2", "item-2")),
+		}
+	}
+
+	tests := []struct {
+		edgeTypes []types.Edge
+		namedType string
+		id        string
+		mode      types.DeleteMode
+		maxDepth  int
+		output    Output
+	}{
+		{
+			// DETACH only removes the edges
+			edgeTypes: edgeTypes(types.DETACH, types.DETACH, types.DETACH),
+			namedType: "Customer",
+			id:        "customer-1",
+			maxDepth:  5,
+			output: Output{
+				result: Result{Nodes: 1, Edges: 2, Complete: true},
+				tombstoned: []string{
+					"customer-1|Node",
+					"customer-1|OrdersOnCustomer::order-1",
+					"customer-1|OrdersOnCustomer::order-2",
+				},
+			},
+		},
+		{
+			// CASCADE follows each edge, Order.customer leads back to the
+			// Customer already being deleted
+			edgeTypes: edgeTypes(types.CASCADE, types.CASCADE, types.CASCADE),
+			namedType: "Customer",
+			id:        "customer-1",
+			maxDepth:  5,
+			output: Output{
+				result: Result{Nodes: 4, Edges: 3, Complete: true},
+				tombstoned: []string{
+					"customer-1|Node",
+					"customer-1|OrdersOnCustomer::order-1",
+					"customer-1|OrdersOnCustomer::order-2",
+					"order-1|Node",
+					"order-1|ItemsOnOrder::item-1",
+					"order-2|Node",
+					"item-1|Node",
+				},
+			},
+		},
+		{
+			edgeTypes: edgeTypes(types.CASCADE, types.DETACH, types.CASCADE),
+			namedType: "Customer",
+			id:        "customer-1",
+			maxDepth:  1,
+			output: Output{
+				err: "Cannot delete Customer customer-1, the cascade is deeper than 1 edges",
+			},
+		},
+		{
+			edgeTypes: edgeTypes(types.RESTRICT, types.DETACH, types.DETACH),
+			namedType: "Customer",
+			id:        "customer-1",
+			maxDepth:  5,
+			output: Output{
+				err: "Cannot delete Customer customer-1, Customer.orders is RESTRICT and has 2 OrdersOnCustomer edges",
+			},
+		},
+		{
+			// A RESTRICT edge to a node deleted by the cascade is allowed
+			edgeTypes: edgeTypes(types.CASCADE, types.RESTRICT, types.DETACH),
+			namedType: "Customer",
+			id:        "customer-1",
+			maxDepth:  5,
+			output: Output{
+				result: Result{Nodes: 3, Edges: 3, Complete: true},
+				tombstoned: []string{
+					"customer-1|Node",
+					"customer-1|OrdersOnCustomer::order-1",
+					"customer-1|OrdersOnCustomer::order-2",
+					"order-1|Node",
+					"order-1|ItemsOnOrder::item-1",
+					"order-2|Node",
+				},
+			},
+		},
+		{
+			// The edge is stored under the Customer, and found from the Order
+			edgeTypes: edgeTypes(types.DETACH, types.DETACH, types.DETACH),
+			namedType: "Order",
+			id:        "order-2",
+			maxDepth:  5,
+			output: Output{
+				result: Result{Nodes: 1, Edges: 1, Complete: true},
+				tombstoned: []string{
+					"order-2|Node",
+					"customer-1|OrdersOnCustomer::order-2",
+				},
+			},
+		},
+		{
+			edgeTypes: edgeTypes(types.DETACH, types.DETACH, types.DETACH),
+			namedType: "Customer",
+			id:        "customer-2",
+			maxDepth:  5,
+			output: Output{
+				err: "Customer customer-2 does not exist",
+			},
+		},
+		{
+			// A Customer is not deleted through the policies of Order, so
+			// Customer.orders cannot be bypassed
+			edgeTypes: edgeTypes(types.RESTRICT, types.DETACH, types.DETACH),
+			namedType: "Order",
+			id:        "customer-1",
+			maxDepth:  5,
+			output: Output{
+				err: "Order customer-1 does not exist",
+			},
+		},
+		{
+			// HARD removes the items, including the edge already deleted
+			edgeTypes: edgeTypes(types.DETACH, types.DETACH, types.DETACH),
+			namedType: "Order",
+			id:        "order-2",
+			mode:      types.HARD,
+			maxDepth:  5,
+			output: Output{
+				result: Result{Nodes: 1, Edges: 2, Complete: true},
+				removed: []string{
+					"order-2|Node",
+					"order-2|ItemsOnOrder::item-2",
+					"customer-1|OrdersOnCustomer::order-2",
+				},
+			},
+		},
+		{
+			// A Node in the trash can only be removed with HARD
+			edgeTypes: edgeTypes(types.DETACH, types.DETACH, types.DETACH),
+			namedType: "Item",
+			id:        "item-2",
+			maxDepth:  5,
+			output: Output{
+				err: "Item item-2 does not exist",
+			},
+		},
+		{
+			// The edge pointing to it is found through the edge-dataType index
+			edgeTypes: edgeTypes(types.DETACH, types.DETACH, types.DETACH),
+			namedType: "Item",
+			id:        "item-2",
+			mode:      types.HARD,
+			maxDepth:  5,
+			output: Output{
+				result: Result{Nodes: 1, Edges: 1, Complete: true},
+				removed: []string{
+					"item-2|Node",
+					"order-2|ItemsOnOrder::item-2",
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestDelete")
+		assert := assert.New(t)
+
+		database.MAX_CASCADE_DEPTH = test.maxDepth
+
+		mockSvc := &mockDynamoDBClient{
+			items: items(),
+		}
+
+		result, err := Delete(
+			ctx,
+			mockSvc,
+			aws.String("test-table"),
+			test.namedType,
+			test.edgeTypes,
+			nil,
+			test.id,
+			test.mode,
+			database.TTL(1517446800),
+		)
+
+		if test.output.err != "" {
+			assert.EqualError(
+				err,
+				test.output.err,
+				fmt.Sprintf("Test %d", i),
+			)
+			assert.Empty(
+				mockSvc.tombstoned,
+				fmt.Sprintf("Test %d", i),
+			)
+			continue
+		}
+
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(
+			test.output.result,
+			result,
+			fmt.Sprintf("Test %d", i),
+		)
+		assert.ElementsMatch(
+			test.output.tombstoned,
+			mockSvc.tombstoned,
+			fmt.Sprintf("Test %d", i),
+		)
+		assert.ElementsMatch(
+			test.output.removed,
+			mockSvc.removed,
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+	database.MAX_CASCADE_DEPTH = 5
+
+	// A Delete that reaches its deadline stops before tombstoning anything,
+	// and running the same Delete again finishes the work
+	ctx, _ := xray.BeginSegment(context.Background(), "TestDelete")
+	assert := assert.New(t)
+
+	mockSvc := &mockDynamoDBClient{
+		items: items(),
+	}
+	doneCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	for _, deleteCtx := range []context.Context{doneCtx, ctx} {
+		result, err := Delete(
+			deleteCtx,
+			mockSvc,
+			aws.String("test-table"),
+			"Customer",
+			edgeTypes(types.CASCADE, types.DETACH, types.CASCADE),
+			nil,
+			"customer-1",
+			types.SOFT,
+			database.TTL(1517446800),
+		)
+		assert.NoError(err)
+
+		if deleteCtx == doneCtx {
+			assert.Equal(Result{}, result)
+			assert.Empty(mockSvc.tombstoned)
+			continue
+		}
+		assert.Equal(Result{Nodes: 4, Edges: 3, Complete: true}, result)
+		assert.Len(mockSvc.tombstoned, 7)
+	}
+}
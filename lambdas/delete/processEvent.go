@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
-	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/delete/item"
+	"github.com/ojkelly/linnet/lambdas/util/database"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
@@ -16,6 +16,7 @@ func processEvent(
 	ctx context.Context,
 	dynamo *dynamodb.DynamoDB,
 	event *types.LambdaEvent,
+	currentTime time.Time,
 ) (
 	response types.LambdaResponse,
 	err error,
@@ -36,29 +37,94 @@ func processEvent(
 		return
 	}
 
-	var ttl string
-	if event.Context.Arguments["set"] != nil &&
-		event.Context.Arguments["set"].(map[string]interface{})["timeToLive"] != nil {
-		ttl = strconv.FormatInt(
-			int64(event.Context.Arguments["set"].(map[string]interface{})["timeToLive"].(float64)),
-			10,
+	var mode types.DeleteMode
+	var ttl database.TTL
+
+	if token, ok := event.Context.Arguments["continuation"].(string); ok && token != "" {
+		// Finish a Delete that stopped at its deadline, with the same mode
+		// and ttl
+		continuation, continuationErr := database.DecodeDeleteContinuation(
+			token,
+			currentTime,
+		)
+		if continuationErr != nil {
+			response.Errors = append(response.Errors, continuationErr.Error())
+			return
+		}
+		if continuation.IDs[0] != deleteID {
+			response.Errors = append(
+				response.Errors,
+				"Cannot continue delete, the continuation is not valid",
+			)
+			return
+		}
+		mode, err = types.ParseDeleteMode(
+			string(continuation.Mode),
+			event.DeleteMode,
 		)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return response, nil
+		}
+		ttl = continuation.TTL
 	} else {
-		// Set the ttl to expire at
-		now := time.Now()
-		ttl = strconv.FormatInt(now.Unix(), 10)
+		modeArgument, _ := event.Context.Arguments["mode"].(string)
+		mode, err = types.ParseDeleteMode(modeArgument, event.DeleteMode)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return response, nil
+		}
+
+		var timeToLive *int64
+		if set, ok := event.Context.Arguments["set"].(map[string]interface{}); ok {
+			if value, ok := types.IntValue(set["timeToLive"]); ok {
+				timeToLive = aws.Int64(value)
+			}
+		}
+
+		ttl, err = database.ParseTTL(timeToLive, currentTime)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return response, nil
+		}
 	}
 
-	deletedCount, err := item.Delete(
+	result, deleteErr := item.Delete(
 		ctx,
 		dynamo,
 		aws.String(event.DataSource.TableName),
+		event.NamedType,
+		event.EdgeTypes,
+		event.UniqueFields,
 		deleteID,
+		mode,
 		ttl,
 	)
+	if deleteErr != nil {
+		response.Errors = append(response.Errors, deleteErr.Error())
+		return
+	}
+	for _, releaseErr := range result.ReleaseErrors {
+		response.Errors = append(response.Errors, releaseErr.Error())
+	}
+
+	var continuationToken string
+	if !result.Complete {
+		continuationToken, err = database.DeleteContinuation{
+			IDs:  []string{deleteID},
+			Mode: mode,
+			TTL:  ttl,
+		}.Encode()
+		if err != nil {
+			return
+		}
+	}
 
 	response.Data = map[string]interface{}{
-		"count": deletedCount,
+		"count":        result.Nodes + result.Edges,
+		"nodes":        result.Nodes,
+		"edges":        result.Edges,
+		"continuation": continuationToken,
 	}
 
 	return
@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
 )
 
 var dynamo *dynamodb.DynamoDB
@@ -25,7 +27,7 @@ func handler(ctx context.Context, evt json.RawMessage) (response []byte, err err
 
 	// Unmarshall the Event
 	var event DeleteLambdaEvent
-	err = json.Unmarshal(evt, &event)
+	err = util.UnmarshalEvent(evt, &event)
 	if err != nil {
 		return
 	}
@@ -35,6 +37,7 @@ func handler(ctx context.Context, evt json.RawMessage) (response []byte, err err
 		ctx,
 		dynamo,
 		&event,
+		time.Now(),
 	)
 	if err != nil {
 		return
@@ -6,57 +6,118 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
-// Delete item by id
-// Also delete all edges where this Node was the PRIMARY edge
+// MAX_RETRIES for the items DynamoDB leaves unprocessed in a HARD delete
+var MAX_RETRIES = 5
+
+// Result of a DeleteMany, with the number of Nodes and Edges deleted.
+// Remaining holds the ids not yet deleted, when the Lambda deadline was
+// reached or an error stopped it first. ReleaseErrors are the @unique
+// guards that could not be released for the Nodes deleted.
+type Result struct {
+	Nodes         int
+	Edges         int
+	Remaining     []string
+	ReleaseErrors []error
+}
+
+// DeleteMany items by id, following the delete policy on each of their
+// Edges the same way Delete does.
 //
-// Deletion process is as follows:
-// 1. Items to delete are discoved
-// 2. Items to delete are updated with a NodeState ==
+// Deletion process is as follows, for each id:
+//  1. Items to delete are discovered with database.PlanDelete. A RESTRICT
+//     edge with connected nodes, or a cascade that is too large, stops the
+//     DeleteMany at this id, and the ids before it stay deleted.
+//  2. Items to delete are updated with a linnet:ttl, or removed when the
+//     mode is HARD. The Node goes last, so an id that was stopped part way
+//     can be deleted again.
+//
+// An id whose Node is already deleted is skipped.
 func DeleteMany(
 	ctx context.Context,
 	dynamo dynamodbiface.DynamoDBAPI,
 	tableName *string,
+	namedType string,
+	edgeTypes []types.Edge,
+	uniqueFields []types.UniqueField,
 	ids []string,
-	ttl string,
+	mode types.DeleteMode,
+	ttl database.TTL,
 ) (
-	deletedCount int,
+	result Result,
 	err error,
 ) {
-	ctx, segment := xray.BeginSubsegment(ctx, "Delete")
+	ctx, segment := xray.BeginSubsegment(ctx, "DeleteMany")
 	defer segment.Close(err)
 
-	for _, id := range ids {
-		// First get a list of all the Hash and Sort keys matching this ID
-		itemsToDelete, err := database.FindAllItemsToDeleteWithHashKey(
+	for i, id := range ids {
+		if database.PastDeadline(ctx) {
+			result.Remaining = ids[i:]
+			return
+		}
+
+		plan, err := database.PlanDelete(
 			ctx,
 			dynamo,
-			tableName,
+			*tableName,
+			namedType,
+			edgeTypes,
 			id,
-			ttl,
+			mode == types.HARD,
 		)
+		if _, notFound := err.(types.NodeNotFoundError); notFound {
+			continue
+		}
 		if err != nil {
-			return deletedCount, err
+			result.Remaining = ids[i:]
+			return result, err
 		}
 
-		for _, item := range *itemsToDelete {
-			// Then delete everything in that list.
-			deleted, err := database.UpdateItemTTL(
+		// The @unique values of the deleted Nodes are read before a HARD
+		// delete removes them
+		var uniqueValues []database.UniqueValue
+		if len(uniqueFields) > 0 {
+			nodes, err := database.HydrateNodes(
 				ctx,
 				dynamo,
-				tableName,
-				item,
-				ttl,
+				*tableName,
+				plan.NodeIDs(),
+				true,
 			)
 			if err != nil {
-				return deletedCount, err
+				result.Remaining = ids[i:]
+				return result, err
 			}
+			uniqueValues = database.UniqueValues(uniqueFields, nodes)
+		}
 
-			if deleted {
-				deletedCount = deletedCount + 1
-			}
+		deleted, err := database.DeletePlanned(
+			ctx,
+			dynamo,
+			*tableName,
+			plan,
+			mode,
+			ttl,
+			MAX_RETRIES,
+		)
+		result.Nodes = result.Nodes + deleted.Nodes
+		result.Edges = result.Edges + deleted.Edges
+		if err != nil || !deleted.Complete {
+			result.Remaining = ids[i:]
+			return result, err
+		}
+
+		releaseErr := database.ReleaseUniqueValues(
+			ctx,
+			dynamo,
+			*tableName,
+			uniqueValues,
+		)
+		if releaseErr != nil {
+			result.ReleaseErrors = append(result.ReleaseErrors, releaseErr)
 		}
 	}
-	return deletedCount, err
+	return
 }
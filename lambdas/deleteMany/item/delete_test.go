@@ -0,0 +1,250 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDeleteDynamoDBClient holds every Node and Edge item, and the keys of
+// any items given a ttl are kept in tombstoned as "id|linnet:dataType"
+type mockDeleteDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	mutex      sync.Mutex
+	items      []types.Node
+	tombstoned []string
+}
+
+func (m *mockDeleteDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	// Every item under a hash key, or the edges on a node
+	partitionKeyName := "id"
+	sortKeyValue := ""
+	var partitionKeyValue string
+	if name, ok := input.ExpressionAttributeNames["#partitionKeyName"]; ok {
+		partitionKeyName = *name
+		partitionKeyValue = *input.ExpressionAttributeValues[":partitionKeyValue"].S
+		sortKeyValue = *input.ExpressionAttributeValues[":sortKeyValue"].S
+	} else {
+		partitionKeyValue = *input.ExpressionAttributeValues[":idValue"].S
+	}
+	hideDeleted := input.FilterExpression != nil &&
+		strings.Contains(*input.FilterExpression, "attribute_not_exists(#ttl)")
+
+	output := &dynamodb.QueryOutput{}
+	for _, stored := range m.items {
+		if stored[partitionKeyName] == partitionKeyValue &&
+			(!hideDeleted || stored["linnet:ttl"] == nil) &&
+			strings.HasPrefix(stored["linnet:dataType"].(string), sortKeyValue) {
+			item, err := dynamodbattribute.MarshalMap(stored)
+			if err != nil {
+				return nil, err
+			}
+			output.Items = append(output.Items, item)
+		}
+	}
+	output.ScannedCount = aws.Int64(int64(len(output.Items)))
+	return output, nil
+}
+
+func (m *mockDeleteDynamoDBClient) UpdateItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.UpdateItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.UpdateItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, stored := range m.items {
+		if stored["id"] == *input.Key["id"].S &&
+			stored["linnet:dataType"] == *input.Key["linnet:dataType"].S &&
+			stored["linnet:ttl"] == nil {
+			stored["linnet:ttl"] = *input.ExpressionAttributeValues[":ttlValue"].N
+			m.tombstoned = append(
+				m.tombstoned,
+				*input.Key["id"].S+"|"+*input.Key["linnet:dataType"].S,
+			)
+			return &dynamodb.UpdateItemOutput{}, nil
+		}
+	}
+
+	return nil, awserr.New(
+		dynamodb.ErrCodeConditionalCheckFailedException,
+		"The conditional request failed",
+		nil,
+	)
+}
+
+func TestDeleteMany(t *testing.T) {
+	type Output struct {
+		result     Result
+		tombstoned []string
+		err        string
+	}
+
+	edgeTypes := func(ordersOnDelete types.EdgeDeletePolicy) []types.Edge {
+		return []types.Edge{
+			types.Edge{
+				TypeName:    "Customer",
+				Field:       "orders",
+				FieldType:   "Order",
+				EdgeName:    "OrdersOnCustomer",
+				Cardinality: "MANY",
+				Principal:   "TRUE",
+				OnDelete:    ordersOnDelete,
+				Counterpart: types.EdgeCounterpart{
+					TypeName: "Order",
+					Field:    "customer",
+				},
+			},
+			types.Edge{
+				TypeName:    "Order",
+				Field:       "customer",
+				FieldType:   "Customer",
+				EdgeName:    "OrdersOnCustomer",
+				Cardinality: "ONE",
+				Principal:   "FALSE",
+				OnDelete:    types.DETACH,
+				Counterpart: types.EdgeCounterpart{
+					TypeName: "Customer",
+					Field:    "orders",
+				},
+			},
+		}
+	}
+
+	items := func() []types.Node {
+		node := func(id string, namedType string) types.Node {
+			return types.Node{
+				"id":               id,
+				"linnet:dataType":  "Node",
+				"linnet:namedType": namedType,
+			}
+		}
+		edge := func(customerID string, orderID string) types.Node {
+			return types.Node{
+				"id":              customerID,
+				"linnet:dataType": "OrdersOnCustomer::" + orderID,
+				"linnet:edge":     orderID,
+			}
+		}
+		return []types.Node{
+			node("customer-1", "Customer"),
+			node("customer-2", "Customer"),
+			node("order-1", "Order"),
+			node("order-2", "Order"),
+			edge("customer-1", "order-1"),
+			edge("customer-1", "order-2"),
+		}
+	}
+
+	tests := []struct {
+		edgeTypes []types.Edge
+		namedType string
+		ids       []string
+		output    Output
+	}{
+		{
+			// A RESTRICT edge stops the DeleteMany, the ids before it stay
+			// deleted
+			edgeTypes: edgeTypes(types.RESTRICT),
+			namedType: "Customer",
+			ids:       []string{"customer-2", "customer-1"},
+			output: Output{
+				result: Result{
+					Nodes:     1,
+					Remaining: []string{"customer-1"},
+				},
+				tombstoned: []string{"customer-2|Node"},
+				err:        "Cannot delete Customer customer-1, Customer.orders is RESTRICT and has 2 OrdersOnCustomer edges",
+			},
+		},
+		{
+			// CASCADE deletes the connected Nodes
+			edgeTypes: edgeTypes(types.CASCADE),
+			namedType: "Customer",
+			ids:       []string{"customer-1"},
+			output: Output{
+				result: Result{Nodes: 3, Edges: 2},
+				tombstoned: []string{
+					"customer-1|Node",
+					"customer-1|OrdersOnCustomer::order-1",
+					"customer-1|OrdersOnCustomer::order-2",
+					"order-1|Node",
+					"order-2|Node",
+				},
+			},
+		},
+		{
+			// The edges stored under the Customer are deleted with each
+			// Order, and a missing id is skipped
+			edgeTypes: edgeTypes(types.RESTRICT),
+			namedType: "Order",
+			ids:       []string{"order-1", "order-3", "order-2"},
+			output: Output{
+				result: Result{Nodes: 2, Edges: 2},
+				tombstoned: []string{
+					"order-1|Node",
+					"customer-1|OrdersOnCustomer::order-1",
+					"order-2|Node",
+					"customer-1|OrdersOnCustomer::order-2",
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestDeleteMany")
+		assert := assert.New(t)
+
+		mockSvc := &mockDeleteDynamoDBClient{
+			items: items(),
+		}
+
+		result, err := DeleteMany(
+			ctx,
+			mockSvc,
+			aws.String("test-table"),
+			test.namedType,
+			test.edgeTypes,
+			nil,
+			test.ids,
+			types.SOFT,
+			database.TTL(1517446800),
+		)
+
+		if test.output.err != "" {
+			assert.EqualError(err, test.output.err, fmt.Sprintf("Test %d", i))
+		} else {
+			assert.NoError(err, fmt.Sprintf("Test %d", i))
+		}
+		assert.Equal(test.output.result, result, fmt.Sprintf("Test %d", i))
+		assert.ElementsMatch(
+			test.output.tombstoned,
+			mockSvc.tombstoned,
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
@@ -0,0 +1,181 @@
+package item
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	connectionPlural "github.com/ojkelly/linnet/lambdas/connectionPlural/item"
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// Connection selects the Nodes connected to the Node with ID, through
+// Field on the type being deleted
+type Connection struct {
+	Field string `json:"field"`
+	ID    string `json:"id"`
+}
+
+// SelectNodes returns the ids of the Nodes to delete. They are selected by
+// ids, by connection, or when neither is passed every Node of namedType is
+// read through the namedType-id index. The filter is then applied to them.
+// Ids that are not a Node of namedType are left out.
+func SelectNodes(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	edgeTypes []types.Edge,
+	ids []string,
+	connection *Connection,
+	filter types.Filter,
+) (
+	selectedIDs []string,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "SelectNodes")
+	defer segment.Close(err)
+
+	if len(ids) > 0 && connection != nil {
+		err = fmt.Errorf("Cannot DeleteMany %s, pass ids or connection, not both", namedType)
+		return
+	}
+
+	switch {
+	case len(ids) > 0:
+		selectedIDs = util.UniqueIDs(ids)
+
+	case connection != nil:
+		selectedIDs, err = selectConnectedIDs(
+			ctx,
+			dynamo,
+			tableName,
+			namedType,
+			edgeTypes,
+			*connection,
+		)
+		if err != nil {
+			return
+		}
+
+	case len(filter) > 0:
+		// Every Node of namedType is checked against the filter
+		var nodes []types.Node
+		nodes, err = database.QueryNodesByNamedType(
+			ctx,
+			dynamo,
+			tableName,
+			namedType,
+		)
+		if err != nil {
+			return
+		}
+		return filterNodes(ctx, filter, nodes)
+
+	default:
+		err = fmt.Errorf("Cannot DeleteMany %s, no ids, connection or filter passed", namedType)
+		return
+	}
+
+	// The ids passed can be of any Node, so they are read to keep only
+	// the Nodes of namedType
+	if len(selectedIDs) == 0 || (len(filter) == 0 && len(ids) == 0) {
+		return
+	}
+
+	nodes, err := database.HydrateNodes(
+		ctx,
+		dynamo,
+		tableName,
+		selectedIDs,
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodesOfType []types.Node
+	ofType := make(map[string]bool)
+	for _, node := range nodes {
+		if node["linnet:namedType"] == namedType {
+			nodesOfType = append(nodesOfType, node)
+			ofType[node["id"].(string)] = true
+		}
+	}
+
+	if len(filter) == 0 {
+		var typedIDs []string
+		for _, id := range selectedIDs {
+			if ofType[id] {
+				typedIDs = append(typedIDs, id)
+			}
+		}
+		return typedIDs, nil
+	}
+	return filterNodes(ctx, filter, nodesOfType)
+}
+
+// selectConnectedIDs reads every edge on the counterpart of the field, from
+// the Node in the connection
+func selectConnectedIDs(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	edgeTypes []types.Edge,
+	connection Connection,
+) (
+	ids []string,
+	err error,
+) {
+	found, edge := util.GetEdgeFromEdgeTypes(
+		connection.Field,
+		util.GetEdgesOnType(namedType, edgeTypes),
+	)
+	if found {
+		found, edge = util.GetCounterpartEdge(edge, edgeTypes)
+	}
+	if !found {
+		err = fmt.Errorf(
+			"Cannot DeleteMany %s, %s is not an edge on %s",
+			namedType,
+			connection.Field,
+			namedType,
+		)
+		return
+	}
+
+	return database.QueryAllEdges(
+		ctx,
+		dynamo,
+		tableName,
+		connection.ID,
+		edge,
+	)
+}
+
+func filterNodes(
+	ctx context.Context,
+	filter types.Filter,
+	nodes []types.Node,
+) (
+	ids []string,
+	err error,
+) {
+	nodes, err = connectionPlural.FilterNodes(
+		ctx,
+		filter,
+		nodes,
+	)
+	if err != nil {
+		return
+	}
+
+	for _, node := range nodes {
+		ids = append(ids, node["id"].(string))
+	}
+	return
+}
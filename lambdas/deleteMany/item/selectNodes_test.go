@@ -0,0 +1,269 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDynamoDBClient holds every Node and Edge item. A query on the
+// namedType-id index returns the Nodes of that type, any other query
+// returns the edges on a Node.
+type mockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	items []types.Node
+}
+
+func (m *mockDynamoDBClient) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchGetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchGetItemOutput,
+	error,
+) {
+	output := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]*dynamodb.AttributeValue{},
+	}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		for _, key := range keysAndAttributes.Keys {
+			for _, stored := range m.items {
+				if stored["id"] == *key["id"].S &&
+					stored["linnet:dataType"] == *key["linnet:dataType"].S {
+					item, err := dynamodbattribute.MarshalMap(stored)
+					if err != nil {
+						return nil, err
+					}
+					output.Responses[tableName] = append(output.Responses[tableName], item)
+				}
+			}
+		}
+	}
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	output := &dynamodb.QueryOutput{}
+	for _, stored := range m.items {
+		var matches bool
+		if namedType, ok := input.ExpressionAttributeValues[":namedType"]; ok {
+			matches = stored["linnet:namedType"] == *namedType.S &&
+				stored["linnet:dataType"] == "Node"
+		} else {
+			partitionKeyName := *input.ExpressionAttributeNames["#partitionKeyName"]
+			partitionKeyValue := *input.ExpressionAttributeValues[":partitionKeyValue"].S
+			sortKeyValue := *input.ExpressionAttributeValues[":sortKeyValue"].S
+			matches = stored[partitionKeyName] == partitionKeyValue &&
+				strings.HasPrefix(stored["linnet:dataType"].(string), sortKeyValue)
+		}
+
+		if matches {
+			item, err := dynamodbattribute.MarshalMap(stored)
+			if err != nil {
+				return nil, err
+			}
+			output.Items = append(output.Items, item)
+		}
+	}
+	output.Count = aws.Int64(int64(len(output.Items)))
+	output.ScannedCount = aws.Int64(int64(len(output.Items)))
+	return output, nil
+}
+
+func TestSelectNodes(t *testing.T) {
+	type Input struct {
+		ids        []string
+		connection *Connection
+		filter     types.Filter
+	}
+
+	type Output struct {
+		ids []string
+		err string
+	}
+
+	edgeTypes := []types.Edge{
+		types.Edge{
+			TypeName:    "Customer",
+			Field:       "orders",
+			FieldType:   "Order",
+			EdgeName:    "OrdersOnCustomer",
+			Cardinality: "MANY",
+			Principal:   "TRUE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Order",
+				Field:    "customer",
+			},
+		},
+		types.Edge{
+			TypeName:    "Order",
+			Field:       "customer",
+			FieldType:   "Customer",
+			EdgeName:    "OrdersOnCustomer",
+			Cardinality: "ONE",
+			Principal:   "FALSE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Customer",
+				Field:    "orders",
+			},
+		},
+	}
+
+	order := func(id string, status string) types.Node {
+		return types.Node{
+			"id":               id,
+			"linnet:dataType":  "Node",
+			"linnet:namedType": "Order",
+			"status":           status,
+		}
+	}
+	edge := func(customerID string, orderID string) types.Node {
+		return types.Node{
+			"id":               customerID,
+			"linnet:dataType":  "OrdersOnCustomer::" + orderID,
+			"linnet:namedType": "Customer",
+			"linnet:edge":      orderID,
+		}
+	}
+	items := []types.Node{
+		types.Node{
+			"id":               "customer-1",
+			"linnet:dataType":  "Node",
+			"linnet:namedType": "Customer",
+		},
+		order("order-1", "PENDING"),
+		order("order-2", "CANCELLED"),
+		order("order-3", "CANCELLED"),
+		edge("customer-1", "order-1"),
+		edge("customer-1", "order-2"),
+		edge("customer-2", "order-3"),
+	}
+
+	cancelled := types.Filter{
+		"status": types.FilterConfigValue{
+			"equalTo": "CANCELLED",
+		},
+	}
+
+	tests := []struct {
+		input  Input
+		output Output
+	}{
+		{
+			// ids without a filter keep only the Orders that exist
+			input: Input{
+				ids: []string{"order-1", "order-2", "order-1", "order-9"},
+			},
+			output: Output{
+				ids: []string{"order-1", "order-2"},
+			},
+		},
+		{
+			// A Customer is not deleted as an Order
+			input: Input{
+				ids: []string{"customer-1", "order-1"},
+			},
+			output: Output{
+				ids: []string{"order-1"},
+			},
+		},
+		{
+			input: Input{
+				ids:    []string{"order-1", "order-2", "order-9"},
+				filter: cancelled,
+			},
+			output: Output{
+				ids: []string{"order-2"},
+			},
+		},
+		{
+			input: Input{
+				connection: &Connection{Field: "customer", ID: "customer-1"},
+			},
+			output: Output{
+				ids: []string{"order-1", "order-2"},
+			},
+		},
+		{
+			input: Input{
+				connection: &Connection{Field: "customer", ID: "customer-1"},
+				filter:     cancelled,
+			},
+			output: Output{
+				ids: []string{"order-2"},
+			},
+		},
+		{
+			// Every Order is checked against the filter
+			input: Input{
+				filter: cancelled,
+			},
+			output: Output{
+				ids: []string{"order-2", "order-3"},
+			},
+		},
+		{
+			input: Input{},
+			output: Output{
+				err: "Cannot DeleteMany Order, no ids, connection or filter passed",
+			},
+		},
+		{
+			input: Input{
+				connection: &Connection{Field: "items", ID: "customer-1"},
+			},
+			output: Output{
+				err: "Cannot DeleteMany Order, items is not an edge on Order",
+			},
+		},
+		{
+			input: Input{
+				ids:        []string{"order-1"},
+				connection: &Connection{Field: "customer", ID: "customer-1"},
+			},
+			output: Output{
+				err: "Cannot DeleteMany Order, pass ids or connection, not both",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestSelectNodes")
+		assert := assert.New(t)
+
+		ids, err := SelectNodes(
+			ctx,
+			&mockDynamoDBClient{items: items},
+			"test-table",
+			"Order",
+			edgeTypes,
+			test.input.ids,
+			test.input.connection,
+			test.input.filter,
+		)
+
+		if test.output.err != "" {
+			assert.EqualError(err, test.output.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.ElementsMatch(test.output.ids, ids, fmt.Sprintf("Test %d", i))
+	}
+}
@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
-	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/deleteMany/item"
+	"github.com/ojkelly/linnet/lambdas/util/database"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
@@ -16,6 +16,7 @@ func processEvent(
 	ctx context.Context,
 	dynamo *dynamodb.DynamoDB,
 	event *DeleteLambdaEvent,
+	currentTime time.Time,
 ) (
 	response types.LambdaResponse,
 	err error,
@@ -26,42 +27,126 @@ func processEvent(
 	xray.AWS(dynamo.Client)
 
 	var deleteIDs DeleteIDs
+	var mode types.DeleteMode
+	var ttl database.TTL
 
-	if event.Context.Arguments.Where.IDs != nil {
-		deleteIDs = event.Context.Arguments.Where.IDs
+	if event.Context.Arguments.Continuation != "" {
+		// Finish a DeleteMany that stopped at its deadline
+		continuation, continuationErr := database.DecodeDeleteContinuation(
+			event.Context.Arguments.Continuation,
+			currentTime,
+		)
+		if continuationErr != nil {
+			response.Errors = append(response.Errors, continuationErr.Error())
+			return
+		}
+		mode, err = types.ParseDeleteMode(
+			string(continuation.Mode),
+			event.DeleteMode,
+		)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return response, nil
+		}
+		ttl = continuation.TTL
+
+		// The token comes from the client, so its ids are checked to be
+		// Nodes of this type
+		deleteIDs, err = item.SelectNodes(
+			ctx,
+			dynamo,
+			event.DataSource.TableName,
+			event.NamedType,
+			event.EdgeTypes,
+			continuation.IDs,
+			nil,
+			nil,
+		)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return response, nil
+		}
 	} else {
-		response.Errors = append(response.Errors, "Cannot Delete, no ID passed")
-		return
-	}
+		mode, err = types.ParseDeleteMode(
+			event.Context.Arguments.Mode,
+			event.DeleteMode,
+		)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return response, nil
+		}
 
-	now := time.Now()
-	defaultTime := now.Add(time.Duration(-30) * time.Minute).Unix()
-	ttl := strconv.FormatInt(
-		defaultTime,
-		10,
-	)
+		ttl, err = database.ParseTTL(
+			event.Context.Arguments.Set.TimeToLive,
+			currentTime,
+		)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return response, nil
+		}
 
-	// If the provided TTL is more than 30m in the past, it may be invalid
-	// So we use our default.
-	if event.Context.Arguments.Set.TimeToLive < int(defaultTime) {
-		ttl = strconv.FormatInt(
-			int64(event.Context.Arguments.Set.TimeToLive),
-			10,
+		deleteIDs, err = item.SelectNodes(
+			ctx,
+			dynamo,
+			event.DataSource.TableName,
+			event.NamedType,
+			event.EdgeTypes,
+			event.Context.Arguments.Where.IDs,
+			event.Context.Arguments.Where.Connection,
+			event.Context.Arguments.Filter,
 		)
-	} else {
-		// Set the ttl to expire at
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			return response, nil
+		}
+
+		// Only return the ids that would be deleted
+		if event.Context.Arguments.DryRun {
+			response.Data = map[string]interface{}{
+				"count": len(deleteIDs),
+				"ids":   deleteIDs,
+			}
+			return
+		}
 	}
 
-	deletedCount, err := item.DeleteMany(
+	// The Nodes deleted before an error are still counted, and the
+	// continuation holds the ids from the one that failed
+	result, deleteErr := item.DeleteMany(
 		ctx,
 		dynamo,
 		aws.String(event.DataSource.TableName),
+		event.NamedType,
+		event.EdgeTypes,
+		event.UniqueFields,
 		deleteIDs,
+		mode,
 		ttl,
 	)
+	if deleteErr != nil {
+		response.Errors = append(response.Errors, deleteErr.Error())
+	}
+	for _, releaseErr := range result.ReleaseErrors {
+		response.Errors = append(response.Errors, releaseErr.Error())
+	}
+
+	var continuationToken string
+	if len(result.Remaining) > 0 {
+		continuationToken, err = database.DeleteContinuation{
+			IDs:  result.Remaining,
+			Mode: mode,
+			TTL:  ttl,
+		}.Encode()
+		if err != nil {
+			return
+		}
+	}
 
 	response.Data = map[string]interface{}{
-		"count": deletedCount,
+		"count":        result.Nodes + result.Edges,
+		"nodes":        result.Nodes,
+		"edges":        result.Edges,
+		"continuation": continuationToken,
 	}
 
 	return
@@ -1,6 +1,9 @@
 package main
 
-import "github.com/ojkelly/linnet/lambdas/util/types"
+import (
+	"github.com/ojkelly/linnet/lambdas/deleteMany/item"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
 
 // DeleteLambdaEvent -
 type DeleteLambdaEvent struct {
@@ -8,6 +11,8 @@ type DeleteLambdaEvent struct {
 	DataSource   types.DataSourceDynamoDBConfig `json:"dataSource"`
 	NamedType    string                         `json:"namedType"`
 	EdgeTypes    []types.Edge                   `json:"edgeTypes"`
+	UniqueFields []types.UniqueField            `json:"uniqueFields"`
+	DeleteMode   types.DeleteMode               `json:"deleteMode"`
 	Context      DeleteLambdaResolverContext    `json:"context"`
 }
 
@@ -20,8 +25,12 @@ type DeleteLambdaResolverContext struct {
 
 // DeleteLambdaArguments -
 type DeleteLambdaArguments struct {
-	Where WhereArguments `json:"where"`
-	Set   SetArguments   `json:"set"`
+	Where        WhereArguments `json:"where"`
+	Filter       types.Filter   `json:"filter"`
+	Set          SetArguments   `json:"set"`
+	Mode         string         `json:"mode"`
+	DryRun       bool           `json:"dryRun"`
+	Continuation string         `json:"continuation"`
 }
 
 // DeleteIDs-
@@ -29,10 +38,11 @@ type DeleteIDs = []string
 
 // WhereArguments-
 type WhereArguments struct {
-	IDs DeleteIDs `json:"ids"`
+	IDs        DeleteIDs        `json:"ids"`
+	Connection *item.Connection `json:"connection"`
 }
 
 // SetArguments-
 type SetArguments struct {
-	TimeToLive int `json:"timeToLive"`
+	TimeToLive *int64 `json:"timeToLive"`
 }
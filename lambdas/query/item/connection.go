@@ -61,6 +61,7 @@ func Get(
 		edge,
 		limit,
 		"",
+		event.Context.Arguments.IncludeDeleted,
 	)
 	if err != nil {
 		errors = append(errors, err)
@@ -72,6 +73,7 @@ func Get(
 		dynamo,
 		tableName,
 		edges,
+		event.Context.Arguments.IncludeDeleted,
 	)
 	if err != nil {
 		errors = append(errors, err)
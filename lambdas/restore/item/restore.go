@@ -0,0 +1,252 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// Result of a Restore, Skipped is the number of edges that were not
+// restored as the Node on the other side is still deleted. ReleaseErrors
+// are the @unique guards that could not be released when it failed.
+type Result struct {
+	Nodes         int     `json:"nodes"`
+	Edges         int     `json:"edges"`
+	Skipped       int     `json:"skipped"`
+	ReleaseErrors []error `json:"-"`
+}
+
+// Restore a deleted Node, and its edges, by removing their ttl.
+//
+// Restoring process is as follows:
+//  1. Find the deleted Node, and every deleted edge on either side of it
+//  2. Claim the @unique values of the Node, another Node may have taken
+//     them while it was deleted
+//  3. Restore the Node
+//  4. Restore each edge that connects to a Node that is not deleted
+func Restore(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName *string,
+	namedType string,
+	uniqueFields []types.UniqueField,
+	id string,
+	now time.Time,
+) (
+	result Result,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "Restore")
+	defer segment.Close(err)
+
+	items, err := database.FindDeletedItems(
+		ctx,
+		dynamo,
+		*tableName,
+		id,
+		now,
+	)
+	if err != nil {
+		return
+	}
+
+	var node types.Node
+	var edgeItems []types.Node
+	for _, item := range items {
+		if item["linnet:dataType"] == "Node" {
+			if item["id"] == id && item["linnet:namedType"] == namedType {
+				node = item
+			}
+			continue
+		}
+		edgeItems = append(edgeItems, item)
+	}
+
+	if node == nil {
+		err = fmt.Errorf(
+			"Cannot restore %s %s, it is not deleted or can no longer be restored",
+			namedType,
+			id,
+		)
+		return
+	}
+
+	// The deleted items are found with only their keys, so the Node is read
+	// in full for the values of its @unique fields
+	if len(uniqueFields) > 0 {
+		node, err = database.GetNode(ctx, dynamo, *tableName, id)
+		if err != nil {
+			return
+		}
+		if node == nil {
+			err = fmt.Errorf(
+				"Cannot restore %s %s, it is not deleted or can no longer be restored",
+				namedType,
+				id,
+			)
+			return
+		}
+	}
+
+	liveNodes, err := findLiveNodes(
+		ctx,
+		dynamo,
+		*tableName,
+		id,
+		edgeItems,
+	)
+	if err != nil {
+		return
+	}
+
+	claimed, err := database.ClaimUniqueValues(
+		ctx,
+		dynamo,
+		*tableName,
+		database.UniqueValues(uniqueFields, []types.Node{node}),
+		now,
+	)
+	if err != nil {
+		return
+	}
+
+	restored, err := database.RestoreItem(
+		ctx,
+		dynamo,
+		*tableName,
+		itemKey(node),
+		now,
+	)
+	if err == nil && !restored {
+		err = fmt.Errorf(
+			"Cannot restore %s %s, it is not deleted or can no longer be restored",
+			namedType,
+			id,
+		)
+	}
+	if err != nil {
+		releaseErr := database.ReleaseUniqueValues(
+			ctx,
+			dynamo,
+			*tableName,
+			claimed,
+		)
+		if releaseErr != nil {
+			result.ReleaseErrors = append(result.ReleaseErrors, releaseErr)
+		}
+		return
+	}
+	result.Nodes = 1
+
+	err = database.ConfirmUniqueValues(
+		ctx,
+		dynamo,
+		*tableName,
+		claimed,
+	)
+	if err != nil {
+		return
+	}
+
+	for _, edgeItem := range edgeItems {
+		if !liveNodes[otherNodeID(id, edgeItem)] {
+			result.Skipped = result.Skipped + 1
+			continue
+		}
+
+		restored, err = database.RestoreItem(
+			ctx,
+			dynamo,
+			*tableName,
+			itemKey(edgeItem),
+			now,
+		)
+		if err != nil {
+			return
+		}
+		if restored {
+			result.Edges = result.Edges + 1
+		} else {
+			result.Skipped = result.Skipped + 1
+		}
+	}
+	return
+}
+
+// findLiveNodes returns the ids of the Nodes on the other side of each
+// edge, that are not deleted. The Node being restored counts as live.
+func findLiveNodes(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	id string,
+	edgeItems []types.Node,
+) (
+	liveNodes map[string]bool,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "findLiveNodes")
+	defer segment.Close(err)
+
+	liveNodes = map[string]bool{id: true}
+
+	seen := make(map[string]bool)
+	var otherIDs []string
+	for _, edgeItem := range edgeItems {
+		otherID := otherNodeID(id, edgeItem)
+		if otherID != "" && !seen[otherID] {
+			seen[otherID] = true
+			otherIDs = append(otherIDs, otherID)
+		}
+	}
+	if len(otherIDs) == 0 {
+		return
+	}
+
+	nodes, err := database.HydrateNodes(
+		ctx,
+		dynamo,
+		tableName,
+		otherIDs,
+		false,
+	)
+	if err != nil {
+		return
+	}
+
+	for _, node := range nodes {
+		if nodeID, ok := node["id"].(string); ok {
+			liveNodes[nodeID] = true
+		}
+	}
+	return
+}
+
+// otherNodeID is the Node an edge item connects id to. Edges stored under
+// id point to linnet:edge, the rest are stored under the other Node.
+func otherNodeID(id string, edgeItem types.Node) string {
+	if edgeItem["id"] == id {
+		otherID, _ := edgeItem["linnet:edge"].(string)
+		return otherID
+	}
+	otherID, _ := edgeItem["id"].(string)
+	return otherID
+}
+
+func itemKey(item types.Node) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"id": &dynamodb.AttributeValue{
+			S: aws.String(item["id"].(string)),
+		},
+		"linnet:dataType": &dynamodb.AttributeValue{
+			S: aws.String(item["linnet:dataType"].(string)),
+		},
+	}
+}
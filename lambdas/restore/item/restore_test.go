@@ -0,0 +1,506 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDynamoDBClient holds every Node and Edge item, with linnet:ttl as
+// an int64. The keys of items that are restored are kept in restored as
+// "id|linnet:dataType". With failRestore no item can be restored, and
+// with failRelease no guard can be released.
+type mockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	items       []types.Node
+	restored    []string
+	failRestore bool
+	failRelease bool
+}
+
+func (m *mockDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	keyName := *input.ExpressionAttributeNames["#key"]
+	nodeID := *input.ExpressionAttributeValues[":nodeID"].S
+	now, err := strconv.ParseInt(*input.ExpressionAttributeValues[":now"].N, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dynamodb.QueryOutput{}
+	for _, stored := range m.items {
+		ttl, deleted := stored["linnet:ttl"].(int64)
+		if stored[keyName] == nodeID && deleted && ttl > now {
+			item, err := dynamodbattribute.MarshalMap(stored)
+			if err != nil {
+				return nil, err
+			}
+			// Only the keys are projected
+			if input.ProjectionExpression != nil {
+				for name := range item {
+					switch name {
+					case "id", "linnet:dataType", "linnet:namedType", "linnet:edge":
+					default:
+						delete(item, name)
+					}
+				}
+			}
+			output.Items = append(output.Items, item)
+		}
+	}
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) GetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.GetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.GetItemOutput,
+	error,
+) {
+	output := &dynamodb.GetItemOutput{}
+	for _, stored := range m.items {
+		if stored["id"] == *input.Key["id"].S &&
+			stored["linnet:dataType"] == *input.Key["linnet:dataType"].S {
+			item, err := dynamodbattribute.MarshalMap(stored)
+			if err != nil {
+				return nil, err
+			}
+			output.Item = item
+		}
+	}
+	return output, nil
+}
+
+// PutItemWithContext is only used to claim a guard, which fails when the
+// guard is held by a Node other than :staleNode
+func (m *mockDynamoDBClient) PutItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.PutItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.PutItemOutput,
+	error,
+) {
+	var kept []types.Node
+	for _, stored := range m.items {
+		if stored["id"] != *input.Item["id"].S {
+			kept = append(kept, stored)
+			continue
+		}
+		staleNode := input.ExpressionAttributeValues[":staleNode"]
+		if staleNode == nil || *staleNode.S != stored["linnet:node"] {
+			return nil, awserr.New(
+				dynamodb.ErrCodeConditionalCheckFailedException,
+				"The conditional request failed",
+				nil,
+			)
+		}
+	}
+
+	var item types.Node
+	if err := dynamodbattribute.UnmarshalMap(input.Item, &item); err != nil {
+		return nil, err
+	}
+	m.items = append(kept, item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+// DeleteItemWithContext is only used to release a guard
+func (m *mockDynamoDBClient) DeleteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.DeleteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.DeleteItemOutput,
+	error,
+) {
+	if m.failRelease {
+		return nil, awserr.New(
+			dynamodb.ErrCodeInternalServerError,
+			"The guard could not be deleted",
+			nil,
+		)
+	}
+
+	var kept []types.Node
+	for _, stored := range m.items {
+		if stored["id"] == *input.Key["id"].S &&
+			stored["linnet:node"] == *input.ExpressionAttributeValues[":node"].S {
+			continue
+		}
+		kept = append(kept, stored)
+	}
+	m.items = kept
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchGetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchGetItemOutput,
+	error,
+) {
+	output := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]*dynamodb.AttributeValue{},
+	}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		for _, key := range keysAndAttributes.Keys {
+			for _, stored := range m.items {
+				if stored["id"] == *key["id"].S &&
+					stored["linnet:dataType"] == *key["linnet:dataType"].S {
+					item, err := dynamodbattribute.MarshalMap(stored)
+					if err != nil {
+						return nil, err
+					}
+					output.Responses[tableName] = append(output.Responses[tableName], item)
+				}
+			}
+		}
+	}
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) UpdateItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.UpdateItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.UpdateItemOutput,
+	error,
+) {
+	// A guard is confirmed by removing its ttl
+	if node, ok := input.ExpressionAttributeValues[":node"]; ok {
+		for _, stored := range m.items {
+			if stored["id"] == *input.Key["id"].S && stored["linnet:node"] == *node.S {
+				delete(stored, "linnet:ttl")
+				return &dynamodb.UpdateItemOutput{}, nil
+			}
+		}
+		return nil, awserr.New(
+			dynamodb.ErrCodeConditionalCheckFailedException,
+			"The conditional request failed",
+			nil,
+		)
+	}
+
+	now, err := strconv.ParseInt(*input.ExpressionAttributeValues[":now"].N, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stored := range m.items {
+		if m.failRestore {
+			break
+		}
+		ttl, deleted := stored["linnet:ttl"].(int64)
+		if stored["id"] == *input.Key["id"].S &&
+			stored["linnet:dataType"] == *input.Key["linnet:dataType"].S &&
+			deleted && ttl > now {
+			delete(stored, "linnet:ttl")
+			m.restored = append(
+				m.restored,
+				*input.Key["id"].S+"|"+*input.Key["linnet:dataType"].S,
+			)
+			return &dynamodb.UpdateItemOutput{}, nil
+		}
+	}
+
+	return nil, awserr.New(
+		dynamodb.ErrCodeConditionalCheckFailedException,
+		"The conditional request failed",
+		nil,
+	)
+}
+
+func TestRestore(t *testing.T) {
+	type Output struct {
+		result   Result
+		restored []string
+		err      string
+	}
+
+	now := time.Unix(1517446800, 0)
+	future := now.Add(time.Hour).Unix()
+	past := now.Add(-time.Hour).Unix()
+
+	items := func() []types.Node {
+		node := func(id string, namedType string, ttl int64) types.Node {
+			node := types.Node{
+				"id":               id,
+				"linnet:dataType":  "Node",
+				"linnet:namedType": namedType,
+			}
+			if ttl != 0 {
+				node["linnet:ttl"] = ttl
+			}
+			return node
+		}
+		edge := func(edgeName string, nodeID string, edgeID string, ttl int64) types.Node {
+			return types.Node{
+				"id":              nodeID,
+				"linnet:dataType": edgeName + "::" + edgeID,
+				"linnet:edge":     edgeID,
+				"linnet:ttl":      ttl,
+			}
+		}
+		return []types.Node{
+			node("customer-1", "Customer", 0),
+			node("customer-2", "Customer", future),
+			node("order-1", "Order", future),
+			node("order-2", "Order", past),
+			node("item-1", "Item", future),
+			edge("OrdersOnCustomer", "customer-1", "order-1", future),
+			edge("OrdersOnCustomer", "customer-2", "order-1", future),
+			edge("ItemsOnOrder", "order-1", "item-1", future),
+			edge("OrdersOnCustomer", "customer-1", "order-2", past),
+		}
+	}
+
+	tests := []struct {
+		namedType string
+		id        string
+		output    Output
+	}{
+		{
+			// The edge to the deleted Customer and Item are left deleted
+			namedType: "Order",
+			id:        "order-1",
+			output: Output{
+				result: Result{Nodes: 1, Edges: 1, Skipped: 2},
+				restored: []string{
+					"order-1|Node",
+					"customer-1|OrdersOnCustomer::order-1",
+				},
+			},
+		},
+		{
+			// Expired items cannot be restored
+			namedType: "Order",
+			id:        "order-2",
+			output: Output{
+				err: "Cannot restore Order order-2, it is not deleted or can no longer be restored",
+			},
+		},
+		{
+			namedType: "Customer",
+			id:        "customer-1",
+			output: Output{
+				err: "Cannot restore Customer customer-1, it is not deleted or can no longer be restored",
+			},
+		},
+		{
+			// The id must be of the namedType
+			namedType: "Customer",
+			id:        "order-1",
+			output: Output{
+				err: "Cannot restore Customer order-1, it is not deleted or can no longer be restored",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestRestore")
+		assert := assert.New(t)
+
+		mockSvc := &mockDynamoDBClient{
+			items: items(),
+		}
+
+		result, err := Restore(
+			ctx,
+			mockSvc,
+			aws.String("test-table"),
+			test.namedType,
+			nil,
+			test.id,
+			now,
+		)
+
+		if test.output.err != "" {
+			assert.EqualError(
+				err,
+				test.output.err,
+				fmt.Sprintf("Test %d", i),
+			)
+			assert.Empty(
+				mockSvc.restored,
+				fmt.Sprintf("Test %d", i),
+			)
+			continue
+		}
+
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(
+			test.output.result,
+			result,
+			fmt.Sprintf("Test %d", i),
+		)
+		assert.ElementsMatch(
+			test.output.restored,
+			mockSvc.restored,
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
+
+func TestRestoreUniqueValues(t *testing.T) {
+	type Output struct {
+		restored      []string
+		err           string
+		releaseErrors []error
+		// guard is the Node holding a@example.com after the restore
+		guard string
+	}
+
+	now := time.Unix(1517446800, 0)
+	future := now.Add(time.Hour).Unix()
+
+	uniqueFields := []types.UniqueField{
+		types.UniqueField{TypeName: "Customer", Field: "email"},
+	}
+
+	customer := func(id string, email string, ttl int64) types.Node {
+		node := types.Node{
+			"id":               id,
+			"linnet:dataType":  "Node",
+			"linnet:namedType": "Customer",
+			"email":            email,
+		}
+		if ttl != 0 {
+			node["linnet:ttl"] = ttl
+		}
+		return node
+	}
+	guard := func(email string, nodeID string) types.Node {
+		key := database.UniqueGuardKey("Customer", "email", email)
+		return types.Node{
+			"id":              *key["id"].S,
+			"linnet:dataType": *key["linnet:dataType"].S,
+			"linnet:node":     nodeID,
+		}
+	}
+
+	tests := []struct {
+		items       []types.Node
+		failRestore bool
+		failRelease bool
+		output      Output
+	}{
+		{
+			// The guard was released when the Customer was deleted, so it
+			// is claimed again
+			items: []types.Node{
+				customer("customer-1", "a@example.com", future),
+			},
+			output: Output{
+				restored: []string{"customer-1|Node"},
+				guard:    "customer-1",
+			},
+		},
+		{
+			// Another Customer took the email while this one was deleted
+			items: []types.Node{
+				customer("customer-1", "a@example.com", future),
+				customer("customer-2", "a@example.com", 0),
+				guard("a@example.com", "customer-2"),
+			},
+			output: Output{
+				err:   "UniqueConstraintViolation: Customer.email a@example.com is already used by another Customer",
+				guard: "customer-2",
+			},
+		},
+		{
+			// The guard claimed for a restore that failed could not be
+			// released, so it is reported
+			items: []types.Node{
+				customer("customer-1", "a@example.com", future),
+			},
+			failRestore: true,
+			failRelease: true,
+			output: Output{
+				err: "Cannot restore Customer customer-1, it is not deleted or can no longer be restored",
+				releaseErrors: []error{
+					awserr.New(
+						dynamodb.ErrCodeInternalServerError,
+						"The guard could not be deleted",
+						nil,
+					),
+				},
+				guard: "customer-1",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestRestoreUniqueValues")
+		assert := assert.New(t)
+
+		mockSvc := &mockDynamoDBClient{
+			items:       test.items,
+			failRestore: test.failRestore,
+			failRelease: test.failRelease,
+		}
+
+		result, err := Restore(
+			ctx,
+			mockSvc,
+			aws.String("test-table"),
+			"Customer",
+			uniqueFields,
+			"customer-1",
+			now,
+		)
+
+		if test.output.err != "" {
+			assert.EqualError(err, test.output.err, fmt.Sprintf("Test %d", i))
+		} else {
+			assert.NoError(err, fmt.Sprintf("Test %d", i))
+		}
+		assert.Equal(
+			test.output.releaseErrors,
+			result.ReleaseErrors,
+			fmt.Sprintf("Test %d", i),
+		)
+		assert.ElementsMatch(
+			test.output.restored,
+			mockSvc.restored,
+			fmt.Sprintf("Test %d", i),
+		)
+
+		// A guard that was not released is left pending
+		key := database.UniqueGuardKey("Customer", "email", "a@example.com")
+		var holder string
+		for _, stored := range mockSvc.items {
+			if stored["id"] == *key["id"].S {
+				holder, _ = stored["linnet:node"].(string)
+				if test.output.releaseErrors == nil {
+					assert.Nil(stored["linnet:ttl"], fmt.Sprintf("Test %d", i))
+				}
+			}
+		}
+		assert.Equal(test.output.guard, holder, fmt.Sprintf("Test %d", i))
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/restore/item"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+func processEvent(
+	ctx context.Context,
+	dynamo *dynamodb.DynamoDB,
+	event *types.LambdaEvent,
+	currentTime time.Time,
+) (
+	response types.LambdaResponse,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "processEvent")
+	defer segment.Close(nil)
+
+	xray.AWS(dynamo.Client)
+
+	var restoreID string
+	if where, ok := event.Context.Arguments["where"].(map[string]interface{}); ok {
+		restoreID, _ = where["id"].(string)
+	}
+	if restoreID == "" {
+		response.Errors = append(response.Errors, "Cannot Restore, no ID passed")
+		return
+	}
+
+	result, err := item.Restore(
+		ctx,
+		dynamo,
+		aws.String(event.DataSource.TableName),
+		event.NamedType,
+		event.UniqueFields,
+		restoreID,
+		currentTime,
+	)
+	if err != nil {
+		response.Errors = append(response.Errors, err.Error())
+		for _, releaseErr := range result.ReleaseErrors {
+			response.Errors = append(response.Errors, releaseErr.Error())
+		}
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"count":   result.Nodes + result.Edges,
+		"nodes":   result.Nodes,
+		"edges":   result.Edges,
+		"skipped": result.Skipped,
+	}
+	return
+}
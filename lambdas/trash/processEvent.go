@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// DEFAULT_LIMIT of Nodes to read, when no limit is passed
+var DEFAULT_LIMIT int64 = 25
+
+func processEvent(
+	ctx context.Context,
+	dynamo *dynamodb.DynamoDB,
+	event *types.LambdaEvent,
+	currentTime time.Time,
+) (
+	response types.LambdaResponse,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "processEvent")
+	defer segment.Close(nil)
+
+	xray.AWS(dynamo.Client)
+
+	limit := DEFAULT_LIMIT
+	if argumentLimit, ok := types.IntValue(event.Context.Arguments["limit"]); ok &&
+		argumentLimit > 0 {
+		limit = argumentLimit
+	}
+	cursor, _ := event.Context.Arguments["cursor"].(string)
+
+	nodes, nextCursor, err := database.QueryTrash(
+		ctx,
+		dynamo,
+		event.DataSource.TableName,
+		event.NamedType,
+		currentTime,
+		limit,
+		cursor,
+	)
+	if err != nil {
+		response.Errors = append(response.Errors, err.Error())
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"edges":  nodes,
+		"cursor": nextCursor,
+	}
+	return
+}
@@ -3,12 +3,12 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
@@ -29,20 +29,19 @@ func handler(ctx context.Context, evt json.RawMessage) (response []byte, err err
 
 	// Unmarshall the Event
 	var event types.LambdaEvent
-	err = json.Unmarshal(evt, &event)
+	err = util.UnmarshalEvent(evt, &event)
 	if err != nil {
 		return
 	}
 
-	// Process the event
-	rootNode, err := processEvent(
+	// Process the event, and return the rootNode
+	result := processEvent(
 		ctx,
 		dynamo,
 		&event,
 		time.Now(),
 	)
 
-	response, err = json.Marshal(rootNode)
-	fmt.Println(string(response))
+	response, err = json.Marshal(result)
 	return
 }
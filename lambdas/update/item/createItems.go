@@ -62,6 +62,7 @@ func createItems(
 				"id":               nodeID,
 				"linnet:dataType":  "Node",
 				"linnet:namedType": namedType,
+				"linnet:version":   1,
 				"createdAt":        createdAt,
 				"updatedAt":        updatedAt,
 				"createdBy":        createdBy,
@@ -0,0 +1,230 @@
+package item
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	nodeUtil "github.com/ojkelly/linnet/lambdas/util/node"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// edgeChanges are the nodes to connect and disconnect across an edge
+type edgeChanges struct {
+	edge       types.Edge
+	connect    []string
+	disconnect []string
+	// set replaces every existing edge, when replace is true
+	set     []string
+	replace bool
+	// Nodes created by nested data on the edge
+	created []string
+}
+
+// extractEdgeChanges from the input on an edge field. Both connect and
+// connection add edges, connection is kept as it is used by create.
+func extractEdgeChanges(
+	edge types.Edge,
+	edgeInput map[string]interface{},
+) (
+	changes edgeChanges,
+	err error,
+) {
+	changes.edge = edge
+	changes.connect = append(
+		nodeUtil.ExtractConnectionIDs(edgeInput["connection"]),
+		nodeUtil.ExtractConnectionIDs(edgeInput["connect"])...,
+	)
+	changes.disconnect = nodeUtil.ExtractConnectionIDs(edgeInput["disconnect"])
+
+	if setValue, ok := edgeInput["set"]; ok {
+		changes.replace = true
+		changes.set = nodeUtil.ExtractConnectionIDs(setValue)
+
+		if len(changes.connect) > 0 || len(changes.disconnect) > 0 {
+			err = fmt.Errorf(
+				"Cannot set %s.%s, with connect or disconnect",
+				edge.TypeName,
+				edge.Field,
+			)
+			return
+		}
+	}
+
+	// A new node on a ONE edge replaces the node it was connected to
+	if util.IsOneEdge(edge) && !changes.replace && len(changes.connect) > 0 {
+		changes.replace = true
+		changes.set = changes.connect
+		changes.connect = nil
+	}
+
+	for _, connectID := range changes.connect {
+		for _, disconnectID := range changes.disconnect {
+			if connectID == disconnectID {
+				err = fmt.Errorf(
+					"Cannot connect and disconnect %s on %s.%s",
+					connectID,
+					edge.TypeName,
+					edge.Field,
+				)
+				return
+			}
+		}
+	}
+	return
+}
+
+// resolveEdgeChanges turns a set into the nodes to connect and
+// disconnect, by comparing it against the edges that already exist.
+//
+// Every node in the set is connected again, as its edge may have been
+// deleted. Writing an edge item that already exists replaces it.
+//
+// The changes are also checked against the Cardinality and Required
+// metadata on the edge.
+func resolveEdgeChanges(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	nodeID string,
+	changes edgeChanges,
+) (
+	resolved edgeChanges,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "resolveEdgeChanges")
+	defer segment.Close(err)
+
+	resolved = changes
+
+	// A ONE edge with a new node is replaced, the same as a set
+	if util.IsOneEdge(changes.edge) && !resolved.replace && len(resolved.created) > 0 {
+		resolved.replace = true
+	}
+
+	if util.IsOneEdge(changes.edge) &&
+		len(resolved.set)+len(resolved.created) > 1 {
+		err = types.EdgeCardinalityError{
+			TypeName: changes.edge.TypeName,
+			Field:    changes.edge.Field,
+			EdgeName: changes.edge.EdgeName,
+			NodeID:   nodeID,
+			Count:    len(resolved.set) + len(resolved.created),
+		}
+		return
+	}
+
+	// Only read the existing edges when they are needed
+	if !resolved.replace &&
+		!(changes.edge.Required && len(resolved.disconnect) > 0) {
+		return
+	}
+
+	currentIDs, err := database.QueryAllEdges(
+		ctx,
+		dynamo,
+		tableName,
+		nodeID,
+		changes.edge,
+	)
+	if err != nil {
+		return
+	}
+
+	remaining := len(resolved.connect) + len(resolved.created)
+
+	if resolved.replace {
+		keep := make(map[string]bool, len(changes.set))
+		for _, id := range changes.set {
+			keep[id] = true
+		}
+		for _, id := range changes.created {
+			keep[id] = true
+		}
+
+		resolved.connect = changes.set
+		for _, id := range currentIDs {
+			if !keep[id] {
+				resolved.disconnect = append(resolved.disconnect, id)
+			}
+		}
+		remaining = len(resolved.connect) + len(resolved.created)
+	} else {
+		disconnect := make(map[string]bool, len(resolved.disconnect))
+		for _, id := range resolved.disconnect {
+			disconnect[id] = true
+		}
+		for _, id := range currentIDs {
+			if !disconnect[id] {
+				remaining++
+			}
+		}
+	}
+
+	if changes.edge.Required && remaining == 0 {
+		err = types.EdgeRequiredError{
+			TypeName: changes.edge.TypeName,
+			Field:    changes.edge.Field,
+			EdgeName: changes.edge.EdgeName,
+			NodeID:   nodeID,
+		}
+	}
+	return
+}
+
+// verifyDisconnects checks each node being disconnected keeps an edge,
+// when its side of the edge is Required
+func verifyDisconnects(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	edgeTypes []types.Edge,
+	nodeID string,
+	changes edgeChanges,
+) (
+	errors []error,
+) {
+	var err error
+	ctx, segment := xray.BeginSubsegment(ctx, "verifyDisconnects")
+	defer segment.Close(err)
+
+	found, counterpart := util.GetCounterpartEdge(changes.edge, edgeTypes)
+	if !found || !counterpart.Required {
+		return
+	}
+
+	for _, disconnectID := range changes.disconnect {
+		var currentIDs []string
+		currentIDs, err = database.QueryAllEdges(
+			ctx,
+			dynamo,
+			tableName,
+			disconnectID,
+			counterpart,
+		)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+
+		remaining := 0
+		for _, currentID := range currentIDs {
+			if currentID != nodeID {
+				remaining++
+			}
+		}
+
+		if remaining == 0 {
+			errors = append(errors, types.EdgeRequiredError{
+				TypeName: counterpart.TypeName,
+				Field:    counterpart.Field,
+				EdgeName: counterpart.EdgeName,
+				NodeID:   disconnectID,
+			})
+		}
+	}
+	return
+}
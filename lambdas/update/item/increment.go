@@ -0,0 +1,276 @@
+package item
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// guardOperators are the comparisons a guard can make on the value of a
+// field after the update, and the DynamoDB comparator for each
+var guardOperators = map[string]string{
+	"greaterThanOrEqualTo": ">=",
+	"greaterThan":          ">",
+	"lessThanOrEqualTo":    "<=",
+	"lessThan":             "<",
+}
+
+// incrementGuard is a comparison the value of field must pass after the
+// update, for the update to be applied
+type incrementGuard struct {
+	field    string
+	operator string
+	value    *big.Rat
+}
+
+// holds is true when value passes the guard
+func (g incrementGuard) holds(value *big.Rat) bool {
+	switch g.operator {
+	case "greaterThanOrEqualTo":
+		return value.Cmp(g.value) >= 0
+	case "greaterThan":
+		return value.Cmp(g.value) > 0
+	case "lessThanOrEqualTo":
+		return value.Cmp(g.value) <= 0
+	case "lessThan":
+		return value.Cmp(g.value) < 0
+	}
+	return false
+}
+
+// extractIncrements gets the amounts to add to each field from increment,
+// and the guards on the values the fields will have after the update.
+// Amounts are kept exact, so a Float field holding money is not rounded.
+func extractIncrements(
+	namedType string,
+	edgesOnType []types.Edge,
+	uniqueFields []types.UniqueField,
+	arguments map[string]interface{},
+	updateInput map[string]interface{},
+) (
+	increments map[string]*big.Rat,
+	guards []incrementGuard,
+	err error,
+) {
+	incrementInput, _ := arguments["increment"].(map[string]interface{})
+	guardInput, _ := arguments["guard"].(map[string]interface{})
+
+	increments = make(map[string]*big.Rat)
+	for field, value := range incrementInput {
+		if value == nil {
+			continue
+		}
+		if err = checkNumericField(namedType, edgesOnType, field); err != nil {
+			return
+		}
+		for _, uniqueField := range util.GetUniqueFieldsOnType(namedType, uniqueFields) {
+			if uniqueField.Field == field {
+				err = fmt.Errorf(
+					"Cannot increment %s.%s, it is @unique",
+					namedType,
+					field,
+				)
+				return
+			}
+		}
+		if _, ok := updateInput[field]; ok {
+			err = fmt.Errorf(
+				"Cannot update %s, %s cannot be in both data and increment",
+				namedType,
+				field,
+			)
+			return
+		}
+
+		amount, ok := types.NumberValue(value)
+		if !ok {
+			err = fmt.Errorf(
+				"Cannot increment %s.%s, the increment must be a number",
+				namedType,
+				field,
+			)
+			return
+		}
+		increments[field] = amount
+	}
+
+	for field, value := range guardInput {
+		comparisons, _ := value.(map[string]interface{})
+		for operator, guardValue := range comparisons {
+			if guardValue == nil {
+				continue
+			}
+			if err = checkNumericField(namedType, edgesOnType, field); err != nil {
+				return
+			}
+			if _, ok := guardOperators[operator]; !ok {
+				err = fmt.Errorf(
+					"Cannot guard %s.%s, %s is not a guard",
+					namedType,
+					field,
+					operator,
+				)
+				return
+			}
+			if _, ok := updateInput[field]; ok {
+				err = fmt.Errorf(
+					"Cannot guard %s.%s, it is set in data",
+					namedType,
+					field,
+				)
+				return
+			}
+
+			number, ok := types.NumberValue(guardValue)
+			if !ok {
+				err = fmt.Errorf(
+					"Cannot guard %s.%s, %s must be a number",
+					namedType,
+					field,
+					operator,
+				)
+				return
+			}
+			guards = append(guards, incrementGuard{
+				field:    field,
+				operator: operator,
+				value:    number,
+			})
+		}
+	}
+
+	// Sorted, so the same arguments always build the same expression
+	sort.Slice(guards, func(i, j int) bool {
+		if guards[i].field != guards[j].field {
+			return guards[i].field < guards[j].field
+		}
+		return guards[i].operator < guards[j].operator
+	})
+	return
+}
+
+// checkNumericField is a field of the Node that can be incremented
+func checkNumericField(
+	namedType string,
+	edgesOnType []types.Edge,
+	field string,
+) (
+	err error,
+) {
+	foundEdge, _ := util.GetEdgeFromEdgeTypes(field, edgesOnType)
+	if foundEdge || util.IsReadOnlyField(field) {
+		err = fmt.Errorf(
+			"Cannot increment %s.%s, it is not a number field",
+			namedType,
+			field,
+		)
+	}
+	return
+}
+
+// addIncrements to the expression. A guard is a condition on the value
+// before the update, so the amount added is taken from its value. A field
+// that is not set starts from 0, so it passes when 0 would.
+func addIncrements(
+	expression *database.UpdateExpression,
+	increments map[string]*big.Rat,
+	guards []incrementGuard,
+) (
+	err error,
+) {
+	var fields []string
+	for field := range increments {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		err = expression.Add(field, types.NumberFromRat(increments[field]))
+		if err != nil {
+			return
+		}
+	}
+
+	for _, guard := range guards {
+		increment := incrementOf(increments, guard.field)
+
+		var value string
+		value, err = expression.Value(types.NumberFromRat(
+			new(big.Rat).Sub(guard.value, increment),
+		))
+		if err != nil {
+			return
+		}
+
+		condition := fmt.Sprintf(
+			"%s %s %s",
+			expression.Name(guard.field),
+			guardOperators[guard.operator],
+			value,
+		)
+		if guard.holds(increment) {
+			condition = fmt.Sprintf(
+				"(attribute_not_exists(%s) OR %s)",
+				expression.Name(guard.field),
+				condition,
+			)
+		}
+		expression.Condition(condition)
+	}
+	return
+}
+
+// incrementGuardFailure is the error for the first guard the Node would
+// not pass after the increments, if there is one
+func incrementGuardFailure(
+	namedType string,
+	nodeID string,
+	increments map[string]*big.Rat,
+	guards []incrementGuard,
+	node types.Node,
+) (
+	err error,
+) {
+	for _, guard := range guards {
+		current := new(big.Rat)
+		if node[guard.field] != nil {
+			var ok bool
+			current, ok = types.NumberValue(node[guard.field])
+			if !ok {
+				return fmt.Errorf(
+					"Cannot increment %s.%s, it is not a number on %s %s",
+					namedType,
+					guard.field,
+					namedType,
+					nodeID,
+				)
+			}
+		}
+
+		value := new(big.Rat).Add(current, incrementOf(increments, guard.field))
+		if !guard.holds(value) {
+			return types.IncrementGuardError{
+				NamedType: namedType,
+				ID:        nodeID,
+				Field:     guard.field,
+				Guard:     guard.operator,
+				Bound:     types.NumberFromRat(guard.value),
+				Value:     types.NumberFromRat(value),
+				Node:      node,
+			}
+		}
+	}
+	return
+}
+
+// incrementOf field, which is 0 when it is only guarded
+func incrementOf(increments map[string]*big.Rat, field string) *big.Rat {
+	if increment, ok := increments[field]; ok {
+		return increment
+	}
+	return new(big.Rat)
+}
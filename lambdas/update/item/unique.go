@@ -0,0 +1,106 @@
+package item
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// uniqueChanges are the values of @unique fields an update moves the Node
+// to and from. The guards of the values it moves to are claimed before
+// the update, and the guards of the values it moves from are released
+// after it.
+type uniqueChanges struct {
+	to   []database.UniqueValue
+	from []database.UniqueValue
+	// checked is true when the update is conditional on the current values
+	checked bool
+}
+
+// planUniqueChanges compares the @unique fields in updateInput with the
+// Node as it is now. The expression is given a condition on each field
+// that changes, so the update is not applied when another request has
+// changed it since.
+func planUniqueChanges(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	nodeID string,
+	uniqueFields []types.UniqueField,
+	updateInput map[string]interface{},
+	expression *database.UpdateExpression,
+) (
+	changes uniqueChanges,
+	err error,
+) {
+	var changedFields []string
+	for _, uniqueField := range util.GetUniqueFieldsOnType(namedType, uniqueFields) {
+		if _, ok := updateInput[uniqueField.Field]; ok {
+			changedFields = append(changedFields, uniqueField.Field)
+		}
+	}
+	if len(changedFields) == 0 {
+		return
+	}
+
+	ctx, segment := xray.BeginSubsegment(ctx, "planUniqueChanges")
+	defer segment.Close(err)
+
+	node, err := database.GetNode(ctx, dynamo, tableName, nodeID)
+	if err != nil {
+		return
+	}
+	if _, deleted := node["linnet:ttl"]; node == nil || deleted {
+		// UpdateNode returns the NodeNotFoundError
+		return
+	}
+
+	for _, field := range changedFields {
+		from := node[field]
+		to := updateInput[field]
+		if (from == nil) == (to == nil) && fmt.Sprint(from) == fmt.Sprint(to) {
+			continue
+		}
+
+		if from == nil {
+			expression.Condition(fmt.Sprintf(
+				"attribute_not_exists(%s)",
+				expression.Name(field),
+			))
+		} else {
+			var fromValue string
+			fromValue, err = expression.Value(from)
+			if err != nil {
+				return
+			}
+			expression.Condition(fmt.Sprintf(
+				"%s = %s",
+				expression.Name(field),
+				fromValue,
+			))
+			changes.from = append(changes.from, database.UniqueValue{
+				NamedType: namedType,
+				Field:     field,
+				Value:     from,
+				NodeID:    nodeID,
+			})
+		}
+		changes.checked = true
+
+		if to != nil {
+			changes.to = append(changes.to, database.UniqueValue{
+				NamedType: namedType,
+				Field:     field,
+				Value:     to,
+				NodeID:    nodeID,
+			})
+		}
+	}
+	return
+}
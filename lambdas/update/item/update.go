@@ -0,0 +1,557 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	nodeUtil "github.com/ojkelly/linnet/lambdas/util/node"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+var MAX_RETRIES = 5
+
+// Update the Node selected by where.id
+//
+// Only the fields passed in data are changed, and fields passed as null
+// are removed. Nested data on an edge field creates new Nodes connected
+// to the updated Node, and connect, disconnect and set change which
+// existing Nodes it is connected to.
+//
+// When expectedVersion is passed the update is only applied if the Node is
+// still at that version, otherwise a VersionConflictError is returned with
+// the Node as it is now.
+//
+// Fields in increment are added to atomically, and guard compares the
+// values they will have after the update. An update that would fail a
+// guard is not applied, and an IncrementGuardError is returned with the
+// Node as it is now.
+func Update(
+	ctx context.Context,
+	event *types.LambdaEvent,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName *string,
+	now time.Time,
+) (
+	rootNode types.Node,
+	errors []error,
+) {
+	var err error
+	ctx, segment := xray.BeginSubsegment(ctx, "Update")
+	defer segment.Close(err)
+
+	nodeID, updateInput, err := extractUpdateInput(event.Context.Arguments)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	expectedVersion, checkVersion, err := extractExpectedVersion(
+		event.NamedType,
+		event.Context.Arguments,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	segment.AddAnnotation("nodeID", nodeID)
+
+	edgesOnType := util.GetEdgesOnType(
+		event.NamedType,
+		event.EdgeTypes,
+	)
+
+	increments, guards, err := extractIncrements(
+		event.NamedType,
+		edgesOnType,
+		event.UniqueFields,
+		event.Context.Arguments,
+		updateInput,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	expression := database.NewUpdateExpression()
+
+	// Items for any nested Nodes and their Edges
+	var items []types.Node
+
+	// Edges to connect and disconnect on each edge field
+	var edgeChangesOnNode []edgeChanges
+
+	for fieldName, fieldValue := range updateInput {
+		if util.IsReadOnlyField(fieldName) {
+			continue
+		}
+
+		// Check if this is an edge
+		foundEdge, edge := util.GetEdgeFromEdgeTypes(
+			fieldName,
+			edgesOnType,
+		)
+
+		if foundEdge == false {
+			if fieldValue == nil {
+				expression.Remove(fieldName)
+				continue
+			}
+
+			err = expression.Set(fieldName, fieldValue)
+			if err != nil {
+				errors = append(errors, err)
+				return
+			}
+		} else if edgeInput, ok := fieldValue.(map[string]interface{}); ok {
+			var nestedItems []types.Node
+			nestedItems, err = createNestedItems(
+				ctx,
+				event,
+				edge,
+				nodeID,
+				now,
+				edgeInput,
+			)
+			if err != nil {
+				errors = append(errors, err)
+				return
+			}
+			items = append(items, nestedItems...)
+
+			var changes edgeChanges
+			changes, err = extractEdgeChanges(edge, edgeInput)
+			if err != nil {
+				errors = append(errors, err)
+				return
+			}
+			for _, nestedItem := range nestedItems {
+				if nestedItem["linnet:dataType"] == "Node" &&
+					nestedItem["linnet:namedType"] == edge.FieldType {
+					changes.created = append(changes.created, nestedItem["id"].(string))
+				}
+			}
+			edgeChangesOnNode = append(edgeChangesOnNode, changes)
+		}
+	}
+
+	// Work out which edges to add and remove, and check the nodes being
+	// connected exist before anything is written
+	var connections []types.Connection
+	for i, changes := range edgeChangesOnNode {
+		changes, err = resolveEdgeChanges(
+			ctx,
+			dynamo,
+			*tableName,
+			nodeID,
+			changes,
+		)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+		edgeChangesOnNode[i] = changes
+
+		errors = verifyDisconnects(
+			ctx,
+			dynamo,
+			*tableName,
+			event.EdgeTypes,
+			nodeID,
+			changes,
+		)
+		if errors != nil {
+			return
+		}
+
+		if len(changes.connect) > 0 {
+			connections = append(connections, types.Connection{
+				Edge:   changes.edge,
+				NodeID: nodeID,
+				IDs:    changes.connect,
+			})
+			for _, connectID := range changes.connect {
+				items = append(items, nodeUtil.CreateEdgeItem(
+					ctx,
+					changes.edge,
+					nodeID,
+					connectID,
+					now,
+					now,
+					"linnet",
+				))
+			}
+		}
+	}
+
+	errors = nodeUtil.ValidateEdges(event.EdgeTypes, items)
+	if errors != nil {
+		return
+	}
+
+	errors = database.VerifyConnections(
+		ctx,
+		dynamo,
+		*tableName,
+		connections,
+	)
+	if errors != nil {
+		return
+	}
+
+	err = expression.Set("updatedAt", now)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	// Claim the guards of the @unique values the Node moves to, and of
+	// any nested Nodes, before anything is written
+	unique, err := planUniqueChanges(
+		ctx,
+		dynamo,
+		*tableName,
+		event.NamedType,
+		nodeID,
+		event.UniqueFields,
+		updateInput,
+		expression,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	if checkVersion {
+		err = expectVersion(expression, expectedVersion)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+	}
+
+	err = addIncrements(expression, increments, guards)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	claimed, err := database.ClaimUniqueValues(
+		ctx,
+		dynamo,
+		*tableName,
+		append(unique.to, database.UniqueValues(event.UniqueFields, items)...),
+		now,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	// Update the node first, this checks it exists before we
+	// add anything to it
+	node, err := database.UpdateNode(
+		ctx,
+		dynamo,
+		*tableName,
+		event.NamedType,
+		nodeID,
+		expression,
+	)
+	if err != nil {
+		releaseErr := database.ReleaseUniqueValues(ctx, dynamo, *tableName, claimed)
+		if _, ok := err.(types.NodeNotFoundError); ok &&
+			(checkVersion || len(guards) > 0 || unique.checked) {
+			err = explainConditionFailure(
+				ctx,
+				dynamo,
+				*tableName,
+				event.NamedType,
+				nodeID,
+				checkVersion,
+				expectedVersion,
+				increments,
+				guards,
+			)
+		}
+
+		// The Node as it is now is returned with a conflict or failed guard
+		var current types.Node
+		switch failure := err.(type) {
+		case types.VersionConflictError:
+			current = failure.Node
+		case types.IncrementGuardError:
+			current = failure.Node
+		}
+		if current != nil {
+			rootNode = util.CleanRootNode(
+				ctx,
+				nodeID,
+				event.EdgeTypes,
+				event.LinnetFields,
+				[]types.Node{current},
+			)
+		}
+		errors = append(errors, err)
+		if releaseErr != nil {
+			errors = append(errors, releaseErr)
+		}
+		return
+	}
+
+	// The Node has its new values, so their guards are confirmed. A guard
+	// for a nested Node that is not written below is replaced by the next
+	// claim, as its Node does not exist.
+	err = database.ConfirmUniqueValues(
+		ctx,
+		dynamo,
+		*tableName,
+		claimed,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	// The values moved from are free for other Nodes. A guard that is not
+	// released is replaced by the next claim, as the Node no longer has
+	// its value, so the update carries on.
+	err = database.ReleaseUniqueValues(ctx, dynamo, *tableName, unique.from)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	if len(items) > 0 {
+		writeErrors := writeItems(
+			ctx,
+			dynamo,
+			*tableName,
+			items,
+		)
+		if writeErrors != nil {
+			errors = append(errors, writeErrors...)
+			return
+		}
+	}
+
+	for _, changes := range edgeChangesOnNode {
+		err = database.DisconnectNodes(
+			ctx,
+			dynamo,
+			*tableName,
+			changes.edge,
+			nodeID,
+			changes.disconnect,
+			now,
+		)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+	}
+
+	// Nodes connected on a ONE edge lose the node they were connected to
+	err = database.ReplaceCounterpartEdges(
+		ctx,
+		dynamo,
+		*tableName,
+		event.EdgeTypes,
+		connections,
+		now,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	// Clean up the rootNode for return
+	rootNode = util.CleanRootNode(
+		ctx,
+		nodeID,
+		event.EdgeTypes,
+		event.LinnetFields,
+		append(items, node),
+	)
+	return
+}
+
+// explainConditionFailure reads the Node after a conditional update was
+// not applied, to tell a Node that does not exist from one at another
+// version, one whose values fail a guard, and one changed by another
+// request since it was read
+func explainConditionFailure(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	nodeID string,
+	checkVersion bool,
+	expectedVersion int64,
+	increments map[string]*big.Rat,
+	guards []incrementGuard,
+) (
+	err error,
+) {
+	node, err := database.GetNode(ctx, dynamo, tableName, nodeID)
+	if err != nil {
+		return
+	}
+	if _, deleted := node["linnet:ttl"]; node == nil ||
+		deleted ||
+		node["linnet:namedType"] != namedType {
+		return types.NodeNotFoundError{
+			NamedType: namedType,
+			ID:        nodeID,
+		}
+	}
+
+	if checkVersion {
+		err = versionConflict(namedType, nodeID, expectedVersion, node)
+		if err != nil {
+			return
+		}
+	}
+
+	err = incrementGuardFailure(namedType, nodeID, increments, guards, node)
+	if err != nil {
+		return
+	}
+
+	return fmt.Errorf(
+		"Cannot update %s %s, it was changed by another request, try again",
+		namedType,
+		nodeID,
+	)
+}
+
+// extractUpdateInput gets the id of the node to update, and the data to
+// update it with from the mutation arguments. data can be left out when
+// there is an increment.
+func extractUpdateInput(
+	arguments map[string]interface{},
+) (
+	nodeID string,
+	updateInput map[string]interface{},
+	err error,
+) {
+	if where, ok := arguments["where"].(map[string]interface{}); ok {
+		nodeID, _ = where["id"].(string)
+	}
+	if nodeID == "" {
+		err = fmt.Errorf("Cannot Update, no ID passed")
+		return
+	}
+
+	updateInput, ok := arguments["data"].(map[string]interface{})
+	if ok {
+		return
+	}
+
+	if increment, _ := arguments["increment"].(map[string]interface{}); len(increment) > 0 {
+		return nodeID, map[string]interface{}{}, nil
+	}
+	err = fmt.Errorf("Cannot Update %s, no data or increment passed", nodeID)
+	return
+}
+
+// createNestedItems for the data passed on an edge field, and the edges
+// connecting them to nodeID
+func createNestedItems(
+	ctx context.Context,
+	event *types.LambdaEvent,
+	edge types.Edge,
+	nodeID string,
+	now time.Time,
+	edgeInput map[string]interface{},
+) (
+	items []types.Node,
+	err error,
+) {
+	createdBy := "linnet"
+
+	nestedItems, err := createItems(
+		ctx,
+		event.LinnetFields,
+		event.DataSource,
+		edge.FieldType,
+		event.EdgeTypes,
+		"",
+		nodeID,
+		now,
+		now,
+		createdBy,
+		edgeInput,
+	)
+	if err != nil {
+		return
+	}
+
+	for _, nestedItem := range nestedItems {
+		if nestedItem["linnet:dataType"] == "Node" &&
+			nestedItem["linnet:namedType"] == edge.FieldType {
+			items = append(items, nodeUtil.CreateEdgeItem(
+				ctx,
+				edge,
+				nodeID,
+				nestedItem["id"].(string),
+				now,
+				now,
+				createdBy,
+			))
+		}
+		items = append(items, nestedItem)
+	}
+	return
+}
+
+// writeItems to DynamoDB in batches of 25
+func writeItems(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	items []types.Node,
+) (
+	errors []error,
+) {
+	requests, err := database.MarshallItemsToWriteRequests(
+		ctx,
+		items,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	var writeRequests []*dynamodb.WriteRequest
+	for _, request := range requests {
+		writeRequests = append(writeRequests, request...)
+	}
+
+	result, err := database.BatchWriteRequests(
+		ctx,
+		dynamo,
+		tableName,
+		writeRequests,
+		MAX_RETRIES,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+	for _, failed := range result.Failed {
+		errors = append(errors, fmt.Errorf(
+			"Cannot write %s %s, DynamoDB did not process it after %d retries",
+			*failed.PutRequest.Item["id"].S,
+			*failed.PutRequest.Item["linnet:dataType"].S,
+			MAX_RETRIES,
+		))
+	}
+	return
+}
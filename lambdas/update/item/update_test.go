@@ -0,0 +1,1216 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/constants"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDynamoDBClient holds a single Node, and applies SET actions to it.
+// Edge items are returned by Query, and the keys of any edge items
+// deleted are kept in tombstoned as "id|linnet:dataType". Guards for
+// @unique values are kept by id, with the other Nodes that hold them.
+type mockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	node           types.Node
+	edgeItems      []types.Node
+	namedTypes     map[string]string
+	batchWriteSize int
+	tombstoned     []string
+	guards         map[string]map[string]*dynamodb.AttributeValue
+	others         map[string]types.Node
+	failRelease    bool
+}
+
+var conditionFailed = awserr.New(
+	dynamodb.ErrCodeConditionalCheckFailedException,
+	"The conditional request failed",
+	nil,
+)
+
+func (m *mockDynamoDBClient) GetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.GetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.GetItemOutput,
+	error,
+) {
+	id := *input.Key["id"].S
+	if strings.HasPrefix(id, "unique::") {
+		return &dynamodb.GetItemOutput{Item: m.guards[id]}, nil
+	}
+
+	node := m.others[id]
+	if m.node != nil && m.node["id"] == id {
+		node = m.node
+	}
+	if node == nil {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	item, err := dynamodbattribute.MarshalMap(node)
+	return &dynamodb.GetItemOutput{Item: item}, err
+}
+
+func (m *mockDynamoDBClient) PutItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.PutItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.PutItemOutput,
+	error,
+) {
+	id := *input.Item["id"].S
+	if stored, ok := m.guards[id]; ok {
+		staleNode := input.ExpressionAttributeValues[":staleNode"]
+		if staleNode == nil || *staleNode.S != *stored["linnet:node"].S {
+			return nil, conditionFailed
+		}
+	}
+	m.guards[id] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) DeleteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.DeleteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.DeleteItemOutput,
+	error,
+) {
+	if m.failRelease {
+		return nil, awserr.New(
+			dynamodb.ErrCodeInternalServerError,
+			"The guard could not be deleted",
+			nil,
+		)
+	}
+
+	id := *input.Key["id"].S
+	stored, ok := m.guards[id]
+	if !ok || *stored["linnet:node"].S != *input.ExpressionAttributeValues[":node"].S {
+		return nil, conditionFailed
+	}
+	delete(m.guards, id)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchGetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchGetItemOutput,
+	error,
+) {
+	output := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]*dynamodb.AttributeValue{},
+	}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		for _, key := range keysAndAttributes.Keys {
+			id := *key["id"].S
+			if namedType, ok := m.namedTypes[id]; ok {
+				output.Responses[tableName] = append(
+					output.Responses[tableName],
+					map[string]*dynamodb.AttributeValue{
+						"id":               &dynamodb.AttributeValue{S: aws.String(id)},
+						"linnet:dataType":  &dynamodb.AttributeValue{S: aws.String("Node")},
+						"linnet:namedType": &dynamodb.AttributeValue{S: aws.String(namedType)},
+					},
+				)
+			}
+		}
+	}
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	partitionKeyName := *input.ExpressionAttributeNames["#partitionKeyName"]
+	partitionKeyValue := *input.ExpressionAttributeValues[":partitionKeyValue"].S
+	sortKeyValue := *input.ExpressionAttributeValues[":sortKeyValue"].S
+
+	output := &dynamodb.QueryOutput{}
+	for _, edgeItem := range m.edgeItems {
+		if edgeItem[partitionKeyName] == partitionKeyValue &&
+			strings.HasPrefix(edgeItem["linnet:dataType"].(string), sortKeyValue) {
+			item, err := dynamodbattribute.MarshalMap(edgeItem)
+			if err != nil {
+				return nil, err
+			}
+			output.Items = append(output.Items, item)
+		}
+	}
+	output.ScannedCount = aws.Int64(int64(len(output.Items)))
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) UpdateItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.UpdateItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.UpdateItemOutput,
+	error,
+) {
+	if id := *input.Key["id"].S; strings.HasPrefix(id, "unique::") {
+		// ConfirmUniqueGuard
+		stored, ok := m.guards[id]
+		if !ok || *stored["linnet:node"].S != *input.ExpressionAttributeValues[":node"].S {
+			return nil, conditionFailed
+		}
+		delete(stored, "linnet:ttl")
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+
+	if *input.Key["linnet:dataType"].S != "Node" {
+		m.tombstoned = append(
+			m.tombstoned,
+			*input.Key["id"].S+"|"+*input.Key["linnet:dataType"].S,
+		)
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+
+	if m.node == nil || m.node["id"] != *input.Key["id"].S {
+		return nil, conditionFailed
+	}
+
+	attributes, err := dynamodbattribute.MarshalMap(m.node)
+	if err != nil {
+		return nil, err
+	}
+	if !conditionHolds(input, attributes) {
+		return nil, conditionFailed
+	}
+
+	// Apply each "#name = :value" action from the SET clause, and the
+	// "#name :value" action from the ADD clause
+	expression := strings.Split(*input.UpdateExpression, " ADD ")
+	actions := strings.Split(expression[0], " REMOVE ")[0]
+	for _, action := range strings.Split(strings.TrimPrefix(actions, "SET "), ", ") {
+		parts := strings.Split(action, " = ")
+		if len(parts) == 2 {
+			attributes[*input.ExpressionAttributeNames[parts[0]]] = input.ExpressionAttributeValues[parts[1]]
+		}
+	}
+	if len(expression) == 2 {
+		for _, action := range strings.Split(expression[1], ", ") {
+			parts := strings.Split(action, " ")
+			name := *input.ExpressionAttributeNames[parts[0]]
+			var current float64
+			if attributes[name] != nil {
+				current, _ = strconv.ParseFloat(*attributes[name].N, 64)
+			}
+			added, _ := strconv.ParseFloat(*input.ExpressionAttributeValues[parts[1]].N, 64)
+			attributes[name] = &dynamodb.AttributeValue{
+				N: aws.String(strconv.FormatFloat(current+added, 'f', -1, 64)),
+			}
+		}
+	}
+
+	return &dynamodb.UpdateItemOutput{
+		Attributes: attributes,
+	}, nil
+}
+
+// conditionHolds evaluates a ConditionExpression against the attributes
+// of the Node. Conditions are joined with AND, and each may be a
+// parenthesised OR of comparisons and attribute_exists checks.
+func conditionHolds(
+	input *dynamodb.UpdateItemInput,
+	attributes map[string]*dynamodb.AttributeValue,
+) bool {
+	if input.ConditionExpression == nil {
+		return true
+	}
+	for _, condition := range strings.Split(*input.ConditionExpression, " AND ") {
+		condition = strings.TrimSuffix(strings.TrimPrefix(condition, "("), ")")
+		holds := false
+		for _, alternative := range strings.Split(condition, " OR ") {
+			holds = holds || comparisonHolds(input, attributes, alternative)
+		}
+		if !holds {
+			return false
+		}
+	}
+	return true
+}
+
+func comparisonHolds(
+	input *dynamodb.UpdateItemInput,
+	attributes map[string]*dynamodb.AttributeValue,
+	comparison string,
+) bool {
+	if strings.HasPrefix(comparison, "attribute_exists(") {
+		name := strings.TrimSuffix(strings.TrimPrefix(comparison, "attribute_exists("), ")")
+		return attributes[*input.ExpressionAttributeNames[name]] != nil
+	}
+	if strings.HasPrefix(comparison, "attribute_not_exists(") {
+		name := strings.TrimSuffix(strings.TrimPrefix(comparison, "attribute_not_exists("), ")")
+		return attributes[*input.ExpressionAttributeNames[name]] == nil
+	}
+
+	parts := strings.Split(comparison, " ")
+	current := attributes[*input.ExpressionAttributeNames[parts[0]]]
+	value := input.ExpressionAttributeValues[parts[2]]
+	if current == nil {
+		return false
+	}
+	if value.S != nil {
+		return parts[1] == "=" && current.S != nil && *current.S == *value.S
+	}
+	if current.N == nil {
+		return false
+	}
+	a, _ := strconv.ParseFloat(*current.N, 64)
+	b, _ := strconv.ParseFloat(*value.N, 64)
+	switch parts[1] {
+	case "=":
+		return a == b
+	case ">=":
+		return a >= b
+	case ">":
+		return a > b
+	case "<=":
+		return a <= b
+	case "<":
+		return a < b
+	}
+	return false
+}
+
+func (m *mockDynamoDBClient) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchWriteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchWriteItemOutput,
+	error,
+) {
+	for _, requests := range input.RequestItems {
+		m.batchWriteSize = m.batchWriteSize + len(requests)
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func TestUpdate(t *testing.T) {
+	type Output struct {
+		response       types.Node
+		batchWriteSize int
+		tombstoned     []string
+		errors         []string
+	}
+
+	currentTime := time.Unix(1517446800, 10).UTC()
+
+	edgeTypes := []types.Edge{
+		types.Edge{
+			TypeName:    "Customer",
+			Field:       "orders",
+			FieldType:   "Order",
+			EdgeName:    "OrdersOnCustomer",
+			Required:    false,
+			Cardinality: "MANY",
+			Principal:   "TRUE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Order",
+				Field:    "customer",
+			},
+		},
+		types.Edge{
+			TypeName:    "Order",
+			Field:       "customer",
+			FieldType:   "Customer",
+			EdgeName:    "OrdersOnCustomer",
+			Required:    true,
+			Cardinality: "ONE",
+			Principal:   "FALSE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Customer",
+				Field:    "orders",
+			},
+		},
+	}
+
+	storedNode := types.Node{
+		"id":               "81af6f8f-8639-4ff6-a881-083eb7135de0",
+		"linnet:dataType":  "Node",
+		"linnet:namedType": "Customer",
+		"name":             "customer name",
+		"email":            "dsarggsdrf@dsfgfsd.sfd",
+		"createdAt":        "2018-08-16T05:10:24.092529313Z",
+		"createdBy":        "linnet",
+	}
+
+	orderNode := types.Node{
+		"id":               "e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+		"linnet:dataType":  "Node",
+		"linnet:namedType": "Order",
+		"status":           "PENDING",
+	}
+
+	// Each Customer is connected to one Order, the edge is stored under
+	// the Customer as it is the principal
+	edgeItems := []types.Node{
+		types.Node{
+			"id":               "81af6f8f-8639-4ff6-a881-083eb7135de0",
+			"linnet:dataType":  "OrdersOnCustomer::e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+			"linnet:namedType": "Order",
+			"linnet:edge":      "e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+		},
+		types.Node{
+			"id":               "5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c",
+			"linnet:dataType":  "OrdersOnCustomer::0b5d8c2e-93f4-4c6a-8a3e-2f9b8d7c6e51",
+			"linnet:namedType": "Order",
+			"linnet:edge":      "0b5d8c2e-93f4-4c6a-8a3e-2f9b8d7c6e51",
+		},
+	}
+
+	namedTypes := map[string]string{
+		"81af6f8f-8639-4ff6-a881-083eb7135de0": "Customer",
+		"5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c": "Customer",
+		"e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5": "Order",
+		"0b5d8c2e-93f4-4c6a-8a3e-2f9b8d7c6e51": "Order",
+	}
+
+	tests := []struct {
+		namedType string
+		node      types.Node
+		arguments map[string]interface{}
+		output    Output
+	}{
+		{
+			node: storedNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				},
+				"data": map[string]interface{}{
+					"name":      "new name",
+					"createdBy": "someone else",
+				},
+			},
+			output: Output{
+				response: types.Node{
+					"id":        "81af6f8f-8639-4ff6-a881-083eb7135de0",
+					"name":      "new name",
+					"email":     "dsarggsdrf@dsfgfsd.sfd",
+					"createdBy": "linnet",
+					"updatedAt": "2018-02-01T01:00:00.00000001Z",
+				},
+			},
+		},
+		{
+			node: storedNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				},
+				"data": map[string]interface{}{
+					"orders": map[string]interface{}{
+						"data": []interface{}{
+							map[string]interface{}{
+								"status": "COMPLETE",
+							},
+						},
+					},
+				},
+			},
+			output: Output{
+				response: types.Node{
+					"id":   "81af6f8f-8639-4ff6-a881-083eb7135de0",
+					"name": "customer name",
+				},
+				// The Order, and the Edge to the Order
+				batchWriteSize: 2,
+			},
+		},
+		{
+			node: types.Node{
+				"id":               "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				"linnet:dataType":  "Node",
+				"linnet:namedType": "Customer",
+				"linnet:ttl":       1517446800,
+			},
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				},
+				"data": map[string]interface{}{
+					"name": "new name",
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Customer 81af6f8f-8639-4ff6-a881-083eb7135de0 does not exist",
+				},
+			},
+		},
+		{
+			node: storedNode,
+			arguments: map[string]interface{}{
+				"data": map[string]interface{}{
+					"name": "new name",
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot Update, no ID passed",
+				},
+			},
+		},
+		{
+			node: storedNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				},
+				"data": map[string]interface{}{
+					"orders": map[string]interface{}{
+						"connect": []interface{}{
+							"0b5d8c2e-93f4-4c6a-8a3e-2f9b8d7c6e51",
+						},
+					},
+				},
+			},
+			output: Output{
+				// The Edge to the connected Order
+				batchWriteSize: 1,
+				// An Order has ONE Customer, so the connected Order
+				// loses its old Customer
+				tombstoned: []string{
+					"5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c|OrdersOnCustomer::0b5d8c2e-93f4-4c6a-8a3e-2f9b8d7c6e51",
+				},
+			},
+		},
+		{
+			// Replace the Customer on an Order, this edge is stored
+			// under the Customer as the Order is not the principal
+			namedType: "Order",
+			node:      orderNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+				},
+				"data": map[string]interface{}{
+					"customer": map[string]interface{}{
+						"set": "5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c",
+					},
+				},
+			},
+			output: Output{
+				response: types.Node{
+					"id":     "e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+					"status": "PENDING",
+				},
+				batchWriteSize: 1,
+				tombstoned: []string{
+					"81af6f8f-8639-4ff6-a881-083eb7135de0|OrdersOnCustomer::e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+				},
+			},
+		},
+		{
+			// Connecting a new Customer replaces the old one, as an
+			// Order has ONE Customer
+			namedType: "Order",
+			node:      orderNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+				},
+				"data": map[string]interface{}{
+					"customer": map[string]interface{}{
+						"connect": "5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c",
+					},
+				},
+			},
+			output: Output{
+				batchWriteSize: 1,
+				tombstoned: []string{
+					"81af6f8f-8639-4ff6-a881-083eb7135de0|OrdersOnCustomer::e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+				},
+			},
+		},
+		{
+			namedType: "Order",
+			node:      orderNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+				},
+				"data": map[string]interface{}{
+					"customer": map[string]interface{}{
+						"set": []interface{}{
+							"81af6f8f-8639-4ff6-a881-083eb7135de0",
+							"5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c",
+						},
+					},
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Order.customer can only have ONE OrdersOnCustomer edge, e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5 has 2",
+				},
+			},
+		},
+		{
+			namedType: "Order",
+			node:      orderNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+				},
+				"data": map[string]interface{}{
+					"customer": map[string]interface{}{
+						"disconnect": "81af6f8f-8639-4ff6-a881-083eb7135de0",
+					},
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Order.customer requires a OrdersOnCustomer edge, e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5 has none",
+				},
+			},
+		},
+		{
+			node: storedNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				},
+				"data": map[string]interface{}{
+					"orders": map[string]interface{}{
+						"set": []interface{}{},
+					},
+				},
+			},
+			output: Output{
+				// Removing the Order would leave it without a Customer
+				errors: []string{
+					"Order.customer requires a OrdersOnCustomer edge, e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5 has none",
+				},
+			},
+		},
+		{
+			node: storedNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				},
+				"data": map[string]interface{}{
+					"orders": map[string]interface{}{
+						"connect": []interface{}{
+							"5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c",
+						},
+					},
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot connect Customer.orders, Order nodes not found: 5e1f3a58-1d57-4e0e-a4a3-cf6b0d1e7f4c",
+				},
+			},
+		},
+		{
+			node: storedNode,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"id": "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				},
+				"data": map[string]interface{}{
+					"orders": map[string]interface{}{
+						"connect": []interface{}{
+							"e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+						},
+						"disconnect": []interface{}{
+							"e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5",
+						},
+					},
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot connect and disconnect e2b1e4a9-4cc1-4bb5-9f43-7be1b5c3a3d5 on Customer.orders",
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestUpdate")
+
+		assert := assert.New(t)
+
+		namedType := test.namedType
+		if namedType == "" {
+			namedType = "Customer"
+		}
+
+		mockDynamoDB := &mockDynamoDBClient{
+			node:       test.node,
+			edgeItems:  edgeItems,
+			namedTypes: namedTypes,
+		}
+
+		event := types.LambdaEvent{
+			LinnetFields: constants.LinnetFields,
+			DataSource: types.DataSourceDynamoDBConfig{
+				UseCallerCredentials: false,
+				AwsRegion:            "us-east-1",
+				TableName:            "DynamoDBTestTable",
+			},
+			NamedType: namedType,
+			EdgeTypes: edgeTypes,
+			Context: types.LinnetResolverContext{
+				Arguments: test.arguments,
+			},
+		}
+
+		output, errs := Update(
+			ctx,
+			&event,
+			mockDynamoDB,
+			aws.String(event.DataSource.TableName),
+			currentTime,
+		)
+
+		var errors []string
+		for _, err := range errs {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(test.output.errors, errors, fmt.Sprintf("Test %d", i))
+
+		for key, value := range test.output.response {
+			assert.Equal(value, output[key], fmt.Sprintf("Test %d: %s", i, key))
+		}
+		assert.Equal(
+			test.output.batchWriteSize,
+			mockDynamoDB.batchWriteSize,
+			fmt.Sprintf("Test %d", i),
+		)
+		assert.Equal(
+			test.output.tombstoned,
+			mockDynamoDB.tombstoned,
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
+
+func TestUpdateUniqueFields(t *testing.T) {
+	currentTime := time.Unix(1517446800, 10).UTC()
+
+	guard := func(email string, nodeID string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"id":              &dynamodb.AttributeValue{S: aws.String("unique::Customer::email::" + email)},
+			"linnet:dataType": &dynamodb.AttributeValue{S: aws.String("unique::Customer::email::" + email)},
+			"linnet:node":     &dynamodb.AttributeValue{S: aws.String(nodeID)},
+		}
+	}
+	customer := func(id string, email string) types.Node {
+		return types.Node{
+			"id":               id,
+			"linnet:dataType":  "Node",
+			"linnet:namedType": "Customer",
+			"email":            email,
+		}
+	}
+
+	type Output struct {
+		errors []string
+		// guards is the Node each email is held by after the update
+		guards map[string]string
+	}
+
+	tests := []struct {
+		guards      map[string]map[string]*dynamodb.AttributeValue
+		others      map[string]types.Node
+		failRelease bool
+		arguments   map[string]interface{}
+		output      Output
+	}{
+		{
+			// The guard moves to the new value
+			guards: map[string]map[string]*dynamodb.AttributeValue{
+				"a@example.com": guard("a@example.com", "customer-1"),
+			},
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{"id": "customer-1"},
+				"data":  map[string]interface{}{"email": "b@example.com"},
+			},
+			output: Output{
+				guards: map[string]string{"b@example.com": "customer-1"},
+			},
+		},
+		{
+			// The value is held by another Customer
+			guards: map[string]map[string]*dynamodb.AttributeValue{
+				"a@example.com": guard("a@example.com", "customer-1"),
+				"b@example.com": guard("b@example.com", "customer-2"),
+			},
+			others: map[string]types.Node{
+				"customer-2": customer("customer-2", "b@example.com"),
+			},
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{"id": "customer-1"},
+				"data":  map[string]interface{}{"email": "b@example.com"},
+			},
+			output: Output{
+				errors: []string{
+					"UniqueConstraintViolation: Customer.email b@example.com is already used by another Customer",
+				},
+				guards: map[string]string{
+					"a@example.com": "customer-1",
+					"b@example.com": "customer-2",
+				},
+			},
+		},
+		{
+			// The Customer that held the value was deleted
+			guards: map[string]map[string]*dynamodb.AttributeValue{
+				"a@example.com": guard("a@example.com", "customer-1"),
+				"b@example.com": guard("b@example.com", "customer-2"),
+			},
+			others: map[string]types.Node{
+				"customer-2": types.Node{
+					"id":               "customer-2",
+					"linnet:dataType":  "Node",
+					"linnet:namedType": "Customer",
+					"email":            "b@example.com",
+					"linnet:ttl":       1517446800,
+				},
+			},
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{"id": "customer-1"},
+				"data":  map[string]interface{}{"email": "b@example.com"},
+			},
+			output: Output{
+				guards: map[string]string{"b@example.com": "customer-1"},
+			},
+		},
+		{
+			// Removing the value frees it
+			guards: map[string]map[string]*dynamodb.AttributeValue{
+				"a@example.com": guard("a@example.com", "customer-1"),
+			},
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{"id": "customer-1"},
+				"data":  map[string]interface{}{"email": nil},
+			},
+			output: Output{
+				guards: map[string]string{},
+			},
+		},
+		{
+			// The update is applied, and the guard that could not be
+			// released is reported
+			guards: map[string]map[string]*dynamodb.AttributeValue{
+				"a@example.com": guard("a@example.com", "customer-1"),
+			},
+			failRelease: true,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{"id": "customer-1"},
+				"data":  map[string]interface{}{"email": "b@example.com"},
+			},
+			output: Output{
+				errors: []string{
+					"InternalServerError: The guard could not be deleted",
+				},
+				guards: map[string]string{
+					"a@example.com": "customer-1",
+					"b@example.com": "customer-1",
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestUpdateUniqueFields")
+		assert := assert.New(t)
+
+		guards := make(map[string]map[string]*dynamodb.AttributeValue)
+		for email, item := range test.guards {
+			guards["unique::Customer::email::"+email] = item
+		}
+		mockDynamoDB := &mockDynamoDBClient{
+			node:        customer("customer-1", "a@example.com"),
+			guards:      guards,
+			others:      test.others,
+			failRelease: test.failRelease,
+		}
+
+		_, errs := Update(
+			ctx,
+			&types.LambdaEvent{
+				LinnetFields: constants.LinnetFields,
+				DataSource: types.DataSourceDynamoDBConfig{
+					TableName: "DynamoDBTestTable",
+				},
+				NamedType: "Customer",
+				UniqueFields: []types.UniqueField{
+					types.UniqueField{TypeName: "Customer", Field: "email"},
+				},
+				Context: types.LinnetResolverContext{
+					Arguments: test.arguments,
+				},
+			},
+			mockDynamoDB,
+			aws.String("DynamoDBTestTable"),
+			currentTime,
+		)
+
+		var errors []string
+		for _, err := range errs {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(test.output.errors, errors, fmt.Sprintf("Test %d", i))
+
+		heldBy := make(map[string]string)
+		for id, item := range mockDynamoDB.guards {
+			assert.Nil(item["linnet:ttl"], fmt.Sprintf("Test %d", i))
+			heldBy[strings.TrimPrefix(id, "unique::Customer::email::")] = *item["linnet:node"].S
+		}
+		assert.Equal(test.output.guards, heldBy, fmt.Sprintf("Test %d", i))
+	}
+}
+
+func TestUpdateExpectedVersion(t *testing.T) {
+	currentTime := time.Unix(1517446800, 10).UTC()
+
+	customer := func(version interface{}) types.Node {
+		node := types.Node{
+			"id":               "customer-1",
+			"linnet:dataType":  "Node",
+			"linnet:namedType": "Customer",
+			"name":             "customer name",
+		}
+		if version != nil {
+			node["linnet:version"] = version
+		}
+		return node
+	}
+
+	type Output struct {
+		errors []string
+		// version is the version of the Node returned
+		version interface{}
+		name    interface{}
+	}
+
+	tests := []struct {
+		node            types.Node
+		expectedVersion interface{}
+		output          Output
+	}{
+		{
+			node:            customer(3),
+			expectedVersion: float64(3),
+			output: Output{
+				version: int64(4),
+				name:    "new name",
+			},
+		},
+		{
+			// Another update was applied first, so the Node as it is now
+			// is returned with the conflict
+			node:            customer(4),
+			expectedVersion: float64(3),
+			output: Output{
+				errors: []string{
+					"VersionConflict: Customer customer-1 is at version 4, not the expected version 3",
+				},
+				version: int64(4),
+				name:    "customer name",
+			},
+		},
+		{
+			// A Node written before versions were kept is at version 0
+			node:            customer(nil),
+			expectedVersion: float64(0),
+			output: Output{
+				version: int64(1),
+				name:    "new name",
+			},
+		},
+		{
+			node: customer(2),
+			output: Output{
+				version: int64(3),
+				name:    "new name",
+			},
+		},
+		{
+			node:            customer(2),
+			expectedVersion: float64(1.5),
+			output: Output{
+				errors: []string{
+					"Cannot update Customer, expectedVersion must be a whole number of 0 or more",
+				},
+			},
+		},
+		{
+			expectedVersion: float64(1),
+			output: Output{
+				errors: []string{
+					"Customer customer-1 does not exist",
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestUpdateExpectedVersion")
+		assert := assert.New(t)
+
+		arguments := map[string]interface{}{
+			"where": map[string]interface{}{"id": "customer-1"},
+			"data":  map[string]interface{}{"name": "new name"},
+		}
+		if test.expectedVersion != nil {
+			arguments["expectedVersion"] = test.expectedVersion
+		}
+
+		rootNode, errs := Update(
+			ctx,
+			&types.LambdaEvent{
+				LinnetFields: constants.LinnetFields,
+				DataSource: types.DataSourceDynamoDBConfig{
+					TableName: "DynamoDBTestTable",
+				},
+				NamedType: "Customer",
+				Context: types.LinnetResolverContext{
+					Arguments: arguments,
+				},
+			},
+			&mockDynamoDBClient{node: test.node},
+			aws.String("DynamoDBTestTable"),
+			currentTime,
+		)
+
+		var errors []string
+		for _, err := range errs {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(test.output.errors, errors, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.output.version, rootNode["version"], fmt.Sprintf("Test %d", i))
+		assert.Equal(test.output.name, rootNode["name"], fmt.Sprintf("Test %d", i))
+	}
+}
+
+func TestUpdateIncrements(t *testing.T) {
+	currentTime := time.Unix(1517446800, 10).UTC()
+
+	product := func(fields map[string]interface{}) types.Node {
+		node := types.Node{
+			"id":               "product-1",
+			"linnet:dataType":  "Node",
+			"linnet:namedType": "Product",
+		}
+		for field, value := range fields {
+			node[field] = value
+		}
+		return node
+	}
+
+	type Output struct {
+		errors []string
+		// fields are the values on the Node returned
+		fields map[string]interface{}
+	}
+
+	tests := []struct {
+		node      types.Node
+		arguments map[string]interface{}
+		output    Output
+	}{
+		{
+			node: product(map[string]interface{}{"stock": float64(5), "sold": float64(2)}),
+			arguments: map[string]interface{}{
+				"increment": map[string]interface{}{"stock": float64(-1), "sold": float64(1)},
+				"guard": map[string]interface{}{
+					"stock": map[string]interface{}{"greaterThanOrEqualTo": float64(0)},
+				},
+			},
+			output: Output{
+				fields: map[string]interface{}{"stock": types.Number("4"), "sold": types.Number("3")},
+			},
+		},
+		{
+			// The last one has been sold, so the Node as it is now is
+			// returned with the failed guard
+			node: product(map[string]interface{}{"stock": float64(0)}),
+			arguments: map[string]interface{}{
+				"increment": map[string]interface{}{"stock": float64(-1)},
+				"guard": map[string]interface{}{
+					"stock": map[string]interface{}{"greaterThanOrEqualTo": float64(0)},
+				},
+			},
+			output: Output{
+				errors: []string{
+					"IncrementGuardFailed: Product product-1 stock would be -1, which is not greaterThanOrEqualTo 0",
+				},
+				fields: map[string]interface{}{"stock": types.Number("0")},
+			},
+		},
+		{
+			// A field that is not set starts from 0
+			node: product(nil),
+			arguments: map[string]interface{}{
+				"increment": map[string]interface{}{"stock": float64(3)},
+				"guard": map[string]interface{}{
+					"stock": map[string]interface{}{"lessThanOrEqualTo": float64(10)},
+				},
+			},
+			output: Output{
+				fields: map[string]interface{}{"stock": types.Number("3")},
+			},
+		},
+		{
+			node: product(nil),
+			arguments: map[string]interface{}{
+				"increment": map[string]interface{}{"stock": float64(-1)},
+				"guard": map[string]interface{}{
+					"stock": map[string]interface{}{"greaterThan": float64(-1)},
+				},
+			},
+			output: Output{
+				errors: []string{
+					"IncrementGuardFailed: Product product-1 stock would be -1, which is not greaterThan -1",
+				},
+				fields: map[string]interface{}{"stock": nil},
+			},
+		},
+		{
+			// A guard on a field that is not incremented checks its value
+			node: product(map[string]interface{}{"stock": float64(2), "reserved": float64(2)}),
+			arguments: map[string]interface{}{
+				"increment": map[string]interface{}{"stock": float64(-1)},
+				"guard": map[string]interface{}{
+					"stock":    map[string]interface{}{"greaterThanOrEqualTo": float64(0)},
+					"reserved": map[string]interface{}{"lessThan": float64(2)},
+				},
+			},
+			output: Output{
+				errors: []string{
+					"IncrementGuardFailed: Product product-1 reserved would be 2, which is not lessThan 2",
+				},
+				fields: map[string]interface{}{"stock": types.Number("2")},
+			},
+		},
+		{
+			// data and increment can be passed together
+			node: product(map[string]interface{}{"stock": float64(2)}),
+			arguments: map[string]interface{}{
+				"data":      map[string]interface{}{"name": "Widget"},
+				"increment": map[string]interface{}{"stock": float64(-1)},
+			},
+			output: Output{
+				fields: map[string]interface{}{"stock": types.Number("1"), "name": "Widget"},
+			},
+		},
+		{
+			// Without an increment, data is required
+			node: product(map[string]interface{}{"stock": float64(2)}),
+			arguments: map[string]interface{}{
+				"increment": map[string]interface{}{},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot Update product-1, no data or increment passed",
+				},
+			},
+		},
+		{
+			node: product(map[string]interface{}{"stock": float64(2)}),
+			arguments: map[string]interface{}{
+				"data":      map[string]interface{}{"stock": float64(10)},
+				"increment": map[string]interface{}{"stock": float64(-1)},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot update Product, stock cannot be in both data and increment",
+				},
+			},
+		},
+		{
+			node: product(map[string]interface{}{"stock": float64(2)}),
+			arguments: map[string]interface{}{
+				"increment": map[string]interface{}{"stock": "1"},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot increment Product.stock, the increment must be a number",
+				},
+			},
+		},
+		{
+			node: product(map[string]interface{}{"stock": float64(2)}),
+			arguments: map[string]interface{}{
+				"increment": map[string]interface{}{"createdAt": float64(1)},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot increment Product.createdAt, it is not a number field",
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestUpdateIncrements")
+		assert := assert.New(t)
+
+		arguments := map[string]interface{}{
+			"where": map[string]interface{}{"id": "product-1"},
+		}
+		for argument, value := range test.arguments {
+			arguments[argument] = value
+		}
+
+		rootNode, errs := Update(
+			ctx,
+			&types.LambdaEvent{
+				LinnetFields: constants.LinnetFields,
+				DataSource: types.DataSourceDynamoDBConfig{
+					TableName: "DynamoDBTestTable",
+				},
+				NamedType: "Product",
+				Context: types.LinnetResolverContext{
+					Arguments: arguments,
+				},
+			},
+			&mockDynamoDBClient{node: test.node},
+			aws.String("DynamoDBTestTable"),
+			currentTime,
+		)
+
+		var errors []string
+		for _, err := range errs {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(test.output.errors, errors, fmt.Sprintf("Test %d", i))
+		for field, value := range test.output.fields {
+			assert.Equal(value, rootNode[field], fmt.Sprintf("Test %d", i))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package item
+
+import (
+	"fmt"
+
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// extractExpectedVersion gets the version the Node must be at for the
+// update to be applied. passed is false when no expectedVersion was given.
+func extractExpectedVersion(
+	namedType string,
+	arguments map[string]interface{},
+) (
+	expected int64,
+	passed bool,
+	err error,
+) {
+	value, ok := arguments["expectedVersion"]
+	if !ok || value == nil {
+		return
+	}
+
+	version, ok := types.IntValue(value)
+	if !ok || version < 0 {
+		err = fmt.Errorf(
+			"Cannot update %s, expectedVersion must be a whole number of 0 or more",
+			namedType,
+		)
+		return
+	}
+	return version, true, nil
+}
+
+// expectVersion adds a condition on linnet:version to the expression.
+// Version 0 is a Node written before versions were kept.
+func expectVersion(
+	expression *database.UpdateExpression,
+	expected int64,
+) (
+	err error,
+) {
+	if expected == 0 {
+		expression.Condition(fmt.Sprintf(
+			"attribute_not_exists(%s)",
+			expression.Name("linnet:version"),
+		))
+		return
+	}
+
+	expectedValue, err := expression.Value(expected)
+	if err != nil {
+		return
+	}
+	expression.Condition(fmt.Sprintf(
+		"%s = %s",
+		expression.Name("linnet:version"),
+		expectedValue,
+	))
+	return
+}
+
+// versionConflict is the error for a Node that is not at the expected
+// version, if it is not
+func versionConflict(
+	namedType string,
+	nodeID string,
+	expected int64,
+	node types.Node,
+) (
+	err error,
+) {
+	if node.Version() == expected {
+		return
+	}
+	return types.VersionConflictError{
+		NamedType: namedType,
+		ID:        nodeID,
+		Expected:  expected,
+		Current:   node.Version(),
+		Node:      node,
+	}
+}
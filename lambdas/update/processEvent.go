@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/update/item"
@@ -16,25 +17,29 @@ func processEvent(
 	event *types.LambdaEvent,
 	currentTime time.Time,
 ) (
-	rootNode types.Node,
-	err error,
+	response types.LambdaResponse,
 ) {
 	ctx, segment := xray.BeginSubsegment(ctx, "processEvent")
 	defer segment.Close(nil)
 
 	xray.AWS(dynamo.Client)
 
-	rootNode, errs := item.Create(
+	var errs []error
+
+	response.Data, errs = item.Update(
 		ctx,
 		event,
 		dynamo,
+		aws.String(event.DataSource.TableName),
 		currentTime,
 	)
 
 	if errs != nil {
-		//
+		for _, err := range errs {
+			response.Errors = append(response.Errors, err.Error())
+		}
 	}
 
-	// If successfully created, return a cleaned Root Node
-	return
+	// If successfully updated, return a cleaned Root Node
+	return response
 }
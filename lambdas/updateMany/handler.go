@@ -3,13 +3,12 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
-	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/ojkelly/linnet/lambdas/util"
 )
 
 var dynamo *dynamodb.DynamoDB
@@ -28,21 +27,20 @@ func handler(ctx context.Context, evt json.RawMessage) (response []byte, err err
 	defer segment.Close(err)
 
 	// Unmarshall the Event
-	var event types.LambdaEvent
-	err = json.Unmarshal(evt, &event)
+	var event UpdateManyLambdaEvent
+	err = util.UnmarshalEvent(evt, &event)
 	if err != nil {
 		return
 	}
 
-	// Process the event
-	rootNode, err := processEvent(
+	// Process the event, and return the per node results
+	result := processEvent(
 		ctx,
 		dynamo,
 		&event,
 		time.Now(),
 	)
 
-	response, err = json.Marshal(rootNode)
-	fmt.Println(string(response))
+	response, err = json.Marshal(result)
 	return
 }
@@ -0,0 +1,307 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	connectionPlural "github.com/ojkelly/linnet/lambdas/connectionPlural/item"
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// MAX_CONCURRENT_UPDATES is the number of nodes updated at once
+var MAX_CONCURRENT_UPDATES = 10
+
+// Result of an UpdateMany, with the outcome for each selected Node
+type Result struct {
+	Updated []string  `json:"updated"`
+	Failed  []Failure `json:"failed"`
+}
+
+// Failure to update a single Node
+type Failure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// UpdateMany applies the same partial update to every Node selected by
+// ids and/or filter.
+//
+// When only a filter is passed, every Node of namedType is checked
+// against it.
+func UpdateMany(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName *string,
+	namedType string,
+	edgeTypes []types.Edge,
+	uniqueFields []types.UniqueField,
+	ids []string,
+	filter types.Filter,
+	data map[string]interface{},
+	now time.Time,
+) (
+	result Result,
+	errors []error,
+) {
+	var err error
+	ctx, segment := xray.BeginSubsegment(ctx, "UpdateMany")
+	defer segment.Close(err)
+
+	if data == nil {
+		errors = append(errors, fmt.Errorf("Cannot UpdateMany %s, no data passed", namedType))
+		return
+	}
+	if len(ids) == 0 && len(filter) == 0 {
+		errors = append(errors, fmt.Errorf("Cannot UpdateMany %s, no ids or filter passed", namedType))
+		return
+	}
+
+	edgesOnType := util.GetEdgesOnType(
+		namedType,
+		edgeTypes,
+	)
+
+	// Every Node would get the same value, so a @unique field can only be
+	// changed one Node at a time
+	for _, uniqueField := range util.GetUniqueFieldsOnType(namedType, uniqueFields) {
+		if _, ok := data[uniqueField.Field]; ok {
+			errors = append(errors, fmt.Errorf(
+				"Cannot UpdateMany %s, %s is @unique, update each node with update%s",
+				namedType,
+				uniqueField.Field,
+				namedType,
+			))
+			return
+		}
+	}
+
+	// Check the data is valid before selecting any nodes
+	_, err = newUpdateExpression(data, edgesOnType, now)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	selectedIDs, failed, err := selectNodes(
+		ctx,
+		dynamo,
+		*tableName,
+		namedType,
+		ids,
+		filter,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	segment.AddMetadata("selectedIDs", selectedIDs)
+
+	result = updateNodes(
+		ctx,
+		dynamo,
+		*tableName,
+		namedType,
+		edgesOnType,
+		selectedIDs,
+		data,
+		now,
+	)
+	result.Failed = append(failed, result.Failed...)
+	return
+}
+
+// selectNodes returns the ids of the Nodes to update, and a failure for
+// each id passed that does not exist
+func selectNodes(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	ids []string,
+	filter types.Filter,
+) (
+	selectedIDs []string,
+	failed []Failure,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "selectNodes")
+	defer segment.Close(err)
+
+	var nodes []types.Node
+
+	if len(ids) > 0 {
+		ids = util.UniqueIDs(ids)
+
+		var hydratedNodes []types.Node
+		hydratedNodes, err = database.HydrateNodes(
+			ctx,
+			dynamo,
+			tableName,
+			ids,
+			false,
+		)
+		if err != nil {
+			return
+		}
+
+		nodesByID := make(map[string]types.Node)
+		for _, node := range hydratedNodes {
+			if node["linnet:namedType"] == namedType {
+				nodesByID[node["id"].(string)] = node
+			}
+		}
+
+		// Keep the order the ids were passed in
+		for _, id := range ids {
+			if node, ok := nodesByID[id]; ok {
+				nodes = append(nodes, node)
+			} else {
+				failed = append(failed, Failure{
+					ID: id,
+					Error: types.NodeNotFoundError{
+						NamedType: namedType,
+						ID:        id,
+					}.Error(),
+				})
+			}
+		}
+	} else {
+		nodes, err = database.QueryNodesByNamedType(
+			ctx,
+			dynamo,
+			tableName,
+			namedType,
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(filter) > 0 {
+		nodes, err = connectionPlural.FilterNodes(
+			ctx,
+			filter,
+			nodes,
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, node := range nodes {
+		selectedIDs = append(selectedIDs, node["id"].(string))
+	}
+	return
+}
+
+// updateNodes applies the update to each node, running at most
+// MAX_CONCURRENT_UPDATES at a time
+func updateNodes(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	edgesOnType []types.Edge,
+	ids []string,
+	data map[string]interface{},
+	now time.Time,
+) (
+	result Result,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "updateNodes")
+	defer segment.Close(nil)
+
+	errs := make([]error, len(ids))
+	semaphore := make(chan struct{}, MAX_CONCURRENT_UPDATES)
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			// Each update needs its own expression, as UpdateNode adds
+			// its conditions to it
+			expression, err := newUpdateExpression(data, edgesOnType, now)
+			if err == nil {
+				_, err = database.UpdateNode(
+					ctx,
+					dynamo,
+					tableName,
+					namedType,
+					id,
+					expression,
+				)
+			}
+			errs[i] = err
+		}(i, id)
+	}
+	wg.Wait()
+
+	for i, id := range ids {
+		if errs[i] != nil {
+			result.Failed = append(result.Failed, Failure{
+				ID:    id,
+				Error: errs[i].Error(),
+			})
+		} else {
+			result.Updated = append(result.Updated, id)
+		}
+	}
+	return
+}
+
+// newUpdateExpression from the data passed to the mutation. Edges cannot
+// be changed on many nodes at once
+func newUpdateExpression(
+	data map[string]interface{},
+	edgesOnType []types.Edge,
+	now time.Time,
+) (
+	expression *database.UpdateExpression,
+	err error,
+) {
+	expression = database.NewUpdateExpression()
+
+	for fieldName, fieldValue := range data {
+		if util.IsReadOnlyField(fieldName) {
+			continue
+		}
+
+		foundEdge, edge := util.GetEdgeFromEdgeTypes(
+			fieldName,
+			edgesOnType,
+		)
+		if foundEdge {
+			err = fmt.Errorf(
+				"Cannot UpdateMany %s.%s, it is an edge",
+				edge.TypeName,
+				edge.Field,
+			)
+			return
+		}
+
+		if fieldValue == nil {
+			expression.Remove(fieldName)
+			continue
+		}
+
+		err = expression.Set(fieldName, fieldValue)
+		if err != nil {
+			return
+		}
+	}
+
+	err = expression.Set("updatedAt", now)
+	return
+}
@@ -0,0 +1,353 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDynamoDBClient holds Nodes by id, and applies SET actions to them.
+// Query returns one Node per page, to check every page is read
+type mockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	mutex sync.Mutex
+	nodes map[string]types.Node
+	order []string
+}
+
+func (m *mockDynamoDBClient) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchGetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchGetItemOutput,
+	error,
+) {
+	output := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]*dynamodb.AttributeValue{},
+	}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		for _, key := range keysAndAttributes.Keys {
+			if node, ok := m.nodes[*key["id"].S]; ok {
+				item, err := dynamodbattribute.MarshalMap(node)
+				if err != nil {
+					return nil, err
+				}
+				output.Responses[tableName] = append(output.Responses[tableName], item)
+			}
+		}
+	}
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	start := 0
+	if input.ExclusiveStartKey != nil {
+		for i, id := range m.order {
+			if id == *input.ExclusiveStartKey["id"].S {
+				start = i + 1
+			}
+		}
+	}
+
+	output := &dynamodb.QueryOutput{}
+	for i := start; i < len(m.order); i++ {
+		node := m.nodes[m.order[i]]
+		if node["linnet:namedType"] != *input.ExpressionAttributeValues[":namedType"].S ||
+			node["linnet:ttl"] != nil {
+			continue
+		}
+
+		item, err := dynamodbattribute.MarshalMap(node)
+		if err != nil {
+			return nil, err
+		}
+		output.Items = append(output.Items, item)
+		output.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{
+			"id": &dynamodb.AttributeValue{S: aws.String(m.order[i])},
+		}
+		break
+	}
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) UpdateItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.UpdateItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.UpdateItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	node, ok := m.nodes[*input.Key["id"].S]
+	if !ok || node["linnet:ttl"] != nil {
+		return nil, awserr.New(
+			dynamodb.ErrCodeConditionalCheckFailedException,
+			"The conditional request failed",
+			nil,
+		)
+	}
+
+	// Apply each "#name = :value" action from the SET clause
+	actions := strings.Split(strings.Split(*input.UpdateExpression, " ADD ")[0], " REMOVE ")[0]
+	for _, action := range strings.Split(strings.TrimPrefix(actions, "SET "), ", ") {
+		parts := strings.Split(action, " = ")
+		if len(parts) == 2 {
+			var value interface{}
+			err := dynamodbattribute.Unmarshal(input.ExpressionAttributeValues[parts[1]], &value)
+			if err != nil {
+				return nil, err
+			}
+			node[*input.ExpressionAttributeNames[parts[0]]] = value
+		}
+	}
+
+	attributes, err := dynamodbattribute.MarshalMap(node)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.UpdateItemOutput{
+		Attributes: attributes,
+	}, nil
+}
+
+func TestUpdateMany(t *testing.T) {
+	type Input struct {
+		ids    []string
+		filter types.Filter
+		data   map[string]interface{}
+	}
+
+	type Output struct {
+		result Result
+		errors []string
+		// The status of each stored Order after the update
+		status map[string]string
+	}
+
+	currentTime := time.Unix(1517446800, 10).UTC()
+
+	edgeTypes := []types.Edge{
+		types.Edge{
+			TypeName:    "Order",
+			Field:       "customer",
+			FieldType:   "Customer",
+			EdgeName:    "OrdersOnCustomer",
+			Required:    true,
+			Cardinality: "ONE",
+			Principal:   "FALSE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Customer",
+				Field:    "orders",
+			},
+		},
+	}
+
+	uniqueFields := []types.UniqueField{
+		types.UniqueField{TypeName: "Order", Field: "reference"},
+	}
+
+	tests := []struct {
+		input  Input
+		output Output
+	}{
+		{
+			input: Input{
+				ids: []string{"order-1", "order-3", "order-1", "order-9"},
+				data: map[string]interface{}{
+					"status": "PAID",
+				},
+			},
+			output: Output{
+				result: Result{
+					Updated: []string{"order-1", "order-3"},
+					Failed: []Failure{
+						Failure{ID: "order-9", Error: "Order order-9 does not exist"},
+					},
+				},
+				status: map[string]string{
+					"order-1": "PAID",
+					"order-2": "PAID",
+					"order-3": "PAID",
+					"order-4": "PENDING",
+				},
+			},
+		},
+		{
+			input: Input{
+				filter: types.Filter{
+					"status": types.FilterConfigValue{
+						"equalTo": "PENDING",
+					},
+				},
+				data: map[string]interface{}{
+					"status": "PAID",
+				},
+			},
+			output: Output{
+				result: Result{
+					Updated: []string{"order-1", "order-4"},
+				},
+				status: map[string]string{
+					"order-1": "PAID",
+					"order-2": "PAID",
+					"order-3": "CANCELLED",
+					"order-4": "PAID",
+				},
+			},
+		},
+		{
+			input: Input{
+				ids: []string{"order-1", "order-2", "order-5"},
+				filter: types.Filter{
+					"status": types.FilterConfigValue{
+						"equalTo": "PENDING",
+					},
+				},
+				data: map[string]interface{}{
+					"status": "PAID",
+				},
+			},
+			output: Output{
+				result: Result{
+					Updated: []string{"order-1"},
+					Failed: []Failure{
+						Failure{ID: "order-5", Error: "Order order-5 does not exist"},
+					},
+				},
+				status: map[string]string{
+					"order-1": "PAID",
+					"order-2": "PAID",
+					"order-3": "CANCELLED",
+					"order-4": "PENDING",
+				},
+			},
+		},
+		{
+			input: Input{
+				ids: []string{"order-1"},
+				data: map[string]interface{}{
+					"customer": map[string]interface{}{
+						"connection": "customer-1",
+					},
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot UpdateMany Order.customer, it is an edge",
+				},
+				status: map[string]string{
+					"order-1": "PENDING",
+				},
+			},
+		},
+		{
+			input: Input{
+				ids: []string{"order-1", "order-2"},
+				data: map[string]interface{}{
+					"reference": "ORD-1",
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot UpdateMany Order, reference is @unique, update each node with updateOrder",
+				},
+				status: map[string]string{
+					"order-1": "PENDING",
+				},
+			},
+		},
+		{
+			input: Input{
+				data: map[string]interface{}{
+					"status": "PAID",
+				},
+			},
+			output: Output{
+				errors: []string{
+					"Cannot UpdateMany Order, no ids or filter passed",
+				},
+				status: map[string]string{
+					"order-1": "PENDING",
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestUpdateMany")
+
+		assert := assert.New(t)
+
+		mockDynamoDB := &mockDynamoDBClient{
+			nodes: map[string]types.Node{
+				"order-1": types.Node{"id": "order-1", "linnet:dataType": "Node", "linnet:namedType": "Order", "status": "PENDING"},
+				"order-2": types.Node{"id": "order-2", "linnet:dataType": "Node", "linnet:namedType": "Order", "status": "PAID"},
+				"order-3": types.Node{"id": "order-3", "linnet:dataType": "Node", "linnet:namedType": "Order", "status": "CANCELLED"},
+				"order-4": types.Node{"id": "order-4", "linnet:dataType": "Node", "linnet:namedType": "Order", "status": "PENDING"},
+				// A deleted Order
+				"order-5":    types.Node{"id": "order-5", "linnet:dataType": "Node", "linnet:namedType": "Order", "status": "PENDING", "linnet:ttl": 1517446800},
+				"customer-1": types.Node{"id": "customer-1", "linnet:dataType": "Node", "linnet:namedType": "Customer"},
+			},
+			order: []string{"customer-1", "order-1", "order-2", "order-3", "order-4", "order-5"},
+		}
+
+		result, errs := UpdateMany(
+			ctx,
+			mockDynamoDB,
+			aws.String("DynamoDBTestTable"),
+			"Order",
+			edgeTypes,
+			uniqueFields,
+			test.input.ids,
+			test.input.filter,
+			test.input.data,
+			currentTime,
+		)
+
+		var errors []string
+		for _, err := range errs {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(test.output.errors, errors, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.output.result, result, fmt.Sprintf("Test %d", i))
+
+		for id, status := range test.output.status {
+			assert.Equal(
+				status,
+				mockDynamoDB.nodes[id]["status"],
+				fmt.Sprintf("Test %d: %s", i, id),
+			)
+		}
+		for _, id := range result.Updated {
+			assert.Equal(
+				"2018-02-01T01:00:00.00000001Z",
+				mockDynamoDB.nodes[id]["updatedAt"],
+				fmt.Sprintf("Test %d: %s", i, id),
+			)
+		}
+	}
+}
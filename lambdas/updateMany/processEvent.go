@@ -4,25 +4,51 @@ import (
 	"context"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/updateMany/item"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
 func processEvent(
 	ctx context.Context,
 	dynamo *dynamodb.DynamoDB,
-	event *types.LambdaEvent,
+	event *UpdateManyLambdaEvent,
 	currentTime time.Time,
 ) (
-	rootNode types.Node,
-	err error,
+	response types.LambdaResponse,
 ) {
 	ctx, segment := xray.BeginSubsegment(ctx, "processEvent")
 	defer segment.Close(nil)
 
 	xray.AWS(dynamo.Client)
 
-	// If successfully created, return a cleaned Root Node
-	return
+	result, errs := item.UpdateMany(
+		ctx,
+		dynamo,
+		aws.String(event.DataSource.TableName),
+		event.NamedType,
+		event.EdgeTypes,
+		event.UniqueFields,
+		event.Context.Arguments.Where.IDs,
+		event.Context.Arguments.Filter,
+		event.Context.Arguments.Data,
+		currentTime,
+	)
+
+	if errs != nil {
+		for _, err := range errs {
+			response.Errors = append(response.Errors, err.Error())
+		}
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"count":   len(result.Updated),
+		"updated": result.Updated,
+		"failed":  result.Failed,
+	}
+
+	return response
 }
@@ -0,0 +1,32 @@
+package main
+
+import "github.com/ojkelly/linnet/lambdas/util/types"
+
+// UpdateManyLambdaEvent -
+type UpdateManyLambdaEvent struct {
+	LinnetFields []string                        `json:"linnetFields"`
+	DataSource   types.DataSourceDynamoDBConfig  `json:"dataSource"`
+	NamedType    string                          `json:"namedType"`
+	EdgeTypes    []types.Edge                    `json:"edgeTypes"`
+	UniqueFields []types.UniqueField             `json:"uniqueFields"`
+	Context      UpdateManyLambdaResolverContext `json:"context"`
+}
+
+// UpdateManyLambdaResolverContext -
+type UpdateManyLambdaResolverContext struct {
+	Arguments UpdateManyLambdaArguments `json:"arguments"`
+	Result    interface{}               `json:"result"`
+	Source    interface{}               `json:"source"`
+}
+
+// UpdateManyLambdaArguments -
+type UpdateManyLambdaArguments struct {
+	Where  WhereArguments         `json:"where"`
+	Filter types.Filter           `json:"filter"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+// WhereArguments -
+type WhereArguments struct {
+	IDs []string `json:"ids"`
+}
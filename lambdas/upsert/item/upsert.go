@@ -0,0 +1,317 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	createItem "github.com/ojkelly/linnet/lambdas/create/item"
+	updateItem "github.com/ojkelly/linnet/lambdas/update/item"
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+// MAX_ATTEMPTS is how many times the guard is read again, when another
+// upsert changes it between the read and the write
+var MAX_ATTEMPTS = 3
+
+// Upsert the Node selected by the single field and value in where. The
+// guard item for the value says which Node holds it. When that Node
+// exists the update data is applied to it, otherwise the guard is claimed
+// and a Node is created from the create data.
+func Upsert(
+	ctx context.Context,
+	event *types.LambdaEvent,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName *string,
+	now time.Time,
+) (
+	rootNode types.Node,
+	created bool,
+	errors []error,
+) {
+	var err error
+	ctx, segment := xray.BeginSubsegment(ctx, "Upsert")
+	defer segment.Close(err)
+
+	field, value, err := extractUpsertWhere(event.NamedType, event.Context.Arguments)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	// Only a @unique field has a guard to find the Node by
+	isUnique := false
+	for _, uniqueField := range util.GetUniqueFieldsOnType(event.NamedType, event.UniqueFields) {
+		if uniqueField.Field == field {
+			isUnique = true
+		}
+	}
+	if !isUnique {
+		errors = append(errors, fmt.Errorf(
+			"Cannot upsert %s, %s is not @unique",
+			event.NamedType,
+			field,
+		))
+		return
+	}
+
+	createInput, _ := event.Context.Arguments["create"].(map[string]interface{})
+	updateInput, _ := event.Context.Arguments["update"].(map[string]interface{})
+	if createInput == nil || updateInput == nil {
+		errors = append(errors, fmt.Errorf(
+			"Cannot upsert %s, create and update must both be passed",
+			event.NamedType,
+		))
+		return
+	}
+
+	if createValue, ok := createInput[field]; ok && !reflect.DeepEqual(createValue, value) {
+		errors = append(errors, fmt.Errorf(
+			"Cannot upsert %s, create.%s must match where.%s",
+			event.NamedType,
+			field,
+			field,
+		))
+		return
+	}
+
+	guardKey := database.UniqueGuardKey(event.NamedType, field, value)
+
+	for attempt := 0; attempt < MAX_ATTEMPTS; attempt++ {
+		var guard *database.UniqueGuard
+		guard, err = database.GetUniqueGuard(
+			ctx,
+			dynamo,
+			*tableName,
+			guardKey,
+		)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+
+		// A guard whose Node is gone, or no longer has the value, can be
+		// replaced
+		var staleNodeID string
+		if guard != nil {
+			if guard.Pending(now) {
+				errors = append(errors, fmt.Errorf(
+					"Cannot upsert %s, another request for %s %v has not finished, try again",
+					event.NamedType,
+					field,
+					value,
+				))
+				return
+			}
+
+			var held bool
+			held, err = database.HoldsUniqueValue(
+				ctx,
+				dynamo,
+				*tableName,
+				*guard,
+				database.UniqueValue{
+					NamedType: event.NamedType,
+					Field:     field,
+					Value:     value,
+					NodeID:    guard.NodeID,
+				},
+				now,
+			)
+			if err != nil {
+				errors = append(errors, err)
+				return
+			}
+
+			if held {
+				rootNode, errors = updateItem.Update(
+					ctx,
+					withArguments(event, map[string]interface{}{
+						"where": map[string]interface{}{"id": guard.NodeID},
+						"data":  updateInput,
+					}),
+					dynamo,
+					tableName,
+					now,
+				)
+				// A Node deleted since it was read leaves a stale guard
+				if len(errors) != 1 || !isNodeNotFound(errors[0]) {
+					return
+				}
+				errors = nil
+			}
+			staleNodeID = guard.NodeID
+		}
+
+		nodeID := uuid.NewV4().String()
+		if clientID, ok := createInput["id"].(string); ok {
+			nodeID = clientID
+		}
+
+		var claimed bool
+		claimed, err = database.ClaimUniqueGuard(
+			ctx,
+			dynamo,
+			*tableName,
+			guardKey,
+			nodeID,
+			staleNodeID,
+			now,
+		)
+		if err != nil {
+			errors = append(errors, err)
+			return
+		}
+		if !claimed {
+			// Another upsert claimed it first, so read it again
+			continue
+		}
+
+		return createNode(
+			ctx,
+			event,
+			dynamo,
+			tableName,
+			now,
+			guardKey,
+			nodeID,
+			field,
+			value,
+			createInput,
+		)
+	}
+
+	errors = append(errors, fmt.Errorf(
+		"Cannot upsert %s, %s %v changed while upserting, try again",
+		event.NamedType,
+		field,
+		value,
+	))
+	return
+}
+
+// createNode for an upsert, once the guard has been claimed for nodeID.
+// When nothing is created the guard is released again.
+func createNode(
+	ctx context.Context,
+	event *types.LambdaEvent,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName *string,
+	now time.Time,
+	guardKey map[string]*dynamodb.AttributeValue,
+	nodeID string,
+	field string,
+	value interface{},
+	createInput map[string]interface{},
+) (
+	rootNode types.Node,
+	created bool,
+	errors []error,
+) {
+	data := make(map[string]interface{})
+	for key, fieldValue := range createInput {
+		data[key] = fieldValue
+	}
+	data["id"] = nodeID
+	data[field] = value
+
+	rootNode, errors = createItem.Create(
+		ctx,
+		withArguments(event, map[string]interface{}{
+			"data": data,
+		}),
+		dynamo,
+		tableName,
+		now,
+	)
+	if rootNode == nil {
+		err := database.ReleaseUniqueGuard(
+			ctx,
+			dynamo,
+			*tableName,
+			guardKey,
+			nodeID,
+		)
+		if err != nil {
+			errors = append(errors, err)
+		}
+		return nil, false, errors
+	}
+
+	err := database.ConfirmUniqueGuard(
+		ctx,
+		dynamo,
+		*tableName,
+		guardKey,
+		nodeID,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+	return rootNode, true, errors
+}
+
+// extractUpsertWhere gets the single field and value the Node is selected by
+func extractUpsertWhere(
+	namedType string,
+	arguments map[string]interface{},
+) (
+	field string,
+	value interface{},
+	err error,
+) {
+	where, _ := arguments["where"].(map[string]interface{})
+
+	var fields int
+	for whereField, whereValue := range where {
+		if whereValue == nil {
+			continue
+		}
+		field = whereField
+		value = whereValue
+		fields = fields + 1
+	}
+
+	if fields != 1 {
+		err = fmt.Errorf(
+			"Cannot upsert %s, where must have exactly one field, it has %d",
+			namedType,
+			fields,
+		)
+		return
+	}
+
+	switch value.(type) {
+	case string, types.Number, float64, bool:
+	default:
+		err = fmt.Errorf(
+			"Cannot upsert %s, where.%s must be a String, Int, Float or Boolean",
+			namedType,
+			field,
+		)
+	}
+	return
+}
+
+// withArguments copies the event, with new arguments for create or update
+func withArguments(
+	event *types.LambdaEvent,
+	arguments map[string]interface{},
+) *types.LambdaEvent {
+	copied := *event
+	copied.Context.Arguments = arguments
+	return &copied
+}
+
+func isNodeNotFound(err error) bool {
+	_, ok := err.(types.NodeNotFoundError)
+	return ok
+}
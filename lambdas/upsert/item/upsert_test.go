@@ -0,0 +1,425 @@
+package item
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/constants"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDynamoDBClient stores items by "id|linnet:dataType", and checks the
+// conditions used on Nodes and guards. When raceNodeID is set, the first
+// guard claimed fails, as if an upsert for raceNodeID claimed it first.
+type mockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	raceNodeID string
+
+	mutex sync.Mutex
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+var conditionFailed = awserr.New(
+	dynamodb.ErrCodeConditionalCheckFailedException,
+	"The conditional request failed",
+	nil,
+)
+
+func itemKey(key map[string]*dynamodb.AttributeValue) string {
+	return *key["id"].S + "|" + *key["linnet:dataType"].S
+}
+
+func (m *mockDynamoDBClient) GetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.GetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.GetItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return &dynamodb.GetItemOutput{Item: m.items[itemKey(input.Key)]}, nil
+}
+
+func (m *mockDynamoDBClient) PutItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.PutItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.PutItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := itemKey(input.Item)
+	stored, exists := m.items[key]
+
+	if strings.HasPrefix(key, "unique::") {
+		if m.raceNodeID != "" {
+			m.items[key] = map[string]*dynamodb.AttributeValue{
+				"id":              input.Item["id"],
+				"linnet:dataType": input.Item["linnet:dataType"],
+				"linnet:node":     &dynamodb.AttributeValue{S: aws.String(m.raceNodeID)},
+			}
+			m.raceNodeID = ""
+			return nil, conditionFailed
+		}
+		if exists {
+			staleNode := input.ExpressionAttributeValues[":staleNode"]
+			if staleNode == nil || *staleNode.S != *stored["linnet:node"].S {
+				return nil, conditionFailed
+			}
+		}
+	} else if exists {
+		// attribute_not_exists(id)
+		return nil, conditionFailed
+	}
+
+	m.items[key] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchGetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchGetItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	output := &dynamodb.BatchGetItemOutput{
+		Responses: make(map[string][]map[string]*dynamodb.AttributeValue),
+	}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		for _, key := range keysAndAttributes.Keys {
+			if stored, exists := m.items[itemKey(key)]; exists {
+				output.Responses[tableName] = append(output.Responses[tableName], stored)
+			}
+		}
+	}
+	return output, nil
+}
+
+func (m *mockDynamoDBClient) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchWriteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchWriteItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, requests := range input.RequestItems {
+		for _, writeRequest := range requests {
+			if writeRequest.DeleteRequest != nil {
+				delete(m.items, itemKey(writeRequest.DeleteRequest.Key))
+				continue
+			}
+			m.items[itemKey(writeRequest.PutRequest.Item)] = writeRequest.PutRequest.Item
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) UpdateItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.UpdateItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.UpdateItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := itemKey(input.Key)
+	stored, exists := m.items[key]
+
+	if strings.HasPrefix(key, "unique::") {
+		// ConfirmUniqueGuard
+		if !exists || *stored["linnet:node"].S != *input.ExpressionAttributeValues[":node"].S {
+			return nil, conditionFailed
+		}
+		delete(stored, "linnet:ttl")
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+
+	if !exists || stored["linnet:ttl"] != nil {
+		return nil, conditionFailed
+	}
+
+	// Apply each "#name = :value" action from the SET clause
+	actions := strings.Split(strings.Split(*input.UpdateExpression, " ADD ")[0], " REMOVE ")[0]
+	for _, action := range strings.Split(strings.TrimPrefix(actions, "SET "), ", ") {
+		parts := strings.Split(action, " = ")
+		if len(parts) == 2 {
+			stored[*input.ExpressionAttributeNames[parts[0]]] = input.ExpressionAttributeValues[parts[1]]
+		}
+	}
+	return &dynamodb.UpdateItemOutput{Attributes: stored}, nil
+}
+
+func (m *mockDynamoDBClient) DeleteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.DeleteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.DeleteItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := itemKey(input.Key)
+	stored, exists := m.items[key]
+	if !exists || *stored["linnet:node"].S != *input.ExpressionAttributeValues[":node"].S {
+		return nil, conditionFailed
+	}
+	delete(m.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestUpsert(t *testing.T) {
+	currentTime := time.Unix(1517446800, 10).UTC()
+	guardKey := "unique::Customer::email::a@example.com|unique::Customer::email::a@example.com"
+
+	customer := func(id string, name string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"id":               &dynamodb.AttributeValue{S: aws.String(id)},
+			"linnet:dataType":  &dynamodb.AttributeValue{S: aws.String("Node")},
+			"linnet:namedType": &dynamodb.AttributeValue{S: aws.String("Customer")},
+			"email":            &dynamodb.AttributeValue{S: aws.String("a@example.com")},
+			"name":             &dynamodb.AttributeValue{S: aws.String(name)},
+		}
+	}
+	guard := func(nodeID string, ttl *time.Time) map[string]*dynamodb.AttributeValue {
+		item := map[string]*dynamodb.AttributeValue{
+			"id":              &dynamodb.AttributeValue{S: aws.String("unique::Customer::email::a@example.com")},
+			"linnet:dataType": &dynamodb.AttributeValue{S: aws.String("unique::Customer::email::a@example.com")},
+			"linnet:node":     &dynamodb.AttributeValue{S: aws.String(nodeID)},
+		}
+		if ttl != nil {
+			item["linnet:ttl"] = &dynamodb.AttributeValue{
+				N: aws.String(strconv.FormatInt(ttl.Unix(), 10)),
+			}
+		}
+		return item
+	}
+	later := currentTime.Add(time.Minute)
+	earlier := currentTime.Add(-time.Minute)
+
+	arguments := func(where map[string]interface{}, create map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"where":  where,
+			"create": create,
+			"update": map[string]interface{}{"name": "updated"},
+		}
+	}
+	byEmail := map[string]interface{}{"email": "a@example.com"}
+	createInput := map[string]interface{}{"name": "created"}
+
+	type Output struct {
+		created bool
+		name    string
+		// guardNode is the Node the guard holds after the upsert, "new"
+		// for a created Node
+		guardNode string
+		err       string
+	}
+
+	tests := []struct {
+		items      map[string]map[string]*dynamodb.AttributeValue
+		raceNodeID string
+		arguments  map[string]interface{}
+		output     Output
+	}{
+		{
+			// Without a guard, the Node is created
+			arguments: arguments(byEmail, createInput),
+			output:    Output{created: true, name: "created", guardNode: "new"},
+		},
+		{
+			// The Node the guard holds is updated
+			items: map[string]map[string]*dynamodb.AttributeValue{
+				"customer-1|Node": customer("customer-1", "existing"),
+				guardKey:          guard("customer-1", nil),
+			},
+			arguments: arguments(byEmail, createInput),
+			output:    Output{name: "updated", guardNode: "customer-1"},
+		},
+		{
+			// The Node the guard held is gone, so a new one is created
+			items: map[string]map[string]*dynamodb.AttributeValue{
+				guardKey: guard("customer-1", nil),
+			},
+			arguments: arguments(byEmail, createInput),
+			output:    Output{created: true, name: "created", guardNode: "new"},
+		},
+		{
+			items: map[string]map[string]*dynamodb.AttributeValue{
+				guardKey: guard("customer-1", &later),
+			},
+			arguments: arguments(byEmail, createInput),
+			output: Output{
+				guardNode: "customer-1",
+				err:       "Cannot upsert Customer, another request for email a@example.com has not finished, try again",
+			},
+		},
+		{
+			// An upsert that stopped before creating its Node
+			items: map[string]map[string]*dynamodb.AttributeValue{
+				guardKey: guard("customer-1", &earlier),
+			},
+			arguments: arguments(byEmail, createInput),
+			output:    Output{created: true, name: "created", guardNode: "new"},
+		},
+		{
+			// Another upsert claims the guard between the read and write
+			items: map[string]map[string]*dynamodb.AttributeValue{
+				"customer-2|Node": customer("customer-2", "existing"),
+			},
+			raceNodeID: "customer-2",
+			arguments:  arguments(byEmail, createInput),
+			output:     Output{name: "updated", guardNode: "customer-2"},
+		},
+		{
+			// A failed create releases the guard
+			items: map[string]map[string]*dynamodb.AttributeValue{
+				"customer-3|Node": customer("customer-3", "existing"),
+			},
+			arguments: arguments(byEmail, map[string]interface{}{"id": "customer-3"}),
+			output: Output{
+				err: "Cannot create Customer, 1 of 1 items were not written and the 0 written were removed: Cannot write Customer customer-3, an item with this id already exists",
+			},
+		},
+		{
+			arguments: arguments(
+				map[string]interface{}{"email": "a@example.com", "name": "a"},
+				createInput,
+			),
+			output: Output{
+				err: "Cannot upsert Customer, where must have exactly one field, it has 2",
+			},
+		},
+		{
+			arguments: arguments(
+				byEmail,
+				map[string]interface{}{"email": "b@example.com"},
+			),
+			output: Output{
+				err: "Cannot upsert Customer, create.email must match where.email",
+			},
+		},
+		{
+			// The Node the guard holds no longer has the value
+			items: map[string]map[string]*dynamodb.AttributeValue{
+				"customer-1|Node": map[string]*dynamodb.AttributeValue{
+					"id":               &dynamodb.AttributeValue{S: aws.String("customer-1")},
+					"linnet:dataType":  &dynamodb.AttributeValue{S: aws.String("Node")},
+					"linnet:namedType": &dynamodb.AttributeValue{S: aws.String("Customer")},
+					"email":            &dynamodb.AttributeValue{S: aws.String("b@example.com")},
+				},
+				guardKey: guard("customer-1", nil),
+			},
+			arguments: arguments(byEmail, createInput),
+			output:    Output{created: true, name: "created", guardNode: "new"},
+		},
+		{
+			arguments: arguments(
+				map[string]interface{}{"name": "a"},
+				createInput,
+			),
+			output: Output{
+				err: "Cannot upsert Customer, name is not @unique",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestUpsert")
+		assert := assert.New(t)
+
+		items := make(map[string]map[string]*dynamodb.AttributeValue)
+		for key, item := range test.items {
+			copied := make(map[string]*dynamodb.AttributeValue)
+			for name, value := range item {
+				copied[name] = value
+			}
+			items[key] = copied
+		}
+		mockDynamoDB := &mockDynamoDBClient{
+			raceNodeID: test.raceNodeID,
+			items:      items,
+		}
+
+		output, created, errors := Upsert(
+			ctx,
+			&types.LambdaEvent{
+				LinnetFields: constants.LinnetFields,
+				DataSource: types.DataSourceDynamoDBConfig{
+					TableName: "DynamoDBTestTable",
+				},
+				NamedType: "Customer",
+				UniqueFields: []types.UniqueField{
+					types.UniqueField{TypeName: "Customer", Field: "email"},
+				},
+				Context: types.LinnetResolverContext{
+					Arguments: test.arguments,
+				},
+			},
+			mockDynamoDB,
+			aws.String("DynamoDBTestTable"),
+			currentTime,
+		)
+
+		guardItem := mockDynamoDB.items[guardKey]
+
+		if test.output.err != "" {
+			if assert.Len(errors, 1, fmt.Sprintf("Test %d", i)) {
+				assert.EqualError(errors[0], test.output.err, fmt.Sprintf("Test %d", i))
+			}
+			assert.Nil(output, fmt.Sprintf("Test %d", i))
+			if test.output.guardNode == "" {
+				assert.Nil(guardItem, fmt.Sprintf("Test %d", i))
+			} else if assert.NotNil(guardItem, fmt.Sprintf("Test %d", i)) {
+				assert.Equal(test.output.guardNode, *guardItem["linnet:node"].S, fmt.Sprintf("Test %d", i))
+			}
+			continue
+		}
+
+		assert.Nil(errors, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.output.created, created, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.output.name, output["name"], fmt.Sprintf("Test %d", i))
+		assert.Equal("a@example.com", output["email"], fmt.Sprintf("Test %d", i))
+
+		// The guard holds the Node returned, and is no longer pending
+		if assert.NotNil(guardItem, fmt.Sprintf("Test %d", i)) {
+			guardNode := test.output.guardNode
+			if guardNode == "new" {
+				guardNode = output["id"].(string)
+				assert.NotNil(mockDynamoDB.items[guardNode+"|Node"], fmt.Sprintf("Test %d", i))
+			}
+			assert.Equal(guardNode, *guardItem["linnet:node"].S, fmt.Sprintf("Test %d", i))
+			assert.Nil(guardItem["linnet:ttl"], fmt.Sprintf("Test %d", i))
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package util
 
-import "github.com/ojkelly/linnet/lambdas/util/types"
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
 
 // ChunkNodes an array into smaller arrays
 func ChunkNodes(array []types.Node, chunkSize int) (divided [][]types.Node) {
@@ -29,3 +32,41 @@ func ChunkStringArray(array []string, chunkSize int) (divided [][]string) {
 	}
 	return
 }
+
+// ChunkKeys an array into smaller arrays
+func ChunkKeys(
+	array []map[string]*dynamodb.AttributeValue,
+	chunkSize int,
+) (
+	divided [][]map[string]*dynamodb.AttributeValue,
+) {
+	for i := 0; i < len(array); i += chunkSize {
+		end := i + chunkSize
+
+		if end > len(array) {
+			end = len(array)
+		}
+
+		divided = append(divided, array[i:end])
+	}
+	return
+}
+
+// ChunkWriteRequests an array into smaller arrays
+func ChunkWriteRequests(
+	array []*dynamodb.WriteRequest,
+	chunkSize int,
+) (
+	divided [][]*dynamodb.WriteRequest,
+) {
+	for i := 0; i < len(array); i += chunkSize {
+		end := i + chunkSize
+
+		if end > len(array) {
+			end = len(array)
+		}
+
+		divided = append(divided, array[i:end])
+	}
+	return
+}
@@ -6,4 +6,14 @@ var LinnetFields = []string{
 	"linnet:edge",
 	"linnet:namedType",
 	"linnet:ttl",
+	"linnet:version",
+}
+
+// ReadOnlyFields are set by linnet, and cannot be changed by an update
+var ReadOnlyFields = []string{
+	"id",
+	"createdAt",
+	"createdBy",
+	"updatedAt",
+	"version",
 }
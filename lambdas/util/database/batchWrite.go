@@ -2,83 +2,195 @@ package database
 
 import (
 	"context"
+	"math/rand"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
 )
 
-// BatchWriteRequestsToDynamoDB -
-func BatchWriteRequestsToDynamoDB(
+// BATCH_WRITE_BASE_DELAY is the backoff before the first retry of a batch,
+// it doubles on each retry after that
+var BATCH_WRITE_BASE_DELAY = 50 * time.Millisecond
+
+// BATCH_WRITE_MAX_DELAY is the longest backoff between two retries
+var BATCH_WRITE_MAX_DELAY = 5 * time.Second
+
+// BatchWriteResult splits the requests passed to BatchWriteRequests into
+// the ones DynamoDB wrote, and the ones that failed
+type BatchWriteResult struct {
+	Written []*dynamodb.WriteRequest
+	Failed  []*dynamodb.WriteRequest
+}
+
+// BatchWriteRequests writes the requests in batches of 25. Items DynamoDB
+// leaves unprocessed, and batches that are throttled, are retried up to
+// maxRetries times with a jittered exponential backoff.
+//
+// Every request ends up in either Written or Failed. When err is set the
+// batch that returned it, and every batch not yet sent, are Failed.
+func BatchWriteRequests(
 	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
 	tableName string,
 	requests []*dynamodb.WriteRequest,
-	dynamo dynamodbiface.DynamoDBAPI,
 	maxRetries int,
-	retryNumber int,
 ) (
-	unprocessedItems []*dynamodb.WriteRequest,
+	result BatchWriteResult,
 	err error,
 ) {
-	ctx, segment := xray.BeginSubsegment(ctx, "BatchWriteRequestsToDynamoDB")
+	ctx, segment := xray.BeginSubsegment(ctx, "BatchWriteRequests")
 	defer segment.Close(err)
 
-	batchWriteItemInput := dynamodb.BatchWriteItemInput{
-		RequestItems: map[string][]*dynamodb.WriteRequest{
-			tableName: requests,
-		},
+	chunks := util.ChunkWriteRequests(requests, 25)
+
+	for i, chunk := range chunks {
+		var written, failed []*dynamodb.WriteRequest
+		written, failed, err = batchWriteChunk(
+			ctx,
+			dynamo,
+			tableName,
+			chunk,
+			maxRetries,
+		)
+		result.Written = append(result.Written, written...)
+		result.Failed = append(result.Failed, failed...)
+
+		if err != nil {
+			for _, notSent := range chunks[i+1:] {
+				result.Failed = append(result.Failed, notSent...)
+			}
+			return
+		}
+	}
+	return
+}
+
+// batchWriteChunk writes up to 25 requests, retrying what is left until
+// it is written or maxRetries is reached
+func batchWriteChunk(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	requests []*dynamodb.WriteRequest,
+	maxRetries int,
+) (
+	written []*dynamodb.WriteRequest,
+	failed []*dynamodb.WriteRequest,
+	err error,
+) {
+	pending := requests
+
+	for retryNumber := 0; ; retryNumber++ {
+		if retryNumber > 0 {
+			err = backoff(ctx, retryNumber)
+			if err != nil {
+				return written, pending, err
+			}
+		}
+
+		batchWriteItemResult, err := dynamo.BatchWriteItemWithContext(
+			ctx,
+			&dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]*dynamodb.WriteRequest{
+					tableName: pending,
+				},
+			},
+		)
+		if err != nil {
+			// The whole batch was throttled, so none of it was written
+			if isRetryable(err) && retryNumber < maxRetries {
+				continue
+			}
+			return written, pending, err
+		}
+
+		var unprocessedItems []*dynamodb.WriteRequest
+		if batchWriteItemResult.UnprocessedItems != nil {
+			unprocessedItems = batchWriteItemResult.UnprocessedItems[tableName]
+		}
+		written = append(written, withoutRequests(pending, unprocessedItems)...)
+		pending = unprocessedItems
+
+		if len(pending) == 0 {
+			return written, nil, nil
+		}
+		if retryNumber >= maxRetries {
+			return written, pending, nil
+		}
 	}
+}
 
-	// fmt.Printf("%#v\n", batchWriteItemInput)
-
-	// Write the items
-	// batchWriteItemResult
-	_, err = dynamo.BatchWriteItemWithContext(
-		ctx,
-		&batchWriteItemInput,
-	)
-	if err != nil {
-		// if aerr, ok := err.(awserr.Error); ok {
-		// 	switch aerr.Code() {
-		// 	case dynamodb.ErrCodeProvisionedThroughputExceededException:
-		// 		fmt.Println(dynamodb.ErrCodeProvisionedThroughputExceededException, aerr.Error())
-		// 	case dynamodb.ErrCodeResourceNotFoundException:
-		// 		fmt.Println(dynamodb.ErrCodeResourceNotFoundException, aerr.Error())
-		// 	case dynamodb.ErrCodeInternalServerError:
-		// 		fmt.Println(dynamodb.ErrCodeInternalServerError, aerr.Error())
-		// 	default:
-		// 		fmt.Println(aerr.Error())
-		// 	}
-		// } else {
-		// 	// Print the error, cast err to awserr.Error to get the Code and
-		// 	// Message from an error.
-		// 	fmt.Println(err.Error())
-		// }
-		return
+// backoff waits before a retry, for a random time up to an exponential
+// delay. It returns early with the error of ctx when ctx is done.
+func backoff(ctx context.Context, retryNumber int) error {
+	delay := BATCH_WRITE_MAX_DELAY
+	if retryNumber < 32 && BATCH_WRITE_BASE_DELAY<<uint(retryNumber-1) < delay {
+		delay = BATCH_WRITE_BASE_DELAY << uint(retryNumber-1)
 	}
+	if delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay)) + 1)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
 
-	// If there are unprocessed items, we will retry them
-	// if batchWriteItemResult != nil {
-	// 	if batchWriteItemResult.UnprocessedItems != nil &&
-	// 		batchWriteItemResult.UnprocessedItems[tableName][0] != nil {
-	// 		unprocessedItems = batchWriteItemResult.UnprocessedItems[tableName]
-
-	// 		// retry the unprocessed items
-	// 		unprocessedItems, err = BatchWriteRequestsToDynamoDB(
-	// 			ctx,
-	// 			tableName,
-	// 			unprocessedItems,
-	// 			dynamo,
-	// 			maxRetries,
-	// 			retryNumber,
-	// 		)
-	// 		if err != nil {
-	// 			return unprocessedItems, err
-	// 		}
-
-	// 		retryNumber = retryNumber + 1
-	// 	}
-	// }
-
-	return unprocessedItems, err
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isRetryable is true for errors where the batch can be sent again, such
+// as throttling
+func isRetryable(err error) bool {
+	return request.IsErrorThrottle(err) || request.IsErrorRetryable(err)
+}
+
+// withoutRequests returns the requests that are not in unprocessed.
+// DynamoDB returns unprocessed items as copies, so they are matched by
+// their key.
+func withoutRequests(
+	requests []*dynamodb.WriteRequest,
+	unprocessed []*dynamodb.WriteRequest,
+) (
+	remaining []*dynamodb.WriteRequest,
+) {
+	left := make(map[string]int)
+	for _, request := range unprocessed {
+		left[writeRequestKey(request)]++
+	}
+	for _, request := range requests {
+		key := writeRequestKey(request)
+		if left[key] > 0 {
+			left[key]--
+			continue
+		}
+		remaining = append(remaining, request)
+	}
+	return
+}
+
+// writeRequestKey is the "id|linnet:dataType" of the item a request writes
+func writeRequestKey(request *dynamodb.WriteRequest) string {
+	item := map[string]*dynamodb.AttributeValue{}
+	if request.PutRequest != nil {
+		item = request.PutRequest.Item
+	} else if request.DeleteRequest != nil {
+		item = request.DeleteRequest.Key
+	}
+
+	var id, dataType string
+	if item["id"] != nil && item["id"].S != nil {
+		id = *item["id"].S
+	}
+	if item["linnet:dataType"] != nil && item["linnet:dataType"].S != nil {
+		dataType = *item["linnet:dataType"].S
+	}
+	return id + "|" + dataType
 }
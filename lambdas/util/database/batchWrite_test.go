@@ -1 +1,197 @@
 package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockThrottlingDynamoDBClient throttles the first throttled calls, then
+// writes at most perCall items of each batch and returns the rest as
+// UnprocessedItems. A failWith error is returned for every call.
+type mockThrottlingDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	throttled int
+	perCall   int
+	failWith  error
+	calls     int
+	written   []string
+}
+
+func (m *mockThrottlingDynamoDBClient) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchWriteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchWriteItemOutput,
+	error,
+) {
+	m.calls++
+	if m.failWith != nil {
+		return nil, m.failWith
+	}
+	if m.calls <= m.throttled {
+		return nil, awserr.New(
+			dynamodb.ErrCodeProvisionedThroughputExceededException,
+			"The level of configured provisioned throughput for the table was exceeded",
+			nil,
+		)
+	}
+
+	output := &dynamodb.BatchWriteItemOutput{
+		UnprocessedItems: map[string][]*dynamodb.WriteRequest{},
+	}
+	for tableName, requests := range input.RequestItems {
+		for i, writeRequest := range requests {
+			if i < m.perCall {
+				m.written = append(m.written, writeRequestKey(writeRequest))
+				continue
+			}
+			// DynamoDB returns copies of the unprocessed requests
+			unprocessed := *writeRequest
+			output.UnprocessedItems[tableName] = append(
+				output.UnprocessedItems[tableName],
+				&unprocessed,
+			)
+		}
+	}
+	return output, nil
+}
+
+func TestBatchWriteRequests(t *testing.T) {
+	BATCH_WRITE_BASE_DELAY = time.Millisecond
+	defer func() { BATCH_WRITE_BASE_DELAY = 50 * time.Millisecond }()
+
+	writeRequests := func(count int) (requests []*dynamodb.WriteRequest) {
+		for i := 0; i < count; i++ {
+			requests = append(requests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{
+					Key: map[string]*dynamodb.AttributeValue{
+						"id": &dynamodb.AttributeValue{
+							S: aws.String(fmt.Sprintf("node-%d", i)),
+						},
+						"linnet:dataType": &dynamodb.AttributeValue{
+							S: aws.String("Node"),
+						},
+					},
+				},
+			})
+		}
+		return
+	}
+
+	type Output struct {
+		written int
+		failed  int
+		calls   int
+		err     string
+	}
+
+	tests := []struct {
+		client    *mockThrottlingDynamoDBClient
+		requests  int
+		cancelled bool
+		output    Output
+	}{
+		{
+			// Unprocessed items are sent again until they are written
+			client:   &mockThrottlingDynamoDBClient{perCall: 10},
+			requests: 30,
+			output:   Output{written: 30, calls: 4},
+		},
+		{
+			// A throttled batch is sent again
+			client:   &mockThrottlingDynamoDBClient{perCall: 25, throttled: 2},
+			requests: 5,
+			output:   Output{written: 5, calls: 3},
+		},
+		{
+			// Items still unprocessed after the retries fail, 19 of the first
+			// batch of 25, while the second batch of 5 is written
+			client:   &mockThrottlingDynamoDBClient{perCall: 2},
+			requests: 30,
+			output:   Output{written: 11, failed: 19, calls: 6},
+		},
+		{
+			client:   &mockThrottlingDynamoDBClient{perCall: 25, throttled: 10},
+			requests: 5,
+			output: Output{
+				failed: 5,
+				calls:  3,
+				err:    "ProvisionedThroughputExceededException: The level of configured provisioned throughput for the table was exceeded",
+			},
+		},
+		{
+			// Errors that cannot be retried fail every batch
+			client: &mockThrottlingDynamoDBClient{
+				failWith: awserr.New(
+					dynamodb.ErrCodeResourceNotFoundException,
+					"Requested resource not found",
+					nil,
+				),
+			},
+			requests: 30,
+			output: Output{
+				failed: 30,
+				calls:  1,
+				err:    "ResourceNotFoundException: Requested resource not found",
+			},
+		},
+		{
+			// The backoff stops when ctx is done
+			client:    &mockThrottlingDynamoDBClient{perCall: 25, throttled: 1},
+			requests:  30,
+			cancelled: true,
+			output: Output{
+				failed: 30,
+				calls:  1,
+				err:    "context canceled",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestBatchWriteRequests")
+		assert := assert.New(t)
+
+		if test.cancelled {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			cancel()
+		}
+
+		result, err := BatchWriteRequests(
+			ctx,
+			test.client,
+			"test-table",
+			writeRequests(test.requests),
+			2,
+		)
+
+		if test.output.err != "" {
+			assert.EqualError(err, test.output.err, fmt.Sprintf("Test %d", i))
+		} else {
+			assert.NoError(err, fmt.Sprintf("Test %d", i))
+		}
+		assert.Len(result.Written, test.output.written, fmt.Sprintf("Test %d", i))
+		assert.Len(result.Failed, test.output.failed, fmt.Sprintf("Test %d", i))
+		assert.Len(test.client.written, test.output.written, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.output.calls, test.client.calls, fmt.Sprintf("Test %d", i))
+
+		// Every request is either written or failed, never both
+		keys := make(map[string]bool)
+		for _, writeRequest := range append(result.Written, result.Failed...) {
+			keys[writeRequestKey(writeRequest)] = true
+		}
+		assert.Len(keys, test.requests, fmt.Sprintf("Test %d", i))
+	}
+}
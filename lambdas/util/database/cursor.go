@@ -0,0 +1,61 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// encodeCursor turns a LastEvaluatedKey into a cursor that can be passed
+// back to us, an empty key is the end of the results
+func encodeCursor(
+	lastEvaluatedKey map[string]*dynamodb.AttributeValue,
+) (
+	cursor string,
+	err error,
+) {
+	if len(lastEvaluatedKey) == 0 {
+		return
+	}
+
+	keyMap := make(map[string]string)
+	err = dynamodbattribute.UnmarshalMap(lastEvaluatedKey, &keyMap)
+	if err != nil {
+		return
+	}
+
+	keyJSON, err := json.Marshal(keyMap)
+	if err != nil {
+		return
+	}
+
+	cursor = base64.StdEncoding.EncodeToString(keyJSON)
+	return
+}
+
+// decodeCursor back into the ExclusiveStartKey it was made from
+func decodeCursor(
+	cursor string,
+) (
+	exclusiveStartKey map[string]*dynamodb.AttributeValue,
+	err error,
+) {
+	if cursor == "" {
+		return
+	}
+
+	keyJSON, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return
+	}
+
+	keyMap := make(map[string]string)
+	err = json.Unmarshal(keyJSON, &keyMap)
+	if err != nil {
+		return
+	}
+
+	return dynamodbattribute.MarshalMap(keyMap)
+}
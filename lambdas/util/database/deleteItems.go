@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// DeleteItems removes every key from the table, in batches of 25. Items
+// DynamoDB leaves unprocessed are retried up to maxRetries times. Like
+// TombstoneItems, no more batches are started once the deadline is close.
+func DeleteItems(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	keys []map[string]*dynamodb.AttributeValue,
+	maxRetries int,
+) (
+	result TombstoneResult,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "DeleteItems")
+	defer segment.Close(err)
+
+	for _, requests := range MarshallKeysToDeleteRequests(keys) {
+		if PastDeadline(ctx) {
+			return result, nil
+		}
+
+		written, err := BatchWriteRequests(
+			ctx,
+			dynamo,
+			tableName,
+			requests,
+			maxRetries,
+		)
+		for _, request := range written.Written {
+			if *request.DeleteRequest.Key["linnet:dataType"].S == "Node" {
+				result.Nodes = result.Nodes + 1
+			} else {
+				result.Edges = result.Edges + 1
+			}
+		}
+		if err != nil {
+			return result, err
+		}
+		if len(written.Failed) > 0 {
+			return result, fmt.Errorf(
+				"Cannot delete %d items, DynamoDB did not process them after %d retries",
+				len(written.Failed),
+				maxRetries,
+			)
+		}
+	}
+
+	result.Complete = true
+	return
+}
+
+// FindInverseEdges returns the key of every edge item stored under another
+// Node that points to nodeID, through the edge-dataType index. Deleted
+// edges are included.
+func FindInverseEdges(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	nodeID string,
+) (
+	keys []map[string]*dynamodb.AttributeValue,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "FindInverseEdges")
+	defer segment.Close(err)
+
+	queryInput := dynamodb.QueryInput{
+		TableName: aws.String(tableName),
+		IndexName: aws.String("edge-dataType"),
+		ExpressionAttributeNames: map[string]*string{
+			"#edge":     aws.String("linnet:edge"),
+			"#dataType": aws.String("linnet:dataType"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":edgeValue": &dynamodb.AttributeValue{
+				S: aws.String(nodeID),
+			},
+		},
+		KeyConditionExpression: aws.String("#edge = :edgeValue"),
+		ProjectionExpression:   aws.String("id, #dataType"),
+	}
+
+	for {
+		queryResult, err := dynamo.QueryWithContext(
+			ctx,
+			&queryInput,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, queryResult.Items...)
+
+		if len(queryResult.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryResult.LastEvaluatedKey
+	}
+	return
+}
@@ -0,0 +1,303 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
+	nodeUtil "github.com/ojkelly/linnet/lambdas/util/node"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// MAX_CASCADE_DEPTH is how many edges away from the deleted Node a
+// CASCADE can reach
+var MAX_CASCADE_DEPTH = 5
+
+// MAX_CASCADE_NODES is the most Nodes a single delete can remove
+var MAX_CASCADE_NODES = 500
+
+// DeletePlan is the key of every item to delete, each key is only added
+// once. Node items are kept by their depth from the deleted Node.
+type DeletePlan struct {
+	nodes [][]map[string]*dynamodb.AttributeValue
+	edges []map[string]*dynamodb.AttributeValue
+	seen  map[string]bool
+}
+
+func (p *DeletePlan) add(id string, dataType string, depth int) {
+	if p.seen[id+"|"+dataType] {
+		return
+	}
+	p.seen[id+"|"+dataType] = true
+
+	key := map[string]*dynamodb.AttributeValue{
+		"id": &dynamodb.AttributeValue{
+			S: aws.String(id),
+		},
+		"linnet:dataType": &dynamodb.AttributeValue{
+			S: aws.String(dataType),
+		},
+	}
+
+	if dataType != "Node" {
+		p.edges = append(p.edges, key)
+		return
+	}
+	for len(p.nodes) <= depth {
+		p.nodes = append(p.nodes, nil)
+	}
+	p.nodes[depth] = append(p.nodes[depth], key)
+}
+
+// NodeIDs of every Node in the plan
+func (p *DeletePlan) NodeIDs() (ids []string) {
+	for _, keys := range p.nodes {
+		for _, key := range keys {
+			ids = append(ids, *key["id"].S)
+		}
+	}
+	return
+}
+
+// Phases to delete the plan in, each phase is finished before the next
+// one starts
+func (p *DeletePlan) Phases() (phases [][]map[string]*dynamodb.AttributeValue) {
+	for depth := len(p.nodes) - 1; depth > 0; depth-- {
+		if len(p.nodes[depth]) > 0 {
+			phases = append(phases, p.nodes[depth])
+		}
+	}
+	phases = append(phases, p.edges)
+	if len(p.nodes) > 0 {
+		phases = append(phases, p.nodes[0])
+	}
+	return
+}
+
+// PlanDelete walks the graph from the Node being deleted, breadth first,
+// and follows the delete policy on each of its Edges. Nothing is written.
+// Each Node is only visited once, so cycles in the graph end the walk.
+// With includeDeleted the items that are already deleted are planned too.
+func PlanDelete(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	edgeTypes []types.Edge,
+	id string,
+	includeDeleted bool,
+) (
+	plan DeletePlan,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "PlanDelete")
+	defer segment.Close(err)
+
+	type queuedNode struct {
+		id        string
+		namedType string
+		depth     int
+	}
+
+	plan.seen = make(map[string]bool)
+	queue := []queuedNode{{id: id, namedType: namedType}}
+	visited := map[string]bool{id: true}
+
+	now := time.Now()
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		// Every item under the Node's own hash key, this is the Node and
+		// the edges it is the principal of
+		itemsToDelete, err := FindAllItemsToDeleteWithHashKey(
+			ctx,
+			dynamo,
+			&tableName,
+			node.id,
+			includeDeleted,
+		)
+		if err != nil {
+			return plan, err
+		}
+
+		if includeDeleted {
+			// Every edge pointing to this Node, including ones that are
+			// not on its edge types any more
+			inverseEdges, err := FindInverseEdges(
+				ctx,
+				dynamo,
+				tableName,
+				node.id,
+			)
+			if err != nil {
+				return plan, err
+			}
+			*itemsToDelete = append(*itemsToDelete, inverseEdges...)
+		}
+
+		foundNode := false
+		for _, item := range *itemsToDelete {
+			dataType := *item["linnet:dataType"].S
+			if dataType == "Node" {
+				// A Node of another type is not deleted through the policies
+				// of namedType
+				if node.id == id &&
+					(item["linnet:namedType"] == nil || *item["linnet:namedType"].S != namedType) {
+					return plan, types.NodeNotFoundError{
+						NamedType: namedType,
+						ID:        id,
+					}
+				}
+				foundNode = true
+			}
+			plan.add(*item["id"].S, dataType, node.depth)
+		}
+
+		// A connected Node that is already deleted is still walked, as a
+		// delete that stopped part way can leave edges on it
+		if !foundNode && node.id == id {
+			return plan, types.NodeNotFoundError{
+				NamedType: namedType,
+				ID:        id,
+			}
+		}
+
+		for _, edge := range util.GetEdgesOnType(node.namedType, edgeTypes) {
+			connectedIDs, err := QueryAllEdges(
+				ctx,
+				dynamo,
+				tableName,
+				node.id,
+				edge,
+			)
+			if err != nil {
+				return plan, err
+			}
+
+			switch edge.OnDelete {
+			case types.RESTRICT:
+				// Nodes deleted by this cascade do not restrict it
+				restricted := 0
+				for _, connectedID := range connectedIDs {
+					if !visited[connectedID] {
+						restricted++
+					}
+				}
+				if restricted > 0 {
+					return plan, types.DeleteRestrictedError{
+						TypeName: edge.TypeName,
+						Field:    edge.Field,
+						EdgeName: edge.EdgeName,
+						NodeID:   node.id,
+						Count:    restricted,
+					}
+				}
+
+			case types.CASCADE:
+				for _, connectedID := range connectedIDs {
+					if visited[connectedID] {
+						continue
+					}
+					if node.depth+1 > MAX_CASCADE_DEPTH {
+						return plan, fmt.Errorf(
+							"Cannot delete %s %s, the cascade is deeper than %d edges",
+							namedType,
+							id,
+							MAX_CASCADE_DEPTH,
+						)
+					}
+
+					visited[connectedID] = true
+					if len(visited) > MAX_CASCADE_NODES {
+						return plan, fmt.Errorf(
+							"Cannot delete %s %s, the cascade is more than %d nodes",
+							namedType,
+							id,
+							MAX_CASCADE_NODES,
+						)
+					}
+
+					queue = append(queue, queuedNode{
+						id:        connectedID,
+						namedType: edge.FieldType,
+						depth:     node.depth + 1,
+					})
+				}
+			}
+
+			// The edge items are deleted for every policy, this also finds
+			// the edges stored under the connected Node
+			for _, connectedID := range connectedIDs {
+				edgeItem := nodeUtil.CreateEdgeItem(
+					ctx,
+					edge,
+					node.id,
+					connectedID,
+					now,
+					now,
+					"linnet",
+				)
+				plan.add(
+					edgeItem["id"].(string),
+					edgeItem["linnet:dataType"].(string),
+					node.depth,
+				)
+			}
+		}
+	}
+	return
+}
+
+// DeletePlanned items, with a linnet:ttl or removed when the mode is HARD.
+// Each phase of the plan is finished before the next one starts, and
+// Complete is false when one could not be finished.
+func DeletePlanned(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	plan DeletePlan,
+	mode types.DeleteMode,
+	ttl TTL,
+	maxRetries int,
+) (
+	result TombstoneResult,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "DeletePlanned")
+	defer segment.Close(err)
+
+	for _, phase := range plan.Phases() {
+		var deleted TombstoneResult
+		if mode == types.HARD {
+			deleted, err = DeleteItems(
+				ctx,
+				dynamo,
+				tableName,
+				phase,
+				maxRetries,
+			)
+		} else {
+			deleted, err = TombstoneItems(
+				ctx,
+				dynamo,
+				tableName,
+				phase,
+				ttl,
+			)
+		}
+		result.Nodes = result.Nodes + deleted.Nodes
+		result.Edges = result.Edges + deleted.Edges
+		if err != nil || !deleted.Complete {
+			return result, err
+		}
+	}
+	result.Complete = true
+	return
+}
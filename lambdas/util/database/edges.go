@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
+	nodeUtil "github.com/ojkelly/linnet/lambdas/util/node"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// EDGE_PAGE_SIZE is the number of edges read per query, when reading
+// every edge on a field
+var EDGE_PAGE_SIZE int64 = 100
+
+// QueryAllEdges reads every page of edges on a field, and returns the
+// ids of the connected nodes. Deleted edges are left out.
+func QueryAllEdges(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	nodeID string,
+	edge types.Edge,
+) (
+	ids []string,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "QueryAllEdges")
+	defer segment.Close(err)
+
+	cursor := ""
+	for {
+		var edges []string
+		edges, cursor, err = QueryForEdges(
+			ctx,
+			dynamo,
+			tableName,
+			nodeID,
+			edge,
+			EDGE_PAGE_SIZE,
+			cursor,
+			false,
+		)
+		if err != nil {
+			return
+		}
+		ids = append(ids, edges...)
+
+		if cursor == "" {
+			return
+		}
+	}
+}
+
+// DisconnectNodes deletes the edge items between a node and the nodes
+// passed, by setting their ttl. Edges that do not exist are skipped.
+func DisconnectNodes(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	edge types.Edge,
+	nodeID string,
+	disconnectIDs []string,
+	now time.Time,
+) (
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "DisconnectNodes")
+	defer segment.Close(err)
+
+	// Disconnected edges expire straight away, they are not restored
+	ttl := TTLAt(now)
+
+	for _, disconnectID := range disconnectIDs {
+		// Build the edge item to find the key it was stored with
+		edgeItem := nodeUtil.CreateEdgeItem(
+			ctx,
+			edge,
+			nodeID,
+			disconnectID,
+			now,
+			now,
+			"linnet",
+		)
+
+		_, err = TombstoneItem(
+			ctx,
+			dynamo,
+			tableName,
+			map[string]*dynamodb.AttributeValue{
+				"id": &dynamodb.AttributeValue{
+					S: aws.String(edgeItem["id"].(string)),
+				},
+				"linnet:dataType": &dynamodb.AttributeValue{
+					S: aws.String(edgeItem["linnet:dataType"].(string)),
+				},
+			},
+			ttl,
+		)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ReplaceCounterpartEdges removes the old edges on the other side of each
+// connection, where that side is a ONE edge. This must run after the new
+// edges are written, so a node is never left without an edge.
+//
+// DynamoDB transactions are not available in the version of the SDK we
+// use, so for a moment both edges can be read.
+func ReplaceCounterpartEdges(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	edgeTypes []types.Edge,
+	connections []types.Connection,
+	now time.Time,
+) (
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ReplaceCounterpartEdges")
+	defer segment.Close(err)
+
+	for _, connection := range connections {
+		found, counterpart := util.GetCounterpartEdge(
+			connection.Edge,
+			edgeTypes,
+		)
+		if !found || !util.IsOneEdge(counterpart) {
+			continue
+		}
+
+		for _, id := range connection.IDs {
+			var currentIDs []string
+			currentIDs, err = QueryAllEdges(
+				ctx,
+				dynamo,
+				tableName,
+				id,
+				counterpart,
+			)
+			if err != nil {
+				return
+			}
+
+			var oldIDs []string
+			for _, currentID := range currentIDs {
+				if currentID != connection.NodeID {
+					oldIDs = append(oldIDs, currentID)
+				}
+			}
+
+			err = DisconnectNodes(
+				ctx,
+				dynamo,
+				tableName,
+				counterpart,
+				id,
+				oldIDs,
+				now,
+			)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
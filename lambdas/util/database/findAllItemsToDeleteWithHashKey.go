@@ -9,13 +9,15 @@ import (
 	"github.com/aws/aws-xray-sdk-go/xray"
 )
 
-// FindAllItemsToDeleteWithHashKey -
+// FindAllItemsToDeleteWithHashKey returns the key of every item under
+// hash that is not already deleted, or every item when includeDeleted.
+// The Node item also has its linnet:namedType.
 func FindAllItemsToDeleteWithHashKey(
 	ctx context.Context,
 	dynamo dynamodbiface.DynamoDBAPI,
 	tableName *string,
 	hash string,
-	ttl string,
+	includeDeleted bool,
 ) (
 	itemsToDelete *[]map[string]*dynamodb.AttributeValue,
 	err error,
@@ -23,36 +25,46 @@ func FindAllItemsToDeleteWithHashKey(
 	ctx, segment := xray.BeginSubsegment(ctx, "findAllItemsWithHashKey")
 	defer segment.Close(err)
 
-	// TODO: handle pages
 	queryInput := &dynamodb.QueryInput{
 		TableName: tableName,
 		ExpressionAttributeNames: map[string]*string{
-			"#dataType": aws.String("linnet:dataType"),
-			"#ttl":      aws.String("linnet:ttl"),
+			"#dataType":  aws.String("linnet:dataType"),
+			"#namedType": aws.String("linnet:namedType"),
 		},
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":idValue": &dynamodb.AttributeValue{
 				S: aws.String(hash),
 			},
-			":now": &dynamodb.AttributeValue{
-				S: aws.String(ttl),
-			},
 		},
 		KeyConditionExpression: aws.String("id = :idValue"),
-		// We only want the HASH and RANGE keys returned, as that's all we're deleting atm.
-		// We can extend this to linnet:edge at some point in the future, but that
-		// could be almosst unbounded (if you have a lot of data), so we're not handling that
-		// style of cascading deletes yet.
-		ProjectionExpression: aws.String("id, #dataType"),
-		FilterExpression:     aws.String("attribute_not_exists(#ttl) OR #ttl > :now"),
+		// We only want the HASH and RANGE keys returned, as that's all we're
+		// deleting, and the namedType to check the Node is the type deleted
+		ProjectionExpression: aws.String("id, #dataType, #namedType"),
+	}
+	if !includeDeleted {
+		hideDeletedItems(queryInput)
 	}
 
-	queryResult, err := dynamo.QueryWithContext(
-		ctx,
-		queryInput,
-	)
+	// A Node with many edges can have more than one page of items
+	var items []map[string]*dynamodb.AttributeValue
+	for {
+		queryResult, err := dynamo.QueryWithContext(
+			ctx,
+			queryInput,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, queryResult.Items...)
+
+		if len(queryResult.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryResult.LastEvaluatedKey
+	}
 
-	itemsToDelete = &queryResult.Items
+	itemsToDelete = &items
 
 	return
 }
@@ -6,19 +6,24 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/util"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
-// HydrateNodes with a given ID, return its Node item
+// HYDRATE_MAX_RETRIES for the keys DynamoDB leaves unprocessed, each
+// retry waits with the same backoff as BatchWriteRequests
+var HYDRATE_MAX_RETRIES = 5
+
+// HydrateNodes with a given ID, return its Node item. Deleted Nodes are
+// only returned with includeDeleted
 func HydrateNodes(
 	ctx context.Context,
 	dynamo dynamodbiface.DynamoDBAPI,
 	tableName string,
 	edges []string,
+	includeDeleted bool,
 ) (
 	nodes []types.Node,
 	err error,
@@ -34,27 +39,50 @@ func HydrateNodes(
 		return
 	}
 	for _, request := range requests {
-		batchGetItemResult, err := dynamo.BatchGetItemWithContext(
-			ctx,
-			request,
-		)
-		if err != nil {
-			return nil, err
-		}
-		responses := batchGetItemResult.Responses[tableName]
+		// Keep requesting until DynamoDB has returned every key, as
+		// keys can be left unprocessed when the table is throttled
+		for retryNumber := 0; request != nil; retryNumber++ {
+			if retryNumber > HYDRATE_MAX_RETRIES {
+				return nil, fmt.Errorf(
+					"Cannot read nodes, DynamoDB did not return them after %d retries",
+					HYDRATE_MAX_RETRIES,
+				)
+			}
+			if retryNumber > 0 {
+				err = backoff(ctx, retryNumber)
+				if err != nil {
+					return nil, err
+				}
+			}
 
-		for _, response := range responses {
-			hydratedItems := make(types.Node, len(batchGetItemResult.Responses[tableName]))
-			err = dynamodbattribute.UnmarshalMap(
-				response,
-				&hydratedItems,
+			batchGetItemResult, err := dynamo.BatchGetItemWithContext(
+				ctx,
+				request,
 			)
-			nodes = append(nodes, hydratedItems)
 			if err != nil {
 				return nil, err
 			}
+			responses := batchGetItemResult.Responses[tableName]
+
+			for _, response := range responses {
+				hydratedItems, err := UnmarshalNode(response)
+				if err != nil {
+					return nil, err
+				}
+				nodes = append(nodes, hydratedItems.WithVersion())
+			}
+
+			request = nil
+			if len(batchGetItemResult.UnprocessedKeys) > 0 {
+				request = &dynamodb.BatchGetItemInput{
+					RequestItems: batchGetItemResult.UnprocessedKeys,
+				}
+			}
 		}
 	}
+	if !includeDeleted {
+		nodes = FilterDeleted(nodes)
+	}
 	return nodes, err
 }
 
@@ -107,3 +135,41 @@ func MarshallItemsToGetItemRequests(
 
 	return requests, err
 }
+
+// GetNode with a given ID, with a consistent read. node is nil when there
+// is no Node item, deleted Nodes are returned with their linnet:ttl.
+func GetNode(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	id string,
+) (
+	node types.Node,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "GetNode")
+	defer segment.Close(err)
+
+	getItemResult, err := dynamo.GetItemWithContext(
+		ctx,
+		&dynamodb.GetItemInput{
+			TableName: aws.String(tableName),
+			Key: map[string]*dynamodb.AttributeValue{
+				"id": &dynamodb.AttributeValue{
+					S: aws.String(id),
+				},
+				"linnet:dataType": &dynamodb.AttributeValue{
+					S: aws.String("Node"),
+				},
+			},
+			ConsistentRead: aws.Bool(true),
+		},
+	)
+	if err != nil || len(getItemResult.Item) == 0 {
+		return
+	}
+
+	node, err = UnmarshalNode(getItemResult.Item)
+	node.WithVersion()
+	return
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUnprocessedKeysDynamoDBClient leaves every key unprocessed for the
+// first unprocessed calls, then returns each key as a Node
+type mockUnprocessedKeysDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	unprocessed int
+	calls       int
+}
+
+func (m *mockUnprocessedKeysDynamoDBClient) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchGetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchGetItemOutput,
+	error,
+) {
+	m.calls++
+	if m.calls <= m.unprocessed {
+		return &dynamodb.BatchGetItemOutput{
+			UnprocessedKeys: input.RequestItems,
+		}, nil
+	}
+
+	output := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]*dynamodb.AttributeValue{},
+	}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		output.Responses[tableName] = keysAndAttributes.Keys
+	}
+	return output, nil
+}
+
+func TestHydrateNodesUnprocessedKeys(t *testing.T) {
+	ctx, _ := xray.BeginSegment(context.Background(), "TestHydrateNodesUnprocessedKeys")
+	assert := assert.New(t)
+
+	BATCH_WRITE_BASE_DELAY = time.Millisecond
+	defer func() { BATCH_WRITE_BASE_DELAY = 50 * time.Millisecond }()
+
+	// The keys are returned once DynamoDB processes them
+	dynamo := &mockUnprocessedKeysDynamoDBClient{unprocessed: 2}
+	nodes, err := HydrateNodes(ctx, dynamo, "test-table", []string{"node-1", "node-2"}, true)
+	assert.NoError(err)
+	assert.Len(nodes, 2)
+	assert.Equal(3, dynamo.calls)
+
+	// A table that stays throttled stops after HYDRATE_MAX_RETRIES
+	dynamo = &mockUnprocessedKeysDynamoDBClient{unprocessed: 100}
+	nodes, err = HydrateNodes(ctx, dynamo, "test-table", []string{"node-1"}, true)
+	assert.EqualError(err, "Cannot read nodes, DynamoDB did not return them after 5 retries")
+	assert.Nil(nodes)
+	assert.Equal(HYDRATE_MAX_RETRIES+1, dynamo.calls)
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// IDEMPOTENCY_TTL is how long the response to a mutation with an
+// idempotencyKey is kept, to be replayed when it is retried
+var IDEMPOTENCY_TTL = 24 * time.Hour
+
+// IDEMPOTENCY_CLAIM_TTL is how long an idempotencyKey is held by a
+// mutation that has not finished. It is longer than a Lambda can run, so
+// a key is only freed early when its Lambda was stopped.
+var IDEMPOTENCY_CLAIM_TTL = 15 * time.Minute
+
+// ClaimIdempotencyKey stores a record for key before a mutation is run.
+// When the mutation already ran with this key and the same arguments, its
+// response is returned to be replayed and nothing is written. Records with
+// a passed ttl are claimed again, as DynamoDB can take a while to remove
+// them.
+func ClaimIdempotencyKey(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	key string,
+	operation string,
+	arguments map[string]interface{},
+	now time.Time,
+) (
+	replay *types.LambdaResponse,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ClaimIdempotencyKey")
+	defer segment.Close(err)
+
+	hash, err := argumentsHash(arguments)
+	if err != nil {
+		return
+	}
+
+	item := idempotencyItemKey(key)
+	item["linnet:operation"] = &dynamodb.AttributeValue{
+		S: aws.String(operation),
+	}
+	item["linnet:arguments"] = &dynamodb.AttributeValue{
+		S: aws.String(hash),
+	}
+	item["linnet:ttl"] = TTLAt(now.Add(IDEMPOTENCY_CLAIM_TTL)).AttributeValue()
+
+	_, err = dynamo.PutItemWithContext(
+		ctx,
+		&dynamodb.PutItemInput{
+			TableName:           aws.String(tableName),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(id) OR #ttl <= :now"),
+			ExpressionAttributeNames: map[string]*string{
+				"#ttl": aws.String("linnet:ttl"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":now": TTLAt(now).AttributeValue(),
+			},
+		},
+	)
+	if aerr, ok := err.(awserr.Error); !ok ||
+		aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+		return nil, err
+	}
+
+	// The key is held by an earlier request
+	getItemResult, err := dynamo.GetItemWithContext(
+		ctx,
+		&dynamodb.GetItemInput{
+			TableName:      aws.String(tableName),
+			Key:            idempotencyItemKey(key),
+			ConsistentRead: aws.Bool(true),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var record struct {
+		Operation string `dynamodbav:"linnet:operation"`
+		Arguments string `dynamodbav:"linnet:arguments"`
+		Response  string `dynamodbav:"linnet:response"`
+	}
+	err = dynamodbattribute.UnmarshalMap(getItemResult.Item, &record)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(getItemResult.Item) == 0:
+		// It was removed since the put, so the retry can claim it
+		err = fmt.Errorf(
+			"Cannot %s, idempotencyKey %s was released, try again",
+			operation,
+			key,
+		)
+	case record.Operation != operation:
+		err = fmt.Errorf(
+			"Cannot %s, idempotencyKey %s was used for %s",
+			operation,
+			key,
+			record.Operation,
+		)
+	case record.Arguments != hash:
+		err = fmt.Errorf(
+			"Cannot %s, idempotencyKey %s was used with different arguments",
+			operation,
+			key,
+		)
+	case record.Response == "":
+		err = fmt.Errorf(
+			"Cannot %s, the request with idempotencyKey %s has not finished",
+			operation,
+			key,
+		)
+	default:
+		// Numbers are read as types.Number, so they replay exactly
+		replay = &types.LambdaResponse{}
+		err = util.UnmarshalEvent([]byte(record.Response), replay)
+	}
+	return
+}
+
+// CompleteIdempotencyKey stores the response of the mutation that claimed
+// key, for IDEMPOTENCY_TTL
+func CompleteIdempotencyKey(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	key string,
+	operation string,
+	arguments map[string]interface{},
+	response types.LambdaResponse,
+	now time.Time,
+) (
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "CompleteIdempotencyKey")
+	defer segment.Close(err)
+
+	hash, err := argumentsHash(arguments)
+	if err != nil {
+		return
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+
+	item := idempotencyItemKey(key)
+	item["linnet:operation"] = &dynamodb.AttributeValue{
+		S: aws.String(operation),
+	}
+	item["linnet:arguments"] = &dynamodb.AttributeValue{
+		S: aws.String(hash),
+	}
+	item["linnet:response"] = &dynamodb.AttributeValue{
+		S: aws.String(string(responseJSON)),
+	}
+	item["linnet:ttl"] = TTLAt(now.Add(IDEMPOTENCY_TTL)).AttributeValue()
+
+	_, err = dynamo.PutItemWithContext(
+		ctx,
+		&dynamodb.PutItemInput{
+			TableName: aws.String(tableName),
+			Item:      item,
+		},
+	)
+	return
+}
+
+// ReleaseIdempotencyKey removes the record for key, so a mutation that
+// failed can be tried again with it
+func ReleaseIdempotencyKey(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	key string,
+) (
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ReleaseIdempotencyKey")
+	defer segment.Close(err)
+
+	_, err = dynamo.DeleteItemWithContext(
+		ctx,
+		&dynamodb.DeleteItemInput{
+			TableName: aws.String(tableName),
+			Key:       idempotencyItemKey(key),
+		},
+	)
+	return
+}
+
+// idempotencyItemKey is stored beside the Nodes, with a dataType no Node
+// or edge uses
+func idempotencyItemKey(key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"id": &dynamodb.AttributeValue{
+			S: aws.String(key),
+		},
+		"linnet:dataType": &dynamodb.AttributeValue{
+			S: aws.String("Idempotency"),
+		},
+	}
+}
+
+// argumentsHash identifies the arguments of a mutation, the keys of a map
+// are written in order so the same arguments always have the same hash
+func argumentsHash(arguments map[string]interface{}) (hash string, err error) {
+	argumentsJSON, err := json.Marshal(arguments)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(argumentsJSON)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockIdempotencyDynamoDBClient stores items by id, and checks the
+// condition ClaimIdempotencyKey puts with
+type mockIdempotencyDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (m *mockIdempotencyDynamoDBClient) PutItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.PutItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.PutItemOutput,
+	error,
+) {
+	id := *input.Item["id"].S
+	if stored, ok := m.items[id]; ok && input.ConditionExpression != nil {
+		ttl, _ := strconv.ParseInt(*stored["linnet:ttl"].N, 10, 64)
+		now, _ := strconv.ParseInt(*input.ExpressionAttributeValues[":now"].N, 10, 64)
+		if ttl > now {
+			return nil, awserr.New(
+				dynamodb.ErrCodeConditionalCheckFailedException,
+				"The conditional request failed",
+				nil,
+			)
+		}
+	}
+	m.items[id] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockIdempotencyDynamoDBClient) GetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.GetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.GetItemOutput,
+	error,
+) {
+	return &dynamodb.GetItemOutput{
+		Item: m.items[*input.Key["id"].S],
+	}, nil
+}
+
+func (m *mockIdempotencyDynamoDBClient) DeleteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.DeleteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.DeleteItemOutput,
+	error,
+) {
+	delete(m.items, *input.Key["id"].S)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	ctx, _ := xray.BeginSegment(context.Background(), "TestIdempotencyKey")
+	now := time.Unix(1517446800, 0)
+
+	arguments := map[string]interface{}{
+		"idempotencyKey": "key-1",
+		"data": map[string]interface{}{
+			"name":    "customer name",
+			"balance": types.Number("10.10"),
+		},
+	}
+
+	// The balance is replayed exactly, not as a float64
+	response := types.LambdaResponse{
+		Data: map[string]interface{}{
+			"id":      "customer-1",
+			"name":    "customer name",
+			"balance": types.Number("10.10"),
+		},
+	}
+
+	type Step struct {
+		// action is claim, complete or release
+		action    string
+		operation string
+		// arguments are the arguments of the step, or the ones above
+		arguments map[string]interface{}
+		after     time.Duration
+		replay    *types.LambdaResponse
+		err       string
+	}
+
+	steps := []Step{
+		{action: "claim", operation: "createCustomer"},
+		{
+			// A retry while the first request is running
+			action:    "claim",
+			operation: "createCustomer",
+			after:     time.Second,
+			err:       "Cannot createCustomer, the request with idempotencyKey key-1 has not finished",
+		},
+		{action: "complete", operation: "createCustomer", after: 2 * time.Second},
+		{
+			action:    "claim",
+			operation: "createCustomer",
+			after:     time.Hour,
+			replay:    &response,
+		},
+		{
+			action:    "claim",
+			operation: "createOrder",
+			after:     time.Hour,
+			err:       "Cannot createOrder, idempotencyKey key-1 was used for createCustomer",
+		},
+		{
+			// The same key is not a retry when the arguments changed
+			action:    "claim",
+			operation: "createCustomer",
+			arguments: map[string]interface{}{
+				"idempotencyKey": "key-1",
+				"data": map[string]interface{}{
+					"name": "another name",
+				},
+			},
+			after: time.Hour,
+			err:   "Cannot createCustomer, idempotencyKey key-1 was used with different arguments",
+		},
+		{
+			// The response has expired, but DynamoDB has not removed it yet
+			action:    "claim",
+			operation: "createCustomer",
+			after:     25 * time.Hour,
+		},
+		{action: "release", after: 25 * time.Hour},
+		{action: "claim", operation: "createOrder", after: 25 * time.Hour},
+	}
+
+	dynamo := &mockIdempotencyDynamoDBClient{
+		items: make(map[string]map[string]*dynamodb.AttributeValue),
+	}
+
+	for i, step := range steps {
+		assert := assert.New(t)
+
+		stepArguments := step.arguments
+		if stepArguments == nil {
+			stepArguments = arguments
+		}
+
+		var replay *types.LambdaResponse
+		var err error
+		switch step.action {
+		case "claim":
+			replay, err = ClaimIdempotencyKey(
+				ctx,
+				dynamo,
+				"test-table",
+				"key-1",
+				step.operation,
+				stepArguments,
+				now.Add(step.after),
+			)
+		case "complete":
+			err = CompleteIdempotencyKey(
+				ctx,
+				dynamo,
+				"test-table",
+				"key-1",
+				step.operation,
+				stepArguments,
+				response,
+				now.Add(step.after),
+			)
+		case "release":
+			err = ReleaseIdempotencyKey(
+				ctx,
+				dynamo,
+				"test-table",
+				"key-1",
+			)
+		}
+
+		if step.err != "" {
+			assert.EqualError(err, step.err, fmt.Sprintf("Test %d", i))
+		} else {
+			assert.NoError(err, fmt.Sprintf("Test %d", i))
+		}
+		assert.Equal(step.replay, replay, fmt.Sprintf("Test %d", i))
+	}
+}
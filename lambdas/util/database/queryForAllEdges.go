@@ -4,10 +4,12 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
-// QueryForAllEdges will recursively query for edges, until we have them all
+// QueryForAllEdges pages through QueryForEdges, limit edges at a time,
+// until we have them all
 func QueryForAllEdges(
 	ctx context.Context,
 	dynamo dynamodbiface.DynamoDBAPI,
@@ -15,31 +17,18 @@ func QueryForAllEdges(
 	id string,
 	edge types.Edge,
 	limit int64,
-	cursor string,
+	includeDeleted bool,
 ) (
 	edges []string,
-	lastEvaluatedKey string, // this may be used as the cursor next time
 	err error,
 ) {
-	var newEdges []string
+	ctx, segment := xray.BeginSubsegment(ctx, "QueryForAllEdges")
+	defer segment.Close(err)
 
-	// Try query for edges
-	newEdges, lastEvaluatedKey, err = QueryForEdges(
-		ctx,
-		dynamo,
-		tableName,
-		id,
-		edge,
-		limit,
-		cursor,
-	)
-	if len(newEdges) > 0 {
-		edges = append(edges, newEdges...)
-	}
-
-	// We have a new cursor, so recurse again
-	if lastEvaluatedKey != "" {
-		edges, lastEvaluatedKey, err = QueryForAllEdges(
+	var cursor string
+	for {
+		var newEdges []string
+		newEdges, cursor, err = QueryForEdges(
 			ctx,
 			dynamo,
 			tableName,
@@ -47,11 +36,18 @@ func QueryForAllEdges(
 			edge,
 			limit,
 			cursor,
+			includeDeleted,
 		)
-		if len(newEdges) > 0 {
-			edges = append(edges, newEdges...)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, newEdges...)
+
+		// No cursor, so that was the last page
+		if cursor == "" {
+			break
 		}
 	}
 
-	return edges, lastEvaluatedKey, err
+	return edges, err
 }
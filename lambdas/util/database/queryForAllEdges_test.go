@@ -0,0 +1,207 @@
+package database_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPagedEdgesDynamoDBClient returns edges Limit at a time, starting
+// after the edge in ExclusiveStartKey, in reverse when ScanIndexForward
+// is false
+type mockPagedEdgesDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	edges   []string
+	queries int
+}
+
+func (m *mockPagedEdgesDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	m.queries++
+
+	edges := m.edges
+	if input.ScanIndexForward != nil && !*input.ScanIndexForward {
+		edges = make([]string, len(m.edges))
+		for i, edge := range m.edges {
+			edges[len(m.edges)-1-i] = edge
+		}
+	}
+
+	start := 0
+	if input.ExclusiveStartKey != nil {
+		for i, edge := range edges {
+			if "OrdersOnCustomer::"+edge == *input.ExclusiveStartKey["linnet:dataType"].S {
+				start = i + 1
+			}
+		}
+	}
+
+	output := dynamodb.QueryOutput{}
+	end := start + int(*input.Limit)
+	if end >= len(edges) {
+		end = len(edges)
+	} else {
+		output.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{
+			"id":              &dynamodb.AttributeValue{S: aws.String("customer-1")},
+			"linnet:dataType": &dynamodb.AttributeValue{S: aws.String("OrdersOnCustomer::" + edges[end-1])},
+		}
+	}
+
+	for _, edge := range edges[start:end] {
+		output.Items = append(output.Items, map[string]*dynamodb.AttributeValue{
+			"id":              &dynamodb.AttributeValue{S: aws.String("customer-1")},
+			"linnet:dataType": &dynamodb.AttributeValue{S: aws.String("OrdersOnCustomer::" + edge)},
+			"linnet:edge":     &dynamodb.AttributeValue{S: aws.String(edge)},
+		})
+	}
+	output.Count = aws.Int64(int64(len(output.Items)))
+	output.ScannedCount = output.Count
+	return &output, nil
+}
+
+func TestQueryForAllEdges(t *testing.T) {
+	edge := types.Edge{
+		TypeName:    "Customer",
+		Field:       "orders",
+		FieldType:   "Order",
+		EdgeName:    "OrdersOnCustomer",
+		Cardinality: "MANY",
+		Principal:   "TRUE",
+	}
+
+	tests := []struct {
+		edges   []string
+		limit   int64
+		queries int
+	}{
+		{
+			edges:   []string{},
+			limit:   2,
+			queries: 1,
+		},
+		{
+			edges:   []string{"order-1", "order-2"},
+			limit:   2,
+			queries: 1,
+		},
+		{
+			edges:   []string{"order-1", "order-2", "order-3", "order-4", "order-5"},
+			limit:   2,
+			queries: 3,
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestQueryForAllEdges")
+		assert := assert.New(t)
+
+		dynamo := mockPagedEdgesDynamoDBClient{edges: test.edges}
+
+		edges, err := database.QueryForAllEdges(
+			ctx,
+			&dynamo,
+			"TestTable",
+			"customer-1",
+			edge,
+			test.limit,
+			false,
+		)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.ElementsMatch(test.edges, edges, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.queries, dynamo.queries, fmt.Sprintf("Test %d", i))
+	}
+}
+
+func TestQueryForEdgePage(t *testing.T) {
+	edge := types.Edge{
+		TypeName:    "Customer",
+		Field:       "orders",
+		FieldType:   "Order",
+		EdgeName:    "OrdersOnCustomer",
+		Cardinality: "MANY",
+		Principal:   "TRUE",
+	}
+
+	// The cursor of order-N is the key of its edge item
+	cursorOf := func(id string) string {
+		edgeCursor, _ := json.Marshal(map[string]string{
+			"id":              "customer-1",
+			"linnet:dataType": "OrdersOnCustomer::" + id,
+		})
+		return base64.StdEncoding.EncodeToString(edgeCursor)
+	}
+
+	tests := []struct {
+		cursor           string
+		backward         bool
+		ids              []string
+		lastEvaluatedKey string
+	}{
+		{
+			ids:              []string{"order-1", "order-2"},
+			lastEvaluatedKey: cursorOf("order-2"),
+		},
+		{
+			cursor: cursorOf("order-2"),
+			ids:    []string{"order-3", "order-4"},
+		},
+		{
+			// Reading backward returns the page in the same order
+			backward:         true,
+			ids:              []string{"order-3", "order-4"},
+			lastEvaluatedKey: cursorOf("order-3"),
+		},
+		{
+			cursor:   cursorOf("order-3"),
+			backward: true,
+			ids:      []string{"order-1", "order-2"},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestQueryForEdgePage")
+		assert := assert.New(t)
+
+		dynamo := mockPagedEdgesDynamoDBClient{
+			edges: []string{"order-1", "order-2", "order-3", "order-4"},
+		}
+
+		edgeKeys, lastEvaluatedKey, err := database.QueryForEdgePage(
+			ctx,
+			&dynamo,
+			"TestTable",
+			"customer-1",
+			edge,
+			2,
+			test.cursor,
+			false,
+			test.backward,
+		)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+
+		var ids []string
+		for _, edgeKey := range edgeKeys {
+			ids = append(ids, edgeKey.ID)
+			assert.Equal(cursorOf(edgeKey.ID), edgeKey.Cursor, fmt.Sprintf("Test %d", i))
+		}
+		assert.Equal(test.ids, ids, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.lastEvaluatedKey, lastEvaluatedKey, fmt.Sprintf("Test %d", i))
+	}
+}
@@ -2,10 +2,6 @@ package database
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
-
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -14,7 +10,8 @@ import (
 	"github.com/ojkelly/linnet/lambdas/util/types"
 )
 
-// QueryForEdges from a rootNodeID and Edge
+// QueryForEdges from a rootNodeID and Edge, deleted edges are only
+// returned with includeDeleted
 func QueryForEdges(
 	ctx context.Context,
 	dynamo dynamodbiface.DynamoDBAPI,
@@ -23,18 +20,64 @@ func QueryForEdges(
 	edge types.Edge,
 	limit int64,
 	cursor string,
+	includeDeleted bool,
 ) (
 	edges []string,
 	lastEvaluatedKey string, // this may be used as the cursor next time
 	err error,
 ) {
-	ctx, segment := xray.BeginSubsegment(ctx, "QueryForEdges")
+	edgeKeys, lastEvaluatedKey, err := QueryForEdgePage(
+		ctx,
+		dynamo,
+		tableName,
+		id,
+		edge,
+		limit,
+		cursor,
+		includeDeleted,
+		false,
+	)
+	for _, edgeKey := range edgeKeys {
+		edges = append(edges, edgeKey.ID)
+	}
+	return unique(edges), lastEvaluatedKey, err
+}
+
+// EdgeKey is the id of a connected node, and the cursor of the edge item
+// to it. Reading from the cursor starts at the next edge.
+type EdgeKey struct {
+	ID     string
+	Cursor string
+}
+
+// QueryForEdgePage reads a page of edges from a rootNodeID, with the cursor
+// of each. backward reads the edges before the cursor, the page is
+// returned in the same order as a forward read.
+func QueryForEdgePage(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName,
+	id string,
+	edge types.Edge,
+	limit int64,
+	cursor string,
+	includeDeleted bool,
+	backward bool,
+) (
+	edges []EdgeKey,
+	lastEvaluatedKey string, // this may be used as the cursor next time
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "QueryForEdgePage")
 	defer segment.Close(err)
 
 	var partitionKeyName string
 	var edgeKeyName string
 	var index *string
 
+	// The attributes that make up the key of an edge item
+	keyNames := []string{"id", "linnet:dataType"}
+
 	if edge.Principal == "TRUE" {
 		partitionKeyName = "id"
 		edgeKeyName = "linnet:edge"
@@ -42,9 +85,9 @@ func QueryForEdges(
 		partitionKeyName = "linnet:edge"
 		edgeKeyName = "id"
 		index = aws.String("edge-dataType")
+		keyNames = append(keyNames, "linnet:edge")
 	}
 
-	// TODO: handle pages
 	queryInput := dynamodb.QueryInput{
 		TableName: aws.String(tableName),
 		IndexName: index,
@@ -64,27 +107,17 @@ func QueryForEdges(
 		KeyConditionExpression: aws.String(
 			"#partitionKeyName = :partitionKeyValue AND begins_with(#sortKeyName, :sortKeyValue)",
 		),
+		ScanIndexForward: aws.Bool(!backward),
 	}
 
-	// If we have a cursor decode it, and use it as the start key
-	var exclusiveStartKey map[string]string
-	if cursor != "" {
-		cursorString, err := base64.StdEncoding.DecodeString(cursor)
-		if err != nil {
-			return edges, lastEvaluatedKey, err
-		}
-
-		err = json.Unmarshal(cursorString, &exclusiveStartKey)
-		if err != nil {
-			return edges, lastEvaluatedKey, err
-		}
-
-		exclusiveStartKeyMap, err := dynamodbattribute.MarshalMap(exclusiveStartKey)
-		if err != nil {
-			return edges, lastEvaluatedKey, err
-		}
+	if !includeDeleted {
+		hideDeletedItems(&queryInput)
+	}
 
-		queryInput.ExclusiveStartKey = exclusiveStartKeyMap
+	// If we have a cursor decode it, and use it as the start key
+	queryInput.ExclusiveStartKey, err = decodeCursor(cursor)
+	if err != nil {
+		return edges, lastEvaluatedKey, err
 	}
 
 	// Query for edges
@@ -92,42 +125,44 @@ func QueryForEdges(
 		ctx,
 		&queryInput,
 	)
-
-	var scannedCount int64
-	if queryResult.ScannedCount != nil {
-		scannedCount = *queryResult.ScannedCount
-	}
-
-	edgeItems := make([]map[string]string, scannedCount)
-	err = dynamodbattribute.UnmarshalListOfMaps(queryResult.Items, &edgeItems)
 	if err != nil {
 		return edges, lastEvaluatedKey, err
 	}
 
-	for _, edgeItem := range edgeItems {
-		if edgeItem[edgeKeyName] != "" {
-			edges = append(edges, edgeItem[edgeKeyName])
+	for _, edgeItem := range queryResult.Items {
+		if edgeItem[edgeKeyName] == nil || aws.StringValue(edgeItem[edgeKeyName].S) == "" {
+			continue
 		}
-	}
 
-	edges = unique(edges)
-
-	// Check for a new cursor
-	lastEvaluatedKeyMap := make(map[string]string)
-
-	err = dynamodbattribute.UnmarshalMap(queryResult.LastEvaluatedKey, &lastEvaluatedKeyMap)
-	if err != nil {
-		return edges, lastEvaluatedKey, err
-	}
-	if lastEvaluatedKeyMap["id"] != "" {
-
-		lastEvaluatedKeyMapJSON, err := json.Marshal(lastEvaluatedKeyMap)
+		// The cursor of an edge is its key, as the LastEvaluatedKey would be
+		// if the page ended on it
+		key := make(map[string]*dynamodb.AttributeValue)
+		for _, keyName := range keyNames {
+			if edgeItem[keyName] != nil && edgeItem[keyName].S != nil {
+				key[keyName] = edgeItem[keyName]
+			}
+		}
+		var edgeCursor string
+		edgeCursor, err = encodeCursor(key)
 		if err != nil {
 			return edges, lastEvaluatedKey, err
 		}
 
-		lastEvaluatedKey = string(base64.StdEncoding.EncodeToString([]byte(lastEvaluatedKeyMapJSON)))
+		edges = append(edges, EdgeKey{
+			ID:     *edgeItem[edgeKeyName].S,
+			Cursor: edgeCursor,
+		})
+	}
+
+	// A backward read returns the edges nearest the cursor first
+	if backward {
+		for i, j := 0, len(edges)-1; i < j; i, j = i+1, j-1 {
+			edges[i], edges[j] = edges[j], edges[i]
+		}
 	}
+
+	// Check for a new cursor
+	lastEvaluatedKey, err = encodeCursor(queryResult.LastEvaluatedKey)
 	return edges, lastEvaluatedKey, err
 }
 
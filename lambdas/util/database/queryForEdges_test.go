@@ -106,7 +106,8 @@ func TestQueryForEdges(t *testing.T) {
 				edges: []string{
 					"05c339a9-e3d3-40c3-9df6-6fb28bae495a",
 				},
-				lastEvaluatedKey: "81af6f8f-8639-4ff6-a881-083eb7135de0",
+				// base64 of {"id":"81af6f8f-8639-4ff6-a881-083eb7135de0"}
+				lastEvaluatedKey: "eyJpZCI6IjgxYWY2ZjhmLTg2MzktNGZmNi1hODgxLTA4M2ViNzEzNWRlMCJ9",
 			},
 			throws: false,
 		},
@@ -126,6 +127,8 @@ func TestQueryForEdges(t *testing.T) {
 			test.input.ID,
 			test.input.Edge,
 			test.input.Limit,
+			"",
+			false,
 		)
 
 		if test.throws {
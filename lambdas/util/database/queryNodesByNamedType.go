@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// QueryNodesByNamedType returns every Node of namedType that has not
+// been deleted, reading all pages of the namedType-id index
+func QueryNodesByNamedType(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+) (
+	nodes []types.Node,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "QueryNodesByNamedType")
+	defer segment.Close(err)
+
+	queryInput := dynamodb.QueryInput{
+		TableName: aws.String(tableName),
+		IndexName: aws.String("namedType-id"),
+		ExpressionAttributeNames: map[string]*string{
+			"#namedType": aws.String("linnet:namedType"),
+			"#dataType":  aws.String("linnet:dataType"),
+			"#ttl":       aws.String("linnet:ttl"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":namedType": &dynamodb.AttributeValue{
+				S: aws.String(namedType),
+			},
+			":dataType": &dynamodb.AttributeValue{
+				S: aws.String("Node"),
+			},
+		},
+		KeyConditionExpression: aws.String("#namedType = :namedType"),
+		// Edge items share the namedType of their Node, so only keep
+		// the Node items
+		FilterExpression: aws.String(
+			"#dataType = :dataType AND attribute_not_exists(#ttl)",
+		),
+	}
+
+	for {
+		queryResult, err := dynamo.QueryWithContext(
+			ctx,
+			&queryInput,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range queryResult.Items {
+			var node types.Node
+			node, err = UnmarshalNode(item)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		}
+
+		if len(queryResult.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryResult.LastEvaluatedKey
+	}
+
+	return
+}
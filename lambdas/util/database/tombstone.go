@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// MAX_CONCURRENT_TOMBSTONES is how many items are tombstoned at once
+var MAX_CONCURRENT_TOMBSTONES = 10
+
+// DEADLINE_MARGIN is the time left before the Lambda deadline, when no
+// more items are started. This leaves time to return what was done.
+var DEADLINE_MARGIN = 2 * time.Second
+
+// TombstoneResult is the number of Node and Edge items given a ttl.
+// Complete is false when the deadline was reached before every item was
+// started.
+type TombstoneResult struct {
+	Nodes    int
+	Edges    int
+	Complete bool
+}
+
+// TombstoneItems sets the ttl on every key, with no more than
+// MAX_CONCURRENT_TOMBSTONES at once. Items that are already deleted, or
+// missing, are skipped.
+func TombstoneItems(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	keys []map[string]*dynamodb.AttributeValue,
+	ttl TTL,
+) (
+	result TombstoneResult,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "TombstoneItems")
+	defer segment.Close(err)
+
+	var mutex sync.Mutex
+	semaphore := make(chan struct{}, MAX_CONCURRENT_TOMBSTONES)
+
+	result.Complete = true
+
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		mutex.Lock()
+		failed := err != nil
+		mutex.Unlock()
+		if failed {
+			break
+		}
+
+		if PastDeadline(ctx) {
+			result.Complete = false
+			break
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(key map[string]*dynamodb.AttributeValue) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			tombstoned, tombstoneErr := TombstoneItem(
+				ctx,
+				dynamo,
+				tableName,
+				key,
+				ttl,
+			)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if tombstoneErr != nil {
+				if err == nil {
+					err = tombstoneErr
+				}
+				return
+			}
+			if tombstoned {
+				if *key["linnet:dataType"].S == "Node" {
+					result.Nodes = result.Nodes + 1
+				} else {
+					result.Edges = result.Edges + 1
+				}
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	if err != nil {
+		result.Complete = false
+	}
+	return
+}
+
+// PastDeadline is true when ctx is done, or its deadline is closer than
+// DEADLINE_MARGIN
+func PastDeadline(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < DEADLINE_MARGIN
+}
+
+// DeleteContinuation is the work left when a delete stops at its
+// deadline. It is passed back to delete, to finish with the same mode
+// and ttl.
+type DeleteContinuation struct {
+	IDs  []string         `json:"ids"`
+	Mode types.DeleteMode `json:"mode"`
+	TTL  TTL              `json:"ttl"`
+}
+
+// Encode the continuation, to return it to the caller
+func (c DeleteContinuation) Encode() (token string, err error) {
+	tokenJSON, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	token = base64.StdEncoding.EncodeToString(tokenJSON)
+	return
+}
+
+// DecodeDeleteContinuation from a token made by Encode. The token is sent
+// by the client, so its ttl is validated the same as a timeToLive.
+func DecodeDeleteContinuation(
+	token string,
+	now time.Time,
+) (
+	continuation DeleteContinuation,
+	err error,
+) {
+	tokenJSON, err := base64.StdEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(tokenJSON, &continuation)
+	}
+	if err != nil || len(continuation.IDs) == 0 {
+		err = fmt.Errorf("Cannot continue delete, the continuation is not valid")
+		return
+	}
+
+	err = continuation.TTL.Validate(now)
+	if err != nil {
+		err = fmt.Errorf("Cannot continue delete, %s", err)
+	}
+	return
+}
@@ -0,0 +1,156 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTombstoneDynamoDBClient tombstones every key once, and keeps the
+// most UpdateItem calls that ran at the same time
+type mockTombstoneDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	mutex      sync.Mutex
+	tombstoned map[string]bool
+	running    int
+	maxRunning int
+}
+
+func (m *mockTombstoneDynamoDBClient) UpdateItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.UpdateItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.UpdateItemOutput,
+	error,
+) {
+	m.mutex.Lock()
+	m.running = m.running + 1
+	if m.running > m.maxRunning {
+		m.maxRunning = m.running
+	}
+	m.mutex.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.running = m.running - 1
+
+	key := *input.Key["id"].S + "|" + *input.Key["linnet:dataType"].S
+	if m.tombstoned[key] {
+		return nil, awserr.New(
+			dynamodb.ErrCodeConditionalCheckFailedException,
+			"The conditional request failed",
+			nil,
+		)
+	}
+	m.tombstoned[key] = true
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func TestTombstoneItems(t *testing.T) {
+	ctx, _ := xray.BeginSegment(context.Background(), "TestTombstoneItems")
+	assert := assert.New(t)
+
+	key := func(id string, dataType string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"id":              &dynamodb.AttributeValue{S: aws.String(id)},
+			"linnet:dataType": &dynamodb.AttributeValue{S: aws.String(dataType)},
+		}
+	}
+
+	var keys []map[string]*dynamodb.AttributeValue
+	for i := 0; i < 30; i++ {
+		keys = append(keys, key("node-1", fmt.Sprintf("Edge::node-%d", i)))
+	}
+	keys = append(keys, key("node-1", "Node"))
+
+	mockSvc := &mockTombstoneDynamoDBClient{
+		tombstoned: map[string]bool{
+			"node-1|Edge::node-0": true,
+		},
+	}
+
+	result, err := database.TombstoneItems(
+		ctx,
+		mockSvc,
+		"test-table",
+		keys,
+		database.TTL(1517446800),
+	)
+
+	assert.NoError(err)
+	assert.Equal(
+		database.TombstoneResult{Nodes: 1, Edges: 29, Complete: true},
+		result,
+	)
+	assert.Len(mockSvc.tombstoned, 31)
+	assert.True(mockSvc.maxRunning <= database.MAX_CONCURRENT_TOMBSTONES)
+
+	// Nothing is started once the deadline is close
+	deadlineCtx, cancel := context.WithTimeout(ctx, database.DEADLINE_MARGIN/2)
+	defer cancel()
+
+	result, err = database.TombstoneItems(
+		deadlineCtx,
+		mockSvc,
+		"test-table",
+		[]map[string]*dynamodb.AttributeValue{key("node-2", "Node")},
+		database.TTL(1517446800),
+	)
+
+	assert.NoError(err)
+	assert.Equal(database.TombstoneResult{}, result)
+	assert.False(mockSvc.tombstoned["node-2|Node"])
+}
+
+func TestDeleteContinuation(t *testing.T) {
+	assert := assert.New(t)
+
+	continuation := database.DeleteContinuation{
+		IDs: []string{"node-1", "node-2"},
+		TTL: database.TTL(1517446800),
+	}
+
+	token, err := continuation.Encode()
+	assert.NoError(err)
+
+	now := time.Unix(1517446800, 0)
+
+	decoded, err := database.DecodeDeleteContinuation(token, now)
+	assert.NoError(err)
+	assert.Equal(continuation, decoded)
+
+	for _, token := range []string{"", "not a token", "e30="} {
+		_, err = database.DecodeDeleteContinuation(token, now)
+		assert.EqualError(
+			err,
+			"Cannot continue delete, the continuation is not valid",
+			token,
+		)
+	}
+
+	// A token made by the client cannot set a ttl outside the bounds of
+	// timeToLive
+	continuation.TTL = database.TTL(1517446800 + 400*24*60*60)
+	token, err = continuation.Encode()
+	assert.NoError(err)
+
+	_, err = database.DecodeDeleteContinuation(token, now)
+	assert.EqualError(
+		err,
+		"Cannot continue delete, timeToLive 1552006800 is more than 8760h0m0s in the future",
+	)
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// FindDeletedItems under a Node that can still be restored. This is the
+// Node, the edges stored under its id, and the edges stored under the
+// other Node found through the edge-dataType index.
+func FindDeletedItems(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	nodeID string,
+	now time.Time,
+) (
+	items []types.Node,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "FindDeletedItems")
+	defer segment.Close(err)
+
+	queries := []struct {
+		index   *string
+		keyName string
+	}{
+		{index: nil, keyName: "id"},
+		{index: aws.String("edge-dataType"), keyName: "linnet:edge"},
+	}
+
+	for _, query := range queries {
+		queryInput := dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			IndexName: query.index,
+			ExpressionAttributeNames: map[string]*string{
+				"#key":       aws.String(query.keyName),
+				"#dataType":  aws.String("linnet:dataType"),
+				"#namedType": aws.String("linnet:namedType"),
+				"#edge":      aws.String("linnet:edge"),
+				"#ttl":       aws.String("linnet:ttl"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":nodeID": &dynamodb.AttributeValue{
+					S: aws.String(nodeID),
+				},
+				":now": TTLAt(now).AttributeValue(),
+			},
+			KeyConditionExpression: aws.String("#key = :nodeID"),
+			FilterExpression:       aws.String("attribute_exists(#ttl) AND #ttl > :now"),
+			ProjectionExpression:   aws.String("id, #dataType, #namedType, #edge"),
+		}
+
+		for {
+			queryResult, err := dynamo.QueryWithContext(
+				ctx,
+				&queryInput,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range queryResult.Items {
+				var node types.Node
+				node, err = UnmarshalNode(item)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, node)
+			}
+
+			if len(queryResult.LastEvaluatedKey) == 0 {
+				break
+			}
+			queryInput.ExclusiveStartKey = queryResult.LastEvaluatedKey
+		}
+	}
+
+	return
+}
+
+// QueryTrash returns one page of the Nodes of namedType that are deleted,
+// and can still be restored. As deleted Nodes are filtered after they are
+// read, a page can have fewer Nodes than limit.
+func QueryTrash(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	now time.Time,
+	limit int64,
+	cursor string,
+) (
+	nodes []types.Node,
+	nextCursor string,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "QueryTrash")
+	defer segment.Close(err)
+
+	queryInput := dynamodb.QueryInput{
+		TableName: aws.String(tableName),
+		IndexName: aws.String("namedType-id"),
+		Limit:     aws.Int64(limit),
+		ExpressionAttributeNames: map[string]*string{
+			"#namedType": aws.String("linnet:namedType"),
+			"#dataType":  aws.String("linnet:dataType"),
+			"#ttl":       aws.String("linnet:ttl"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":namedType": &dynamodb.AttributeValue{
+				S: aws.String(namedType),
+			},
+			":dataType": &dynamodb.AttributeValue{
+				S: aws.String("Node"),
+			},
+			":now": TTLAt(now).AttributeValue(),
+		},
+		KeyConditionExpression: aws.String("#namedType = :namedType"),
+		FilterExpression: aws.String(
+			"#dataType = :dataType AND attribute_exists(#ttl) AND #ttl > :now",
+		),
+	}
+
+	queryInput.ExclusiveStartKey, err = decodeCursor(cursor)
+	if err != nil {
+		return
+	}
+
+	queryResult, err := dynamo.QueryWithContext(
+		ctx,
+		&queryInput,
+	)
+	if err != nil {
+		return
+	}
+
+	for _, item := range queryResult.Items {
+		var node types.Node
+		node, err = UnmarshalNode(item)
+		if err != nil {
+			return
+		}
+		nodes = append(nodes, node)
+	}
+
+	nextCursor, err = encodeCursor(queryResult.LastEvaluatedKey)
+	return
+}
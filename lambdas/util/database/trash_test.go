@@ -0,0 +1,121 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTrashDynamoDBClient returns one deleted Node per page, starting
+// after the id in ExclusiveStartKey
+type mockTrashDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	nodes []types.Node
+	input *dynamodb.QueryInput
+}
+
+func (m *mockTrashDynamoDBClient) QueryWithContext(
+	ctx aws.Context,
+	input *dynamodb.QueryInput,
+	options ...request.Option,
+) (
+	*dynamodb.QueryOutput,
+	error,
+) {
+	m.input = input
+
+	start := 0
+	if input.ExclusiveStartKey != nil {
+		for i, node := range m.nodes {
+			if node["id"] == *input.ExclusiveStartKey["id"].S {
+				start = i + 1
+			}
+		}
+	}
+
+	output := &dynamodb.QueryOutput{}
+	if start < len(m.nodes) {
+		item, err := dynamodbattribute.MarshalMap(m.nodes[start])
+		if err != nil {
+			return nil, err
+		}
+		output.Items = append(output.Items, item)
+	}
+	if start+1 < len(m.nodes) {
+		output.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{
+			"id":               &dynamodb.AttributeValue{S: aws.String(m.nodes[start]["id"].(string))},
+			"linnet:dataType":  &dynamodb.AttributeValue{S: aws.String("Node")},
+			"linnet:namedType": &dynamodb.AttributeValue{S: aws.String("Order")},
+		}
+	}
+	return output, nil
+}
+
+func TestQueryTrash(t *testing.T) {
+	assert := assert.New(t)
+	ctx, _ := xray.BeginSegment(context.Background(), "TestQueryTrash")
+
+	now := time.Unix(1517446800, 0)
+	mockSvc := &mockTrashDynamoDBClient{
+		nodes: []types.Node{
+			types.Node{"id": "order-1", "linnet:dataType": "Node"},
+			types.Node{"id": "order-2", "linnet:dataType": "Node"},
+			types.Node{"id": "order-3", "linnet:dataType": "Node"},
+		},
+	}
+
+	// Follow the cursor from each page, until there are no more
+	var ids []string
+	cursor := ""
+	for i := 0; i < len(mockSvc.nodes); i++ {
+		nodes, nextCursor, err := database.QueryTrash(
+			ctx,
+			mockSvc,
+			"test-table",
+			"Order",
+			now,
+			1,
+			cursor,
+		)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		for _, node := range nodes {
+			ids = append(ids, node["id"].(string))
+		}
+
+		assert.Equal(
+			"1517446800",
+			*mockSvc.input.ExpressionAttributeValues[":now"].N,
+			fmt.Sprintf("Test %d", i),
+		)
+
+		cursor = nextCursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	assert.Equal([]string{"order-1", "order-2", "order-3"}, ids)
+	assert.Equal("", cursor)
+
+	_, _, err := database.QueryTrash(
+		ctx,
+		mockSvc,
+		"test-table",
+		"Order",
+		now,
+		1,
+		"not a cursor",
+	)
+	assert.Error(err)
+}
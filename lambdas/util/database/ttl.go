@@ -0,0 +1,88 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TRASH_RETENTION is how long a deleted item can be restored for, when
+// no timeToLive is passed to delete
+var TRASH_RETENTION = 7 * 24 * time.Hour
+
+// MAX_TTL_PAST is how far in the past a timeToLive can be, to allow for
+// clocks that are behind
+var MAX_TTL_PAST = 30 * time.Minute
+
+// MAX_TTL_FUTURE is how far in the future a timeToLive can be
+var MAX_TTL_FUTURE = 365 * 24 * time.Hour
+
+// TTL is the value of linnet:ttl, the Unix time in seconds after which
+// DynamoDB can remove an item. It is always stored as a number.
+type TTL int64
+
+// DefaultTTL for an item deleted at now
+func DefaultTTL(now time.Time) TTL {
+	return TTL(now.Add(TRASH_RETENTION).Unix())
+}
+
+// TTLAt is the TTL of an item that expires at t
+func TTLAt(t time.Time) TTL {
+	return TTL(t.Unix())
+}
+
+// ParseTTL from the timeToLive argument of a delete. Without a timeToLive
+// the DefaultTTL is used.
+func ParseTTL(
+	timeToLive *int64,
+	now time.Time,
+) (
+	ttl TTL,
+	err error,
+) {
+	if timeToLive == nil {
+		return DefaultTTL(now), nil
+	}
+
+	ttl = TTL(*timeToLive)
+	err = ttl.Validate(now)
+	return
+}
+
+// Validate the TTL is not too far in the past, or the future
+func (ttl TTL) Validate(now time.Time) error {
+	if ttl < TTLAt(now.Add(-MAX_TTL_PAST)) {
+		return fmt.Errorf(
+			"timeToLive %d is more than %s in the past",
+			ttl,
+			MAX_TTL_PAST,
+		)
+	}
+	if ttl > TTLAt(now.Add(MAX_TTL_FUTURE)) {
+		return fmt.Errorf(
+			"timeToLive %d is more than %s in the future",
+			ttl,
+			MAX_TTL_FUTURE,
+		)
+	}
+	return nil
+}
+
+// HasPassed is true once DynamoDB can remove the item
+func (ttl TTL) HasPassed(now time.Time) bool {
+	return ttl <= TTLAt(now)
+}
+
+func (ttl TTL) String() string {
+	return strconv.FormatInt(int64(ttl), 10)
+}
+
+// AttributeValue of the TTL, for use in an expression
+func (ttl TTL) AttributeValue() *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{
+		N: aws.String(ttl.String()),
+	}
+}
@@ -0,0 +1,103 @@
+package database_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTTL(t *testing.T) {
+	now := time.Unix(1517446800, 0)
+
+	tests := []struct {
+		timeToLive *int64
+		output     database.TTL
+		err        string
+	}{
+		{
+			// No timeToLive keeps the items for TRASH_RETENTION
+			timeToLive: nil,
+			output:     database.TTL(1517446800 + 7*24*60*60),
+		},
+		{
+			timeToLive: aws.Int64(1517446800 + 60),
+			output:     database.TTL(1517446800 + 60),
+		},
+		{
+			// Now, or a little in the past, expires straight away
+			timeToLive: aws.Int64(1517446800),
+			output:     database.TTL(1517446800),
+		},
+		{
+			timeToLive: aws.Int64(1517446800 - 30*60),
+			output:     database.TTL(1517446800 - 30*60),
+		},
+		{
+			timeToLive: aws.Int64(1517446800 - 30*60 - 1),
+			err:        "timeToLive 1517444999 is more than 30m0s in the past",
+		},
+		{
+			timeToLive: aws.Int64(0),
+			err:        "timeToLive 0 is more than 30m0s in the past",
+		},
+		{
+			timeToLive: aws.Int64(1517446800 + 365*24*60*60 + 1),
+			err:        "timeToLive 1548982801 is more than 8760h0m0s in the future",
+		},
+		{
+			// Milliseconds, instead of seconds
+			timeToLive: aws.Int64(1517446800000),
+			err:        "timeToLive 1517446800000 is more than 8760h0m0s in the future",
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		ttl, err := database.ParseTTL(test.timeToLive, now)
+
+		if test.err != "" {
+			assert.EqualError(err, test.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.output, ttl, fmt.Sprintf("Test %d", i))
+	}
+}
+
+func TestTTLHasPassed(t *testing.T) {
+	now := time.Unix(1517446800, 0)
+
+	tests := []struct {
+		ttl    database.TTL
+		output bool
+	}{
+		{ttl: database.TTL(1517446799), output: true},
+		{ttl: database.TTL(1517446800), output: true},
+		{ttl: database.TTL(1517446801), output: false},
+		{ttl: database.DefaultTTL(now), output: false},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		assert.Equal(
+			test.output,
+			test.ttl.HasPassed(now),
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
+
+func TestTTLAttributeValue(t *testing.T) {
+	assert := assert.New(t)
+
+	attributeValue := database.TTLAt(time.Unix(1517446800, 0)).AttributeValue()
+
+	assert.Equal("1517446800", *attributeValue.N)
+	assert.Nil(attributeValue.S)
+}
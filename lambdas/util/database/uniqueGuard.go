@@ -0,0 +1,453 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// UNIQUE_CLAIM_TTL is how long a guard claimed for a Node that is still
+// being created is held. Once the Node is written the ttl is removed.
+var UNIQUE_CLAIM_TTL = 15 * time.Minute
+
+// MAX_UNIQUE_CLAIM_ATTEMPTS is how many times a guard is read again, when
+// another request changes it between the read and the claim
+var MAX_UNIQUE_CLAIM_ATTEMPTS = 3
+
+// UniqueGuard records which Node holds a value of a field. A guard with a
+// TTL is claimed for a Node that is still being written.
+type UniqueGuard struct {
+	NodeID string `dynamodbav:"linnet:node"`
+	TTL    TTL    `dynamodbav:"linnet:ttl,omitempty"`
+}
+
+// Pending is true while the Node of the guard is being written
+func (guard UniqueGuard) Pending(now time.Time) bool {
+	return guard.TTL != 0 && !guard.TTL.HasPassed(now)
+}
+
+// UniqueGuardKey is the key of the guard for value of field on namedType.
+// The id and linnet:dataType are both unique::<Type>::<field>::<value>, so
+// guards are spread across partitions and never match a Node or edge.
+func UniqueGuardKey(
+	namedType string,
+	field string,
+	value interface{},
+) map[string]*dynamodb.AttributeValue {
+	guard := fmt.Sprintf(
+		"unique::%s::%s::%v",
+		namedType,
+		field,
+		canonicalUniqueValue(value),
+	)
+	return map[string]*dynamodb.AttributeValue{
+		"id": &dynamodb.AttributeValue{
+			S: aws.String(guard),
+		},
+		"linnet:dataType": &dynamodb.AttributeValue{
+			S: aws.String(guard),
+		},
+	}
+}
+
+// GetUniqueGuard reads a guard, guard is nil when there is none
+func GetUniqueGuard(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	key map[string]*dynamodb.AttributeValue,
+) (
+	guard *UniqueGuard,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "GetUniqueGuard")
+	defer segment.Close(err)
+
+	getItemResult, err := dynamo.GetItemWithContext(
+		ctx,
+		&dynamodb.GetItemInput{
+			TableName:      aws.String(tableName),
+			Key:            key,
+			ConsistentRead: aws.Bool(true),
+		},
+	)
+	if err != nil || len(getItemResult.Item) == 0 {
+		return
+	}
+
+	guard = &UniqueGuard{}
+	err = dynamodbattribute.UnmarshalMap(getItemResult.Item, guard)
+	return
+}
+
+// ClaimUniqueGuard writes a pending guard for nodeID, unless another Node
+// holds it. A guard held by staleNodeID is replaced. claimed is false
+// when the condition failed.
+func ClaimUniqueGuard(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	key map[string]*dynamodb.AttributeValue,
+	nodeID string,
+	staleNodeID string,
+	now time.Time,
+) (
+	claimed bool,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ClaimUniqueGuard")
+	defer segment.Close(err)
+
+	item := map[string]*dynamodb.AttributeValue{
+		"id":              key["id"],
+		"linnet:dataType": key["linnet:dataType"],
+		"linnet:node": &dynamodb.AttributeValue{
+			S: aws.String(nodeID),
+		},
+		"linnet:ttl": TTLAt(now.Add(UNIQUE_CLAIM_TTL)).AttributeValue(),
+	}
+
+	putItemInput := &dynamodb.PutItemInput{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}
+	if staleNodeID != "" {
+		putItemInput.ConditionExpression = aws.String(
+			"attribute_not_exists(id) OR #node = :staleNode",
+		)
+		putItemInput.ExpressionAttributeNames = map[string]*string{
+			"#node": aws.String("linnet:node"),
+		}
+		putItemInput.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+			":staleNode": &dynamodb.AttributeValue{
+				S: aws.String(staleNodeID),
+			},
+		}
+	}
+
+	_, err = dynamo.PutItemWithContext(
+		ctx,
+		putItemInput,
+	)
+	if aerr, ok := err.(awserr.Error); ok &&
+		aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ConfirmUniqueGuard removes the ttl from a guard claimed for nodeID, once
+// the Node is written
+func ConfirmUniqueGuard(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	key map[string]*dynamodb.AttributeValue,
+	nodeID string,
+) (
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ConfirmUniqueGuard")
+	defer segment.Close(err)
+
+	_, err = dynamo.UpdateItemWithContext(
+		ctx,
+		&dynamodb.UpdateItemInput{
+			TableName:           aws.String(tableName),
+			Key:                 key,
+			UpdateExpression:    aws.String("REMOVE #ttl"),
+			ConditionExpression: aws.String("#node = :node"),
+			ExpressionAttributeNames: map[string]*string{
+				"#ttl":  aws.String("linnet:ttl"),
+				"#node": aws.String("linnet:node"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":node": &dynamodb.AttributeValue{
+					S: aws.String(nodeID),
+				},
+			},
+		},
+	)
+	return
+}
+
+// ReleaseUniqueGuard deletes a guard, if it is still held by nodeID
+func ReleaseUniqueGuard(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	key map[string]*dynamodb.AttributeValue,
+	nodeID string,
+) (
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ReleaseUniqueGuard")
+	defer segment.Close(err)
+
+	_, err = dynamo.DeleteItemWithContext(
+		ctx,
+		&dynamodb.DeleteItemInput{
+			TableName:           aws.String(tableName),
+			Key:                 key,
+			ConditionExpression: aws.String("#node = :node"),
+			ExpressionAttributeNames: map[string]*string{
+				"#node": aws.String("linnet:node"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":node": &dynamodb.AttributeValue{
+					S: aws.String(nodeID),
+				},
+			},
+		},
+	)
+	if aerr, ok := err.(awserr.Error); ok &&
+		aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// Another Node holds it now
+		return nil
+	}
+	return
+}
+
+// UniqueValue is the value of a @unique field on the Node NodeID
+type UniqueValue struct {
+	NamedType string
+	Field     string
+	Value     interface{}
+	NodeID    string
+}
+
+// Key of the guard for the value
+func (v UniqueValue) Key() map[string]*dynamodb.AttributeValue {
+	return UniqueGuardKey(v.NamedType, v.Field, v.Value)
+}
+
+// UniqueValues of the @unique fields on each Node item. Fields that are
+// not set have no value to guard.
+func UniqueValues(
+	uniqueFields []types.UniqueField,
+	items []types.Node,
+) (
+	values []UniqueValue,
+) {
+	for _, item := range items {
+		if item["linnet:dataType"] != "Node" {
+			continue
+		}
+		namedType, _ := item["linnet:namedType"].(string)
+		nodeID, _ := item["id"].(string)
+
+		for _, uniqueField := range util.GetUniqueFieldsOnType(namedType, uniqueFields) {
+			value, ok := item[uniqueField.Field]
+			if !ok || value == nil {
+				continue
+			}
+			values = append(values, UniqueValue{
+				NamedType: namedType,
+				Field:     uniqueField.Field,
+				Value:     value,
+				NodeID:    nodeID,
+			})
+		}
+	}
+	return
+}
+
+// ClaimUniqueValues claims the guard of each value for its Node, before
+// the Node is written. A guard held by a Node that is gone, or no longer
+// has the value, is replaced. Guards the Node already holds are left out
+// of claimed.
+//
+// When another Node holds a value a UniqueConstraintViolation is returned,
+// and the guards claimed so far are released.
+func ClaimUniqueValues(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	values []UniqueValue,
+	now time.Time,
+) (
+	claimed []UniqueValue,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ClaimUniqueValues")
+	defer segment.Close(err)
+
+	for _, value := range values {
+		var claimedValue bool
+		claimedValue, err = claimUniqueValue(
+			ctx,
+			dynamo,
+			tableName,
+			value,
+			now,
+		)
+		if err != nil {
+			// A guard that cannot be released is replaced once its ttl
+			// has passed, as its Node never got the value
+			ReleaseUniqueValues(ctx, dynamo, tableName, claimed)
+			return nil, err
+		}
+		if claimedValue {
+			claimed = append(claimed, value)
+		}
+	}
+	return
+}
+
+// claimUniqueValue is false when the Node already holds the guard
+func claimUniqueValue(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	value UniqueValue,
+	now time.Time,
+) (
+	claimed bool,
+	err error,
+) {
+	for attempt := 0; attempt < MAX_UNIQUE_CLAIM_ATTEMPTS; attempt++ {
+		var guard *UniqueGuard
+		guard, err = GetUniqueGuard(ctx, dynamo, tableName, value.Key())
+		if err != nil {
+			return
+		}
+
+		var staleNodeID string
+		if guard != nil {
+			if guard.NodeID == value.NodeID {
+				return false, nil
+			}
+
+			var held bool
+			held, err = HoldsUniqueValue(ctx, dynamo, tableName, *guard, value, now)
+			if err != nil {
+				return
+			}
+			if held {
+				return false, types.UniqueConstraintViolation{
+					TypeName: value.NamedType,
+					Field:    value.Field,
+					Value:    value.Value,
+				}
+			}
+			staleNodeID = guard.NodeID
+		}
+
+		claimed, err = ClaimUniqueGuard(
+			ctx,
+			dynamo,
+			tableName,
+			value.Key(),
+			value.NodeID,
+			staleNodeID,
+			now,
+		)
+		if err != nil || claimed {
+			return
+		}
+		// Another request claimed it first, so read it again
+	}
+
+	err = fmt.Errorf(
+		"Cannot claim %s.%s %v, it changed while it was being claimed, try again",
+		value.NamedType,
+		value.Field,
+		value.Value,
+	)
+	return
+}
+
+// HoldsUniqueValue is true while the Node of a guard is being written, or
+// when the Node exists, is not deleted and still has the value. Otherwise
+// the guard is stale and can be replaced.
+func HoldsUniqueValue(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	guard UniqueGuard,
+	value UniqueValue,
+	now time.Time,
+) (
+	held bool,
+	err error,
+) {
+	if guard.Pending(now) {
+		return true, nil
+	}
+
+	node, err := GetNode(ctx, dynamo, tableName, guard.NodeID)
+	if err != nil || node == nil {
+		return
+	}
+
+	_, deleted := node["linnet:ttl"]
+	held = !deleted &&
+		node["linnet:namedType"] == value.NamedType &&
+		fmt.Sprint(canonicalUniqueValue(node[value.Field])) ==
+			fmt.Sprint(canonicalUniqueValue(value.Value))
+	return
+}
+
+// canonicalUniqueValue writes a number the same way however it was
+// spelled, so 1.5, 1.50 and 15e-1 are one value. Other values are
+// returned as they are.
+func canonicalUniqueValue(value interface{}) interface{} {
+	if number, ok := types.NumberValue(value); ok {
+		return types.NumberFromRat(number)
+	}
+	return value
+}
+
+// ConfirmUniqueValues removes the ttl from the guard of each value, once
+// its Node is written
+func ConfirmUniqueValues(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	values []UniqueValue,
+) (
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ConfirmUniqueValues")
+	defer segment.Close(err)
+
+	for _, value := range values {
+		err = ConfirmUniqueGuard(ctx, dynamo, tableName, value.Key(), value.NodeID)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ReleaseUniqueValues deletes the guard of each value that is still held
+// by its Node
+func ReleaseUniqueValues(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	values []UniqueValue,
+) (
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "ReleaseUniqueValues")
+	defer segment.Close(err)
+
+	for _, value := range values {
+		err = ReleaseUniqueGuard(ctx, dynamo, tableName, value.Key(), value.NodeID)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
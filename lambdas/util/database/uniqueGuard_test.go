@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUniqueGuardDynamoDBClient stores guards and Nodes by id, and checks
+// the conditions guards are claimed and released with
+type mockUniqueGuardDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (m *mockUniqueGuardDynamoDBClient) GetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.GetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.GetItemOutput,
+	error,
+) {
+	return &dynamodb.GetItemOutput{
+		Item: m.items[*input.Key["id"].S],
+	}, nil
+}
+
+func (m *mockUniqueGuardDynamoDBClient) PutItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.PutItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.PutItemOutput,
+	error,
+) {
+	id := *input.Item["id"].S
+	if stored, ok := m.items[id]; ok {
+		staleNode := input.ExpressionAttributeValues[":staleNode"]
+		if staleNode == nil || *staleNode.S != *stored["linnet:node"].S {
+			return nil, awserr.New(
+				dynamodb.ErrCodeConditionalCheckFailedException,
+				"The conditional request failed",
+				nil,
+			)
+		}
+	}
+	m.items[id] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockUniqueGuardDynamoDBClient) DeleteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.DeleteItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.DeleteItemOutput,
+	error,
+) {
+	id := *input.Key["id"].S
+	if stored, ok := m.items[id]; ok &&
+		*stored["linnet:node"].S == *input.ExpressionAttributeValues[":node"].S {
+		delete(m.items, id)
+	}
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestClaimUniqueValues(t *testing.T) {
+	now := time.Unix(1517446800, 0)
+
+	guard := func(value string, nodeID string, ttl TTL) map[string]*dynamodb.AttributeValue {
+		key := UniqueGuardKey("Customer", "email", value)
+		item := map[string]*dynamodb.AttributeValue{
+			"id":              key["id"],
+			"linnet:dataType": key["linnet:dataType"],
+			"linnet:node":     &dynamodb.AttributeValue{S: aws.String(nodeID)},
+		}
+		if ttl != 0 {
+			item["linnet:ttl"] = ttl.AttributeValue()
+		}
+		return item
+	}
+	customer := func(id string, email string, ttl TTL) map[string]*dynamodb.AttributeValue {
+		node := types.Node{
+			"id":               id,
+			"linnet:dataType":  "Node",
+			"linnet:namedType": "Customer",
+			"email":            email,
+		}
+		if ttl != 0 {
+			node["linnet:ttl"] = ttl
+		}
+		item, _ := dynamodbattribute.MarshalMap(node)
+		return item
+	}
+	value := func(email string, nodeID string) UniqueValue {
+		return UniqueValue{
+			NamedType: "Customer",
+			Field:     "email",
+			Value:     email,
+			NodeID:    nodeID,
+		}
+	}
+
+	type Output struct {
+		claimed []UniqueValue
+		err     string
+		// guards is the Node each email is held by after the claim
+		guards map[string]string
+	}
+
+	tests := []struct {
+		items  []map[string]*dynamodb.AttributeValue
+		values []UniqueValue
+		output Output
+	}{
+		{
+			values: []UniqueValue{value("a@example.com", "customer-1")},
+			output: Output{
+				claimed: []UniqueValue{value("a@example.com", "customer-1")},
+				guards:  map[string]string{"a@example.com": "customer-1"},
+			},
+		},
+		{
+			// The Node already holds the value
+			items: []map[string]*dynamodb.AttributeValue{
+				guard("a@example.com", "customer-1", 0),
+			},
+			values: []UniqueValue{value("a@example.com", "customer-1")},
+			output: Output{
+				guards: map[string]string{"a@example.com": "customer-1"},
+			},
+		},
+		{
+			// Another Node holds the second value, so the first is released
+			items: []map[string]*dynamodb.AttributeValue{
+				guard("b@example.com", "customer-2", 0),
+				customer("customer-2", "b@example.com", 0),
+			},
+			values: []UniqueValue{
+				value("a@example.com", "customer-1"),
+				value("b@example.com", "customer-1"),
+			},
+			output: Output{
+				err:    "UniqueConstraintViolation: Customer.email b@example.com is already used by another Customer",
+				guards: map[string]string{"b@example.com": "customer-2"},
+			},
+		},
+		{
+			// Another Node is being written with the value
+			items: []map[string]*dynamodb.AttributeValue{
+				guard("a@example.com", "customer-2", TTLAt(now.Add(time.Minute))),
+			},
+			values: []UniqueValue{value("a@example.com", "customer-1")},
+			output: Output{
+				err:    "UniqueConstraintViolation: Customer.email a@example.com is already used by another Customer",
+				guards: map[string]string{"a@example.com": "customer-2"},
+			},
+		},
+		{
+			// A Node that stopped before it was written
+			items: []map[string]*dynamodb.AttributeValue{
+				guard("a@example.com", "customer-2", TTLAt(now.Add(-time.Minute))),
+			},
+			values: []UniqueValue{value("a@example.com", "customer-1")},
+			output: Output{
+				claimed: []UniqueValue{value("a@example.com", "customer-1")},
+				guards:  map[string]string{"a@example.com": "customer-1"},
+			},
+		},
+		{
+			// The Node holding the value changed it
+			items: []map[string]*dynamodb.AttributeValue{
+				guard("a@example.com", "customer-2", 0),
+				customer("customer-2", "c@example.com", 0),
+			},
+			values: []UniqueValue{value("a@example.com", "customer-1")},
+			output: Output{
+				claimed: []UniqueValue{value("a@example.com", "customer-1")},
+				guards:  map[string]string{"a@example.com": "customer-1"},
+			},
+		},
+		{
+			// The Node holding the value was deleted
+			items: []map[string]*dynamodb.AttributeValue{
+				guard("a@example.com", "customer-2", 0),
+				customer("customer-2", "a@example.com", TTLAt(now)),
+			},
+			values: []UniqueValue{value("a@example.com", "customer-1")},
+			output: Output{
+				claimed: []UniqueValue{value("a@example.com", "customer-1")},
+				guards:  map[string]string{"a@example.com": "customer-1"},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestClaimUniqueValues")
+		assert := assert.New(t)
+
+		dynamo := &mockUniqueGuardDynamoDBClient{
+			items: make(map[string]map[string]*dynamodb.AttributeValue),
+		}
+		for _, item := range test.items {
+			dynamo.items[*item["id"].S] = item
+		}
+
+		claimed, err := ClaimUniqueValues(
+			ctx,
+			dynamo,
+			"test-table",
+			test.values,
+			now,
+		)
+
+		if test.output.err != "" {
+			assert.EqualError(err, test.output.err, fmt.Sprintf("Test %d", i))
+		} else {
+			assert.NoError(err, fmt.Sprintf("Test %d", i))
+		}
+		assert.Equal(test.output.claimed, claimed, fmt.Sprintf("Test %d", i))
+
+		guards := make(map[string]string)
+		for _, email := range []string{"a@example.com", "b@example.com"} {
+			key := UniqueGuardKey("Customer", "email", email)
+			if item, ok := dynamo.items[*key["id"].S]; ok {
+				guards[email] = *item["linnet:node"].S
+			}
+		}
+		assert.Equal(test.output.guards, guards, fmt.Sprintf("Test %d", i))
+	}
+}
+
+func TestUniqueGuardKey(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		guard string
+	}{
+		{
+			value: "a-100",
+			guard: "unique::Product::sku::a-100",
+		},
+		{
+			value: types.Number("1.5"),
+			guard: "unique::Product::sku::1.5",
+		},
+		{
+			value: types.Number("1.50"),
+			guard: "unique::Product::sku::1.5",
+		},
+		{
+			value: types.Number("15e-1"),
+			guard: "unique::Product::sku::1.5",
+		},
+		{
+			value: types.Number("1e2"),
+			guard: "unique::Product::sku::100",
+		},
+		{
+			value: 100,
+			guard: "unique::Product::sku::100",
+		},
+		{
+			// A String that looks like a number is kept as it is
+			value: "1.50",
+			guard: "unique::Product::sku::1.50",
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		key := UniqueGuardKey("Product", "sku", test.value)
+		assert.Equal(test.guard, *key["id"].S, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.guard, *key["linnet:dataType"].S, fmt.Sprintf("Test %d", i))
+	}
+}
+
+func TestHoldsUniqueValueNumbers(t *testing.T) {
+	now := time.Unix(1517446800, 0)
+
+	tests := []struct {
+		// stored is the N attribute DynamoDB returns for the Node
+		stored string
+		value  types.Number
+		held   bool
+	}{
+		{stored: "1.5", value: types.Number("1.50"), held: true},
+		{stored: "1.5", value: types.Number("15e-1"), held: true},
+		{stored: "100", value: types.Number("1e2"), held: true},
+		{stored: "1.5", value: types.Number("1.51"), held: false},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestHoldsUniqueValueNumbers")
+		assert := assert.New(t)
+
+		dynamo := &mockUniqueGuardDynamoDBClient{
+			items: map[string]map[string]*dynamodb.AttributeValue{
+				"product-1": map[string]*dynamodb.AttributeValue{
+					"id":               &dynamodb.AttributeValue{S: aws.String("product-1")},
+					"linnet:dataType":  &dynamodb.AttributeValue{S: aws.String("Node")},
+					"linnet:namedType": &dynamodb.AttributeValue{S: aws.String("Product")},
+					"weight":           &dynamodb.AttributeValue{N: aws.String(test.stored)},
+				},
+			},
+		}
+
+		held, err := HoldsUniqueValue(
+			ctx,
+			dynamo,
+			"test-table",
+			UniqueGuard{NodeID: "product-1"},
+			UniqueValue{
+				NamedType: "Product",
+				Field:     "weight",
+				Value:     test.value,
+				NodeID:    "product-2",
+			},
+			now,
+		)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.held, held, fmt.Sprintf("Test %d", i))
+	}
+}
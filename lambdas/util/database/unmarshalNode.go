@@ -0,0 +1,29 @@
+package database
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// UnmarshalNode from a DynamoDB item. Numbers are kept as a types.Number,
+// so they are not rounded to a float64.
+func UnmarshalNode(
+	item map[string]*dynamodb.AttributeValue,
+) (
+	node types.Node,
+	err error,
+) {
+	decoder := dynamodbattribute.NewDecoder(func(d *dynamodbattribute.Decoder) {
+		d.UseNumber = true
+	})
+
+	node = make(types.Node)
+	err = decoder.Decode(&dynamodb.AttributeValue{M: item}, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	types.ToNumbers(node)
+	return
+}
@@ -0,0 +1,40 @@
+package database_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalNode(t *testing.T) {
+	tests := []types.Node{
+		types.Node{
+			"id":             "order-1",
+			"linnet:version": types.Number("1"),
+			"total":          types.Number("19.99"),
+		},
+		types.Node{
+			"id":    "order-2",
+			"total": types.Number("12345678901234567890.01"),
+			"lines": []interface{}{
+				map[string]interface{}{"quantity": types.Number("9007199254740993")},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		item, err := dynamodbattribute.MarshalMap(test)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.NotNil(item["total"].N, fmt.Sprintf("Test %d", i))
+
+		node, err := database.UnmarshalNode(item)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test, node, fmt.Sprintf("Test %d", i))
+	}
+}
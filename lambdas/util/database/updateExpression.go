@@ -0,0 +1,140 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// UpdateExpression collects the SET, REMOVE and ADD actions, and any
+// conditions for a DynamoDB UpdateItem request.
+//
+// Field names and values are always passed as placeholders, as
+// linnet fields contain a colon and user fields may be reserved words.
+type UpdateExpression struct {
+	set        []string
+	remove     []string
+	add        []string
+	conditions []string
+	names      map[string]*string
+	values     map[string]*dynamodb.AttributeValue
+}
+
+// NewUpdateExpression -
+func NewUpdateExpression() *UpdateExpression {
+	return &UpdateExpression{
+		names:  make(map[string]*string),
+		values: make(map[string]*dynamodb.AttributeValue),
+	}
+}
+
+// Name returns the placeholder for a field name
+func (u *UpdateExpression) Name(field string) string {
+	for placeholder, name := range u.names {
+		if *name == field {
+			return placeholder
+		}
+	}
+
+	placeholder := fmt.Sprintf("#n%d", len(u.names))
+	u.names[placeholder] = aws.String(field)
+	return placeholder
+}
+
+// Value returns the placeholder for a value
+func (u *UpdateExpression) Value(value interface{}) (placeholder string, err error) {
+	attributeValue, err := dynamodbattribute.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	placeholder = fmt.Sprintf(":v%d", len(u.values))
+	u.values[placeholder] = attributeValue
+	return
+}
+
+// Set a field to a value
+func (u *UpdateExpression) Set(field string, value interface{}) (err error) {
+	valuePlaceholder, err := u.Value(value)
+	if err != nil {
+		return
+	}
+
+	u.set = append(
+		u.set,
+		fmt.Sprintf("%s = %s", u.Name(field), valuePlaceholder),
+	)
+	return
+}
+
+// Remove a field
+func (u *UpdateExpression) Remove(field string) {
+	u.remove = append(u.remove, u.Name(field))
+}
+
+// Add a number to a field, a field that is not set starts from 0
+func (u *UpdateExpression) Add(field string, value interface{}) (err error) {
+	valuePlaceholder, err := u.Value(value)
+	if err != nil {
+		return
+	}
+
+	u.add = append(
+		u.add,
+		fmt.Sprintf("%s %s", u.Name(field), valuePlaceholder),
+	)
+	return
+}
+
+// Condition that must be true for the update to be applied.
+// Conditions are joined with AND
+func (u *UpdateExpression) Condition(condition string) {
+	u.conditions = append(u.conditions, condition)
+}
+
+// Empty is true when there are no actions to apply
+func (u *UpdateExpression) Empty() bool {
+	return len(u.set) == 0 && len(u.remove) == 0 && len(u.add) == 0
+}
+
+// UpdateItemInput for the item with key, returning the updated item
+func (u *UpdateExpression) UpdateItemInput(
+	tableName string,
+	key map[string]*dynamodb.AttributeValue,
+) (
+	updateItemInput *dynamodb.UpdateItemInput,
+) {
+	var actions []string
+	if len(u.set) > 0 {
+		actions = append(actions, "SET "+strings.Join(u.set, ", "))
+	}
+	if len(u.remove) > 0 {
+		actions = append(actions, "REMOVE "+strings.Join(u.remove, ", "))
+	}
+	if len(u.add) > 0 {
+		actions = append(actions, "ADD "+strings.Join(u.add, ", "))
+	}
+
+	updateItemInput = &dynamodb.UpdateItemInput{
+		TableName:                aws.String(tableName),
+		Key:                      key,
+		ExpressionAttributeNames: u.names,
+		UpdateExpression:         aws.String(strings.Join(actions, " ")),
+		ReturnValues:             aws.String(dynamodb.ReturnValueAllNew),
+	}
+
+	if len(u.values) > 0 {
+		updateItemInput.ExpressionAttributeValues = u.values
+	}
+
+	if len(u.conditions) > 0 {
+		updateItemInput.ConditionExpression = aws.String(
+			strings.Join(u.conditions, " AND "),
+		)
+	}
+
+	return
+}
@@ -0,0 +1,109 @@
+package database_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateExpression(t *testing.T) {
+	currentTime := time.Unix(1517446800, 10).UTC()
+
+	key := map[string]*dynamodb.AttributeValue{
+		"id": &dynamodb.AttributeValue{
+			S: aws.String("81af6f8f-8639-4ff6-a881-083eb7135de0"),
+		},
+		"linnet:dataType": &dynamodb.AttributeValue{
+			S: aws.String("Node"),
+		},
+	}
+
+	tests := []struct {
+		build  func(expression *database.UpdateExpression)
+		output *dynamodb.UpdateItemInput
+	}{
+		{
+			build: func(expression *database.UpdateExpression) {
+				expression.Set("status", "PAID")
+				expression.Set("updatedAt", currentTime)
+			},
+			output: &dynamodb.UpdateItemInput{
+				TableName: aws.String("TestTable"),
+				Key:       key,
+				ExpressionAttributeNames: map[string]*string{
+					"#n0": aws.String("status"),
+					"#n1": aws.String("updatedAt"),
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":v0": &dynamodb.AttributeValue{S: aws.String("PAID")},
+					":v1": &dynamodb.AttributeValue{S: aws.String("2018-02-01T01:00:00.00000001Z")},
+				},
+				UpdateExpression: aws.String("SET #n0 = :v0, #n1 = :v1"),
+				ReturnValues:     aws.String("ALL_NEW"),
+			},
+		},
+		{
+			build: func(expression *database.UpdateExpression) {
+				expression.Set("price", 99)
+				expression.Remove("description")
+				expression.Condition(fmt.Sprintf(
+					"attribute_not_exists(%s)",
+					expression.Name("linnet:ttl"),
+				))
+			},
+			output: &dynamodb.UpdateItemInput{
+				TableName: aws.String("TestTable"),
+				Key:       key,
+				ExpressionAttributeNames: map[string]*string{
+					"#n0": aws.String("price"),
+					"#n1": aws.String("description"),
+					"#n2": aws.String("linnet:ttl"),
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":v0": &dynamodb.AttributeValue{N: aws.String("99")},
+				},
+				UpdateExpression:    aws.String("SET #n0 = :v0 REMOVE #n1"),
+				ConditionExpression: aws.String("attribute_not_exists(#n2)"),
+				ReturnValues:        aws.String("ALL_NEW"),
+			},
+		},
+		{
+			build: func(expression *database.UpdateExpression) {
+				expression.Set("status", "PAID")
+				expression.Add("linnet:version", 1)
+			},
+			output: &dynamodb.UpdateItemInput{
+				TableName: aws.String("TestTable"),
+				Key:       key,
+				ExpressionAttributeNames: map[string]*string{
+					"#n0": aws.String("status"),
+					"#n1": aws.String("linnet:version"),
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":v0": &dynamodb.AttributeValue{S: aws.String("PAID")},
+					":v1": &dynamodb.AttributeValue{N: aws.String("1")},
+				},
+				UpdateExpression: aws.String("SET #n0 = :v0 ADD #n1 :v1"),
+				ReturnValues:     aws.String("ALL_NEW"),
+			},
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		expression := database.NewUpdateExpression()
+		test.build(expression)
+
+		assert.Equal(
+			test.output,
+			expression.UpdateItemInput("TestTable", key),
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// UpdateNode applies an UpdateExpression to a Node item, and returns the
+// Node after the update.
+//
+// The update is only applied if the Node exists, is of namedType
+// and has not been deleted. Every update adds 1 to linnet:version.
+func UpdateNode(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	namedType string,
+	id string,
+	expression *UpdateExpression,
+) (
+	node types.Node,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "UpdateNode")
+	defer segment.Close(err)
+
+	namedTypeValue, err := expression.Value(namedType)
+	if err != nil {
+		return
+	}
+	expression.Condition(fmt.Sprintf(
+		"attribute_exists(%s) AND %s = %s AND attribute_not_exists(%s)",
+		expression.Name("id"),
+		expression.Name("linnet:namedType"),
+		namedTypeValue,
+		expression.Name("linnet:ttl"),
+	))
+
+	err = expression.Add("linnet:version", 1)
+	if err != nil {
+		return
+	}
+
+	updateItemInput := expression.UpdateItemInput(
+		tableName,
+		map[string]*dynamodb.AttributeValue{
+			"id": &dynamodb.AttributeValue{
+				S: aws.String(id),
+			},
+			"linnet:dataType": &dynamodb.AttributeValue{
+				S: aws.String("Node"),
+			},
+		},
+	)
+
+	updateItemResult, err := dynamo.UpdateItemWithContext(
+		ctx,
+		updateItemInput,
+	)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok &&
+			aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			err = types.NodeNotFoundError{
+				NamedType: namedType,
+				ID:        id,
+			}
+		}
+		return
+	}
+
+	node, err = UnmarshalNode(updateItemResult.Attributes)
+	node.WithVersion()
+	return
+}
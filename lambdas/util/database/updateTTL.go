@@ -2,48 +2,100 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-xray-sdk-go/xray"
 )
 
-// UpdateItemTTL -
-func UpdateItemTTL(
+// TombstoneItem sets the ttl on an item that exists, and has not already
+// been deleted. A missing item is not created.
+func TombstoneItem(
 	ctx context.Context,
 	dynamo dynamodbiface.DynamoDBAPI,
-	tableName *string,
-	item map[string]*dynamodb.AttributeValue,
-	ttl string,
+	tableName string,
+	key map[string]*dynamodb.AttributeValue,
+	ttl TTL,
 ) (
-	deleted bool,
+	tombstoned bool,
 	err error,
 ) {
-	ctx, segment := xray.BeginSubsegment(ctx, "updateItemTTL")
+	ctx, segment := xray.BeginSubsegment(ctx, "TombstoneItem")
 	defer segment.Close(err)
 
-	xray.AddAnnotation(ctx, "ttl", ttl)
+	updateItemInput := &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key:       key,
+		ExpressionAttributeNames: map[string]*string{
+			"#ttl": aws.String("linnet:ttl"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":ttlValue": ttl.AttributeValue(),
+		},
+		UpdateExpression:    aws.String("SET #ttl = :ttlValue"),
+		ConditionExpression: aws.String("attribute_exists(id) AND attribute_not_exists(#ttl)"),
+		ReturnValues:        aws.String("NONE"),
+	}
+
+	_, err = dynamo.UpdateItemWithContext(
+		ctx,
+		updateItemInput,
+	)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok &&
+			aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RestoreItem removes the ttl from an item that was deleted, as long as
+// the ttl has not passed. Once it passes DynamoDB can remove the item at
+// any time, so it cannot be restored.
+func RestoreItem(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	key map[string]*dynamodb.AttributeValue,
+	now time.Time,
+) (
+	restored bool,
+	err error,
+) {
+	ctx, segment := xray.BeginSubsegment(ctx, "RestoreItem")
+	defer segment.Close(err)
 
 	updateItemInput := &dynamodb.UpdateItemInput{
-		TableName: tableName,
-		Key:       item,
+		TableName: aws.String(tableName),
+		Key:       key,
 		ExpressionAttributeNames: map[string]*string{
 			"#ttl": aws.String("linnet:ttl"),
 		},
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":ttlValue": &dynamodb.AttributeValue{
-				N: aws.String(ttl),
-			},
+			":now": TTLAt(now).AttributeValue(),
 		},
-		UpdateExpression: aws.String("SET #ttl = :ttlValue"),
-		ReturnValues:     aws.String("NONE"),
+		UpdateExpression:    aws.String("REMOVE #ttl"),
+		ConditionExpression: aws.String("attribute_exists(#ttl) AND #ttl > :now"),
+		ReturnValues:        aws.String("NONE"),
 	}
 
 	_, err = dynamo.UpdateItemWithContext(
 		ctx,
 		updateItemInput,
 	)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok &&
+			aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return false, nil
+		}
+		return false, err
+	}
 
-	return true, err
+	return true, nil
 }
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// VerifyConnections checks every node we are connecting to exists, and is the
+// type expected by its edge. All the nodes are fetched with BatchGetItem in
+// batches of 25, and an error is returned for each field with missing nodes.
+func VerifyConnections(
+	ctx context.Context,
+	dynamo dynamodbiface.DynamoDBAPI,
+	tableName string,
+	connections []types.Connection,
+) (
+	errors []error,
+) {
+	var err error
+	ctx, segment := xray.BeginSubsegment(ctx, "VerifyConnections")
+	defer segment.Close(err)
+
+	if len(connections) == 0 {
+		return
+	}
+
+	// Collect every id once, so we only request each node once
+	var ids []string
+	seen := make(map[string]bool)
+	for _, connection := range connections {
+		for _, id := range connection.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	nodes, err := HydrateNodes(
+		ctx,
+		dynamo,
+		tableName,
+		ids,
+		false,
+	)
+	if err != nil {
+		errors = append(errors, err)
+		return
+	}
+
+	namedTypes := make(map[string]interface{}, len(nodes))
+	for _, node := range nodes {
+		if id, ok := node["id"].(string); ok {
+			namedTypes[id] = node["linnet:namedType"]
+		}
+	}
+
+	for _, connection := range connections {
+		var missing []string
+		for _, id := range connection.IDs {
+			if namedTypes[id] != connection.Edge.FieldType {
+				missing = append(missing, id)
+			}
+		}
+
+		if len(missing) > 0 {
+			errors = append(errors, fmt.Errorf(
+				"Cannot connect %s.%s, %s nodes not found: %s",
+				connection.Edge.TypeName,
+				connection.Edge.Field,
+				connection.Edge.FieldType,
+				strings.Join(missing, ", "),
+			))
+		}
+	}
+
+	return
+}
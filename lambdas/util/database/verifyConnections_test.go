@@ -0,0 +1,165 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/ojkelly/linnet/lambdas/util/database"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBatchGetDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	namedTypes map[string]string
+}
+
+func (m *mockBatchGetDynamoDBClient) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.BatchGetItemInput,
+	options ...request.Option,
+) (
+	*dynamodb.BatchGetItemOutput,
+	error,
+) {
+	output := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]*dynamodb.AttributeValue{},
+	}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		for _, key := range keysAndAttributes.Keys {
+			id := *key["id"].S
+			if namedType, ok := m.namedTypes[id]; ok {
+				output.Responses[tableName] = append(
+					output.Responses[tableName],
+					map[string]*dynamodb.AttributeValue{
+						"id":               &dynamodb.AttributeValue{S: aws.String(id)},
+						"linnet:dataType":  &dynamodb.AttributeValue{S: aws.String("Node")},
+						"linnet:namedType": &dynamodb.AttributeValue{S: aws.String(namedType)},
+					},
+				)
+			}
+		}
+	}
+	return output, nil
+}
+
+func TestVerifyConnections(t *testing.T) {
+	customerEdge := types.Edge{
+		TypeName:    "Order",
+		Field:       "customer",
+		FieldType:   "Customer",
+		EdgeName:    "OrdersOnCustomer",
+		Required:    true,
+		Cardinality: "ONE",
+		Principal:   "FALSE",
+		Counterpart: types.EdgeCounterpart{
+			TypeName: "Customer",
+			Field:    "orders",
+		},
+	}
+	productsEdge := types.Edge{
+		TypeName:    "Order",
+		Field:       "products",
+		FieldType:   "Product",
+		EdgeName:    "ProductsOnOrders",
+		Required:    true,
+		Cardinality: "MANY",
+		Principal:   "TRUE",
+		Counterpart: types.EdgeCounterpart{
+			TypeName: "Product",
+			Field:    "orders",
+		},
+	}
+
+	namedTypes := map[string]string{
+		"43a67e91-c1b8-4da3-bc32-51e763bb5596": "Customer",
+		"c82c8ee5-5457-4f6b-a516-390662230bb0": "Product",
+		"a7e2371d-69a4-4c28-b5a2-a4ac72ce2c26": "Product",
+	}
+
+	tests := []struct {
+		connections []types.Connection
+		output      []string
+	}{
+		{
+			connections: []types.Connection{
+				types.Connection{
+					Edge:   customerEdge,
+					NodeID: "bd9fc4c8-8209-4e68-9713-78e0c18c5df8",
+					IDs:    []string{"43a67e91-c1b8-4da3-bc32-51e763bb5596"},
+				},
+				types.Connection{
+					Edge:   productsEdge,
+					NodeID: "bd9fc4c8-8209-4e68-9713-78e0c18c5df8",
+					IDs: []string{
+						"c82c8ee5-5457-4f6b-a516-390662230bb0",
+						"a7e2371d-69a4-4c28-b5a2-a4ac72ce2c26",
+					},
+				},
+			},
+			output: nil,
+		},
+		{
+			connections: []types.Connection{
+				types.Connection{
+					Edge:   productsEdge,
+					NodeID: "bd9fc4c8-8209-4e68-9713-78e0c18c5df8",
+					IDs: []string{
+						"c82c8ee5-5457-4f6b-a516-390662230bb0",
+						"960612f4-1f2f-4b86-9c85-4a211c0ca203",
+					},
+				},
+			},
+			output: []string{
+				"Cannot connect Order.products, Product nodes not found: 960612f4-1f2f-4b86-9c85-4a211c0ca203",
+			},
+		},
+		{
+			// A node of the wrong type is treated as missing
+			connections: []types.Connection{
+				types.Connection{
+					Edge:   customerEdge,
+					NodeID: "bd9fc4c8-8209-4e68-9713-78e0c18c5df8",
+					IDs:    []string{"c82c8ee5-5457-4f6b-a516-390662230bb0"},
+				},
+			},
+			output: []string{
+				"Cannot connect Order.customer, Customer nodes not found: c82c8ee5-5457-4f6b-a516-390662230bb0",
+			},
+		},
+		{
+			connections: nil,
+			output:      nil,
+		},
+	}
+
+	for i, test := range tests {
+		ctx, _ := xray.BeginSegment(context.Background(), "TestVerifyConnections")
+
+		assert := assert.New(t)
+
+		errs := database.VerifyConnections(
+			ctx,
+			&mockBatchGetDynamoDBClient{namedTypes: namedTypes},
+			"DynamoDBTestTable",
+			test.connections,
+		)
+
+		var output []string
+		for _, err := range errs {
+			output = append(output, err.Error())
+		}
+
+		assert.Equal(
+			test.output,
+			output,
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
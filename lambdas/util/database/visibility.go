@@ -0,0 +1,43 @@
+package database
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// IsDeleted is true when an item has a linnet:ttl. DynamoDB can take up
+// to 48 hours to remove an item once its ttl passes, so deleted items are
+// hidden as soon as they have one.
+func IsDeleted(item types.Node) bool {
+	return item["linnet:ttl"] != nil
+}
+
+// FilterDeleted removes every deleted item from nodes
+func FilterDeleted(
+	nodes []types.Node,
+) (
+	visible []types.Node,
+) {
+	for _, node := range nodes {
+		if !IsDeleted(node) {
+			visible = append(visible, node)
+		}
+	}
+	return
+}
+
+// hideDeletedItems adds the same rule as IsDeleted to the FilterExpression
+// of a query, so deleted items are not returned
+func hideDeletedItems(queryInput *dynamodb.QueryInput) {
+	if queryInput.ExpressionAttributeNames == nil {
+		queryInput.ExpressionAttributeNames = make(map[string]*string)
+	}
+	queryInput.ExpressionAttributeNames["#ttl"] = aws.String("linnet:ttl")
+
+	filterExpression := "attribute_not_exists(#ttl)"
+	if queryInput.FilterExpression != nil && *queryInput.FilterExpression != "" {
+		filterExpression = "(" + *queryInput.FilterExpression + ") AND " + filterExpression
+	}
+	queryInput.FilterExpression = aws.String(filterExpression)
+}
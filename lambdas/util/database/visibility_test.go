@@ -0,0 +1,70 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterDeleted(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := []types.Node{
+		types.Node{"id": "1"},
+		types.Node{"id": "2", "linnet:ttl": float64(1517446800)},
+		types.Node{"id": "3"},
+	}
+
+	assert.Equal(
+		[]types.Node{
+			types.Node{"id": "1"},
+			types.Node{"id": "3"},
+		},
+		FilterDeleted(nodes),
+	)
+	assert.Nil(FilterDeleted(nil))
+}
+
+func TestHideDeletedItems(t *testing.T) {
+	tests := []struct {
+		filterExpression *string
+		output           string
+	}{
+		{
+			filterExpression: nil,
+			output:           "attribute_not_exists(#ttl)",
+		},
+		{
+			filterExpression: aws.String(""),
+			output:           "attribute_not_exists(#ttl)",
+		},
+		{
+			filterExpression: aws.String("#a = :a OR #b = :b"),
+			output:           "(#a = :a OR #b = :b) AND attribute_not_exists(#ttl)",
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		queryInput := dynamodb.QueryInput{
+			FilterExpression: test.filterExpression,
+		}
+		hideDeletedItems(&queryInput)
+
+		assert.Equal(
+			test.output,
+			*queryInput.FilterExpression,
+			fmt.Sprintf("Test %d", i),
+		)
+		assert.Equal(
+			"linnet:ttl",
+			*queryInput.ExpressionAttributeNames["#ttl"],
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// NewWriteError for an item DynamoDB did not write, with the kind of
+// error it returned
+func NewWriteError(item types.Node, err error) types.WriteError {
+	description := fmt.Sprintf("%v %v", item["linnet:namedType"], item["id"])
+	if item["linnet:dataType"] != "Node" {
+		description = fmt.Sprintf("edge %v on %v", item["linnet:dataType"], item["id"])
+	}
+
+	return types.WriteError{
+		Kind: ClassifyWriteError(err),
+		Item: description,
+		Err:  err,
+	}
+}
+
+// ClassifyWriteError returns the kind of error DynamoDB returned for a
+// write
+func ClassifyWriteError(err error) types.WriteErrorKind {
+	if request.IsErrorThrottle(err) {
+		return types.THROTTLED
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case dynamodb.ErrCodeConditionalCheckFailedException:
+			return types.CONDITION_FAILED
+		case "ValidationException", request.ErrCodeSerialization:
+			return types.VALIDATION
+		// Errors from marshalling the item, before it was sent
+		case "InvalidMarshalError", "unsupportedMarshalTypeError":
+			return types.VALIDATION
+		}
+	}
+	return types.UNKNOWN
+}
@@ -49,3 +49,36 @@ func MarshallItemsToWriteRequests(
 	}
 	return requests, err
 }
+
+// MarshallKeysToDeleteRequests in chunks of 25
+func MarshallKeysToDeleteRequests(
+	keys []map[string]*dynamodb.AttributeValue,
+) (
+	requests [][]*dynamodb.WriteRequest,
+) {
+	// Chunk the keys array into the max size allowed by the dynamo api 25
+	chunks := util.ChunkKeys(keys, 25)
+
+	for _, batchKeys := range chunks {
+		var deleteItems []*dynamodb.WriteRequest
+
+		for _, key := range batchKeys {
+			deleteItems = append(
+				deleteItems,
+				&dynamodb.WriteRequest{
+					DeleteRequest: &dynamodb.DeleteRequest{
+						Key: map[string]*dynamodb.AttributeValue{
+							"id":              key["id"],
+							"linnet:dataType": key["linnet:dataType"],
+						},
+					},
+				},
+			)
+		}
+		requests = append(
+			requests,
+			deleteItems,
+		)
+	}
+	return
+}
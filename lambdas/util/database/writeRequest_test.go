@@ -387,3 +387,40 @@ func TestMarshallItemsToWriteRequests(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshallKeysToDeleteRequests(t *testing.T) {
+	assert := assert.New(t)
+
+	var keys []map[string]*dynamodb.AttributeValue
+	for i := 0; i < 26; i++ {
+		keys = append(keys, map[string]*dynamodb.AttributeValue{
+			"id": &dynamodb.AttributeValue{
+				S: aws.String("33d47355-cf1b-4c78-aeb5-13f4d60e63ad"),
+			},
+			"linnet:dataType": &dynamodb.AttributeValue{
+				S: aws.String(fmt.Sprintf("ProductsOnOrders::%d", i)),
+			},
+			"linnet:edge": &dynamodb.AttributeValue{
+				S: aws.String(fmt.Sprintf("%d", i)),
+			},
+		})
+	}
+
+	requests := MarshallKeysToDeleteRequests(keys)
+
+	assert.Len(requests, 2)
+	assert.Len(requests[0], 25)
+	assert.Len(requests[1], 1)
+	assert.Equal(
+		map[string]*dynamodb.AttributeValue{
+			"id": &dynamodb.AttributeValue{
+				S: aws.String("33d47355-cf1b-4c78-aeb5-13f4d60e63ad"),
+			},
+			"linnet:dataType": &dynamodb.AttributeValue{
+				S: aws.String("ProductsOnOrders::25"),
+			},
+		},
+		requests[1][0].DeleteRequest.Key,
+	)
+	assert.Nil(MarshallKeysToDeleteRequests(nil))
+}
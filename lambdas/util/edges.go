@@ -34,3 +34,42 @@ func GetEdgeFromEdgeTypes(
 
 	return
 }
+
+// GetCounterpartEdge is the Edge on the other side of edge
+func GetCounterpartEdge(
+	edge types.Edge,
+	edgeTypes []types.Edge,
+) (
+	found bool,
+	counterpart types.Edge,
+) {
+	for _, counterpart := range edgeTypes {
+		if counterpart.TypeName == edge.Counterpart.TypeName &&
+			counterpart.Field == edge.Counterpart.Field {
+			return true, counterpart
+		}
+	}
+
+	return
+}
+
+// IsOneEdge is true when an edge can only connect ONE Node
+func IsOneEdge(edge types.Edge) bool {
+	return edge.Cardinality == types.ONE.String()
+}
+
+// GetUniqueFieldsOnType for a namedType, given an array of UniqueFields
+func GetUniqueFieldsOnType(
+	namedType string,
+	uniqueFields []types.UniqueField,
+) (
+	fields []types.UniqueField,
+) {
+	for _, uniqueField := range uniqueFields {
+		if uniqueField.TypeName == namedType {
+			fields = append(fields, uniqueField)
+		}
+	}
+
+	return
+}
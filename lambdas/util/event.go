@@ -0,0 +1,23 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// UnmarshalEvent decodes a Lambda event, with every number in its maps
+// kept as a types.Number rather than rounded to a float64
+func UnmarshalEvent(data []byte, event interface{}) (err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	err = decoder.Decode(event)
+	if err != nil {
+		return
+	}
+
+	types.ToNumbers(event)
+	return
+}
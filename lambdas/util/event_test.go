@@ -0,0 +1,57 @@
+package util_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalEvent(t *testing.T) {
+	tests := []struct {
+		input     string
+		arguments map[string]interface{}
+		output    string
+	}{
+		{
+			input: `{"context":{"arguments":{"data":{"price":19.99,"stock":3}}}}`,
+			arguments: map[string]interface{}{
+				"data": map[string]interface{}{
+					"price": types.Number("19.99"),
+					"stock": types.Number("3"),
+				},
+			},
+			output: `{"data":{"price":19.99,"stock":3}}`,
+		},
+		{
+			// Numbers a float64 cannot hold are kept exactly
+			input: `{"context":{"arguments":{"where":{"id":"order-1"},"data":{"total":12345678901234567890.01,"lines":[{"quantity":9007199254740993}]}}}}`,
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{"id": "order-1"},
+				"data": map[string]interface{}{
+					"total": types.Number("12345678901234567890.01"),
+					"lines": []interface{}{
+						map[string]interface{}{"quantity": types.Number("9007199254740993")},
+					},
+				},
+			},
+			output: `{"data":{"lines":[{"quantity":9007199254740993}],"total":12345678901234567890.01},"where":{"id":"order-1"}}`,
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		var event types.LambdaEvent
+		err := util.UnmarshalEvent([]byte(test.input), &event)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.arguments, event.Context.Arguments, fmt.Sprintf("Test %d", i))
+
+		output, err := json.Marshal(event.Context.Arguments)
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.output, string(output), fmt.Sprintf("Test %d", i))
+	}
+}
@@ -0,0 +1,19 @@
+package util
+
+import "github.com/ojkelly/linnet/lambdas/util/constants"
+
+// IsReadOnlyField is true for fields that are set by linnet, and cannot
+// be changed by an update
+func IsReadOnlyField(fieldName string) bool {
+	for _, linnetField := range constants.LinnetFields {
+		if fieldName == linnetField {
+			return true
+		}
+	}
+	for _, readOnlyField := range constants.ReadOnlyFields {
+		if fieldName == readOnlyField {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package util
+
+// UniqueIDs returns each id once, in the order they are first passed.
+// Empty ids are left out.
+func UniqueIDs(ids []string) (unique []string) {
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return
+}
@@ -0,0 +1,35 @@
+package util_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUniqueIDs(t *testing.T) {
+	tests := []struct {
+		ids    []string
+		unique []string
+	}{
+		{
+			ids:    nil,
+			unique: nil,
+		},
+		{
+			ids:    []string{"order-1", "order-2"},
+			unique: []string{"order-1", "order-2"},
+		},
+		{
+			ids:    []string{"order-2", "order-1", "order-2", "", "order-1"},
+			unique: []string{"order-2", "order-1"},
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		assert.Equal(test.unique, util.UniqueIDs(test.ids), fmt.Sprintf("Test %d", i))
+	}
+}
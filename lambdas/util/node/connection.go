@@ -0,0 +1,27 @@
+package node
+
+import "github.com/ojkelly/linnet/lambdas/util"
+
+// ExtractConnectionIDs from the value passed to connect an edge.
+// Depending on the cardinality of the edge, this is either a single
+// ID or a list of IDs. Each ID is only returned once, as a repeated ID
+// would write the same edge item twice in one batch.
+func ExtractConnectionIDs(
+	connectionValue interface{},
+) (
+	ids []string,
+) {
+	switch value := connectionValue.(type) {
+	case string:
+		ids = append(ids, value)
+	case []string:
+		ids = append(ids, value...)
+	case []interface{}:
+		for _, id := range value {
+			if idString, ok := id.(string); ok {
+				ids = append(ids, idString)
+			}
+		}
+	}
+	return util.UniqueIDs(ids)
+}
@@ -0,0 +1,72 @@
+package node_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ojkelly/linnet/lambdas/util/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractConnectionIDs(t *testing.T) {
+	tests := []struct {
+		input  interface{}
+		output []string
+	}{
+		{
+			input:  "05c339a9-e3d3-40c3-9df6-6fb28bae495a",
+			output: []string{"05c339a9-e3d3-40c3-9df6-6fb28bae495a"},
+		},
+		{
+			input: []interface{}{
+				"05c339a9-e3d3-40c3-9df6-6fb28bae495a",
+				"",
+				"81af6f8f-8639-4ff6-a881-083eb7135de0",
+			},
+			output: []string{
+				"05c339a9-e3d3-40c3-9df6-6fb28bae495a",
+				"81af6f8f-8639-4ff6-a881-083eb7135de0",
+			},
+		},
+		{
+			input:  []string{"81af6f8f-8639-4ff6-a881-083eb7135de0"},
+			output: []string{"81af6f8f-8639-4ff6-a881-083eb7135de0"},
+		},
+		{
+			// A repeated id is only connected once
+			input: []interface{}{
+				"81af6f8f-8639-4ff6-a881-083eb7135de0",
+				"05c339a9-e3d3-40c3-9df6-6fb28bae495a",
+				"81af6f8f-8639-4ff6-a881-083eb7135de0",
+			},
+			output: []string{
+				"81af6f8f-8639-4ff6-a881-083eb7135de0",
+				"05c339a9-e3d3-40c3-9df6-6fb28bae495a",
+			},
+		},
+		{
+			input:  "",
+			output: []string(nil),
+		},
+		{
+			input:  nil,
+			output: []string(nil),
+		},
+		{
+			input:  map[string]interface{}{},
+			output: []string(nil),
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		output := node.ExtractConnectionIDs(test.input)
+
+		assert.Equal(
+			test.output,
+			output,
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
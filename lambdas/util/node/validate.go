@@ -0,0 +1,103 @@
+package node
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// ValidateEdges checks the items about to be written against the
+// Cardinality and Required metadata on each Edge.
+//
+// Every Node connected by the edge items must have no more than one edge
+// on a ONE edge, and every new Node in items must have an edge on each
+// of its Required edges.
+func ValidateEdges(
+	edgeTypes []types.Edge,
+	items []types.Node,
+) (
+	errors []error,
+) {
+	// Count the edges on each edge field, for each node
+	counts := make(map[string]map[string]int)
+	seen := make(map[string]bool)
+
+	for _, item := range items {
+		id, _ := item["id"].(string)
+		dataType, _ := item["linnet:dataType"].(string)
+		if dataType == "Node" || seen[id+dataType] {
+			continue
+		}
+		seen[id+dataType] = true
+
+		edgeName := strings.SplitN(dataType, "::", 2)[0]
+		for _, edge := range edgeTypes {
+			if edge.EdgeName != edgeName {
+				continue
+			}
+
+			nodeID := id
+			if edge.Principal != "TRUE" {
+				nodeID, _ = item["linnet:edge"].(string)
+			}
+
+			key := edge.TypeName + "." + edge.Field
+			if counts[key] == nil {
+				counts[key] = make(map[string]int)
+			}
+			counts[key][nodeID]++
+		}
+	}
+
+	for _, edge := range edgeTypes {
+		if edge.Cardinality != types.ONE.String() {
+			continue
+		}
+
+		edgeCounts := counts[edge.TypeName+"."+edge.Field]
+
+		var nodeIDs []string
+		for nodeID, count := range edgeCounts {
+			if count > 1 {
+				nodeIDs = append(nodeIDs, nodeID)
+			}
+		}
+		sort.Strings(nodeIDs)
+
+		for _, nodeID := range nodeIDs {
+			errors = append(errors, types.EdgeCardinalityError{
+				TypeName: edge.TypeName,
+				Field:    edge.Field,
+				EdgeName: edge.EdgeName,
+				NodeID:   nodeID,
+				Count:    edgeCounts[nodeID],
+			})
+		}
+	}
+
+	for _, item := range items {
+		if item["linnet:dataType"] != "Node" {
+			continue
+		}
+		nodeID, _ := item["id"].(string)
+
+		for _, edge := range edgeTypes {
+			if edge.TypeName != item["linnet:namedType"] ||
+				!edge.Required {
+				continue
+			}
+
+			if counts[edge.TypeName+"."+edge.Field][nodeID] == 0 {
+				errors = append(errors, types.EdgeRequiredError{
+					TypeName: edge.TypeName,
+					Field:    edge.Field,
+					EdgeName: edge.EdgeName,
+					NodeID:   nodeID,
+				})
+			}
+		}
+	}
+
+	return
+}
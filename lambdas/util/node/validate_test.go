@@ -0,0 +1,132 @@
+package node_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ojkelly/linnet/lambdas/util/node"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEdges(t *testing.T) {
+	edgeTypes := []types.Edge{
+		types.Edge{
+			TypeName:    "Customer",
+			Field:       "orders",
+			FieldType:   "Order",
+			EdgeName:    "OrdersOnCustomer",
+			Required:    false,
+			Cardinality: "MANY",
+			Principal:   "TRUE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Order",
+				Field:    "customer",
+			},
+		},
+		types.Edge{
+			TypeName:    "Order",
+			Field:       "customer",
+			FieldType:   "Customer",
+			EdgeName:    "OrdersOnCustomer",
+			Required:    true,
+			Cardinality: "ONE",
+			Principal:   "FALSE",
+			Counterpart: types.EdgeCounterpart{
+				TypeName: "Customer",
+				Field:    "orders",
+			},
+		},
+	}
+
+	customer := types.Node{
+		"id":               "customer-1",
+		"linnet:dataType":  "Node",
+		"linnet:namedType": "Customer",
+	}
+	order := types.Node{
+		"id":               "order-1",
+		"linnet:dataType":  "Node",
+		"linnet:namedType": "Order",
+	}
+	edge := func(customerID string, orderID string) types.Node {
+		return types.Node{
+			"id":               customerID,
+			"linnet:dataType":  "OrdersOnCustomer::" + orderID,
+			"linnet:namedType": "Order",
+			"linnet:edge":      orderID,
+		}
+	}
+
+	tests := []struct {
+		items  []types.Node
+		output []string
+	}{
+		{
+			items: []types.Node{
+				customer,
+				order,
+				edge("customer-1", "order-1"),
+			},
+			output: nil,
+		},
+		{
+			// A Customer can have many Orders
+			items: []types.Node{
+				customer,
+				edge("customer-1", "order-1"),
+				edge("customer-1", "order-2"),
+			},
+			output: nil,
+		},
+		{
+			items: []types.Node{
+				order,
+				edge("customer-1", "order-1"),
+				edge("customer-2", "order-1"),
+			},
+			output: []string{
+				"Order.customer can only have ONE OrdersOnCustomer edge, order-1 has 2",
+			},
+		},
+		{
+			// The same edge written twice is still one edge
+			items: []types.Node{
+				order,
+				edge("customer-1", "order-1"),
+				edge("customer-1", "order-1"),
+			},
+			output: nil,
+		},
+		{
+			items: []types.Node{
+				customer,
+				order,
+			},
+			output: []string{
+				"Order.customer requires a OrdersOnCustomer edge, order-1 has none",
+			},
+		},
+		{
+			items:  nil,
+			output: nil,
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		errs := node.ValidateEdges(edgeTypes, test.items)
+
+		var output []string
+		for _, err := range errs {
+			output = append(output, err.Error())
+		}
+
+		assert.Equal(
+			test.output,
+			output,
+			fmt.Sprintf("Test %d", i),
+		)
+	}
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/ojkelly/linnet/lambdas/util/types"
+)
+
+// RelayConnection is a page of nodes in the Relay Cursor Connections
+// shape. cursors has the cursor of each node, in the same order.
+func RelayConnection(
+	nodes []types.Node,
+	cursors []string,
+	hasPreviousPage bool,
+	hasNextPage bool,
+) (
+	connection types.Node,
+	err error,
+) {
+	if len(nodes) != len(cursors) {
+		return nil, fmt.Errorf(
+			"Cannot make a connection of %d nodes with %d cursors",
+			len(nodes),
+			len(cursors),
+		)
+	}
+
+	edges := make([]types.Node, len(nodes))
+	for i, node := range nodes {
+		edges[i] = types.Node{
+			"cursor": cursors[i],
+			"node":   node,
+		}
+	}
+
+	pageInfo := types.Node{
+		"hasPreviousPage": hasPreviousPage,
+		"hasNextPage":     hasNextPage,
+		"startCursor":     nil,
+		"endCursor":       nil,
+	}
+	if len(cursors) > 0 {
+		pageInfo["startCursor"] = cursors[0]
+		pageInfo["endCursor"] = cursors[len(cursors)-1]
+	}
+
+	return types.Node{
+		"edges":    edges,
+		"pageInfo": pageInfo,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package util_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ojkelly/linnet/lambdas/util"
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelayConnection(t *testing.T) {
+	tests := []struct {
+		nodes           []types.Node
+		cursors         []string
+		hasPreviousPage bool
+		hasNextPage     bool
+		connection      types.Node
+		err             string
+	}{
+		{
+			nodes: []types.Node{
+				types.Node{"id": "order-1"},
+				types.Node{"id": "order-2"},
+			},
+			cursors:     []string{"cursor-1", "cursor-2"},
+			hasNextPage: true,
+			connection: types.Node{
+				"edges": []types.Node{
+					types.Node{"cursor": "cursor-1", "node": types.Node{"id": "order-1"}},
+					types.Node{"cursor": "cursor-2", "node": types.Node{"id": "order-2"}},
+				},
+				"pageInfo": types.Node{
+					"hasPreviousPage": false,
+					"hasNextPage":     true,
+					"startCursor":     "cursor-1",
+					"endCursor":       "cursor-2",
+				},
+			},
+		},
+		{
+			// An empty page has no cursors
+			hasPreviousPage: true,
+			connection: types.Node{
+				"edges": []types.Node{},
+				"pageInfo": types.Node{
+					"hasPreviousPage": true,
+					"hasNextPage":     false,
+					"startCursor":     nil,
+					"endCursor":       nil,
+				},
+			},
+		},
+		{
+			nodes:   []types.Node{types.Node{"id": "order-1"}},
+			cursors: []string{},
+			err:     "Cannot make a connection of 1 nodes with 0 cursors",
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		connection, err := util.RelayConnection(
+			test.nodes,
+			test.cursors,
+			test.hasPreviousPage,
+			test.hasNextPage,
+		)
+		if test.err != "" {
+			assert.EqualError(err, test.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.connection, connection, fmt.Sprintf("Test %d", i))
+	}
+}
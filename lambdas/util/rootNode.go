@@ -28,7 +28,7 @@ func CleanRootNode(
 		items,
 		StripLinnetFields(
 			findRootNode(rootNodeID, items),
-		),
+		).WithVersion(),
 	)
 
 	return
@@ -0,0 +1,11 @@
+package types
+
+// Connection links a Node to one or more existing Nodes across an Edge
+type Connection struct {
+	// The Edge the connection is made on
+	Edge Edge
+	// The Node on this side of the Edge
+	NodeID string
+	// The existing Nodes on the other side of the Edge
+	IDs []string
+}
@@ -7,6 +7,9 @@ type ConnectionPluralLambdaEvent struct {
 	NamedType    string                                `json:"namedType"`
 	EdgeTypes    []Edge                                `json:"edgeTypes"`
 	Context      ConnectionPluralLambdaResolverContext `json:"context"`
+	// Relay returns the connection in the Relay Cursor Connections shape,
+	// with a cursor on each edge and pageInfo
+	Relay bool `json:"relay"`
 }
 
 //ConnectionPluralLambdaResolverContext -
@@ -18,11 +21,35 @@ type ConnectionPluralLambdaResolverContext struct {
 
 // ConnectionPluralLambdaArguments -
 type ConnectionPluralLambdaArguments struct {
-	Filter map[string]FilterConfigValue `json:"filter"`
-	Limit  int64                        `json:"limit"`
-	Cursor string                       `json:"cursor"`
-	Where  WhereArguments               `json:"where"`
+	Filter  Filter         `json:"filter"`
+	OrderBy []OrderBy      `json:"orderBy"`
+	Limit   int64          `json:"limit"`
+	Cursor  string         `json:"cursor"`
+	Where   WhereArguments `json:"where"`
+	// First and After read forward from a cursor, Last and Before read
+	// backward, for Relay connections
+	First  int64  `json:"first"`
+	After  string `json:"after"`
+	Last   int64  `json:"last"`
+	Before string `json:"before"`
+	// IncludeDeleted returns Nodes and Edges that have a ttl
+	IncludeDeleted bool `json:"includeDeleted"`
 }
 
-// FilterConfigValue -
+// Filter selects Nodes by their fields. Each key is a field with the
+// comparisons its value must pass, or AND, OR or NOT to combine filters.
+type Filter map[string]interface{}
+
+// FilterConfigValue are the comparisons on a field of a Filter
 type FilterConfigValue map[string]interface{}
+
+// OrderBy sorts Nodes by a field. Nodes that are equal on the first
+// OrderBy are sorted by the next.
+type OrderBy struct {
+	Field string `json:"field"`
+	// Direction is ASC or DESC, ASC when it is empty
+	Direction string `json:"direction"`
+	// Nulls is FIRST or LAST. When it is empty, nulls sort as the largest
+	// value, last for ASC and first for DESC.
+	Nulls string `json:"nulls"`
+}
@@ -0,0 +1,48 @@
+package types
+
+import "fmt"
+
+// DeleteMode decides if a delete keeps the items until their ttl, or
+// removes them straight away
+type DeleteMode string
+
+const (
+	// SOFT sets a ttl on the items, they can be restored until it passes
+	SOFT DeleteMode = "SOFT"
+	// HARD removes the items, including any already soft deleted
+	HARD DeleteMode = "HARD"
+)
+
+// ParseDeleteMode from the mode argument of a delete. Without a mode the
+// default of the type is used, and without that SOFT. A type that is HARD
+// deleted cannot be SOFT deleted, as that would keep its data.
+func ParseDeleteMode(
+	mode string,
+	typeDefault DeleteMode,
+) (
+	deleteMode DeleteMode,
+	err error,
+) {
+	if mode == "" {
+		mode = string(typeDefault)
+	}
+	if DeleteMode(mode) == SOFT && typeDefault == HARD {
+		return deleteMode, fmt.Errorf(
+			"Cannot delete, mode %s is not allowed as the type is always %s deleted",
+			SOFT,
+			HARD,
+		)
+	}
+	switch DeleteMode(mode) {
+	case "", SOFT:
+		return SOFT, nil
+	case HARD:
+		return HARD, nil
+	}
+	return deleteMode, fmt.Errorf(
+		"Cannot delete, mode %s must be one of %s, %s",
+		mode,
+		SOFT,
+		HARD,
+	)
+}
@@ -0,0 +1,56 @@
+package types_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ojkelly/linnet/lambdas/util/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDeleteMode(t *testing.T) {
+	tests := []struct {
+		mode        string
+		typeDefault types.DeleteMode
+		deleteMode  types.DeleteMode
+		err         string
+	}{
+		{
+			mode:       "",
+			deleteMode: types.SOFT,
+		},
+		{
+			mode:        "",
+			typeDefault: types.HARD,
+			deleteMode:  types.HARD,
+		},
+		{
+			mode:        "HARD",
+			typeDefault: types.SOFT,
+			deleteMode:  types.HARD,
+		},
+		{
+			// A type that is HARD deleted never keeps its data
+			mode:        "SOFT",
+			typeDefault: types.HARD,
+			err:         "Cannot delete, mode SOFT is not allowed as the type is always HARD deleted",
+		},
+		{
+			mode: "LATER",
+			err:  "Cannot delete, mode LATER must be one of SOFT, HARD",
+		},
+	}
+
+	for i, test := range tests {
+		assert := assert.New(t)
+
+		deleteMode, err := types.ParseDeleteMode(test.mode, test.typeDefault)
+
+		if test.err != "" {
+			assert.EqualError(err, test.err, fmt.Sprintf("Test %d", i))
+			continue
+		}
+		assert.NoError(err, fmt.Sprintf("Test %d", i))
+		assert.Equal(test.deleteMode, deleteMode, fmt.Sprintf("Test %d", i))
+	}
+}
@@ -27,6 +27,10 @@ type Edge struct {
 
 	// The vertex on the other side of the edge
 	Counterpart EdgeCounterpart `json:"counterpart"`
+
+	// What happens to the connected Nodes when a Node of TypeName
+	// is deleted, defaults to DETACH
+	OnDelete EdgeDeletePolicy `json:"onDelete"`
 }
 
 // EdgeCounterpart is the Node on the other side of the Edge
@@ -55,3 +59,16 @@ const (
 	// FALSE this is NOT the principle edge
 	FALSE EdgePrinciple = iota
 )
+
+// EdgeDeletePolicy decides what happens to the connected Nodes when a Node
+// is deleted
+type EdgeDeletePolicy string
+
+const (
+	// CASCADE deletes the connected Nodes, and their edges
+	CASCADE EdgeDeletePolicy = "CASCADE"
+	// DETACH deletes only the edges to the connected Nodes
+	DETACH EdgeDeletePolicy = "DETACH"
+	// RESTRICT refuses to delete a Node that has connected Nodes
+	RESTRICT EdgeDeletePolicy = "RESTRICT"
+)
@@ -0,0 +1,215 @@
+package types
+
+import "fmt"
+
+// NodeNotFoundError is returned when a Node does not exist, or has been deleted
+type NodeNotFoundError struct {
+	NamedType string
+	ID        string
+}
+
+func (e NodeNotFoundError) Error() string {
+	return fmt.Sprintf("%s %s does not exist", e.NamedType, e.ID)
+}
+
+// EdgeCardinalityError is returned when a Node would have more than ONE
+// edge on a ONE edge
+type EdgeCardinalityError struct {
+	TypeName string
+	Field    string
+	EdgeName string
+	NodeID   string
+	Count    int
+}
+
+func (e EdgeCardinalityError) Error() string {
+	return fmt.Sprintf(
+		"%s.%s can only have %s %s edge, %s has %d",
+		e.TypeName,
+		e.Field,
+		ONE,
+		e.EdgeName,
+		e.NodeID,
+		e.Count,
+	)
+}
+
+// EdgeRequiredError is returned when a Node would have no edges on a
+// Required edge
+type EdgeRequiredError struct {
+	TypeName string
+	Field    string
+	EdgeName string
+	NodeID   string
+}
+
+func (e EdgeRequiredError) Error() string {
+	return fmt.Sprintf(
+		"%s.%s requires a %s edge, %s has none",
+		e.TypeName,
+		e.Field,
+		e.EdgeName,
+		e.NodeID,
+	)
+}
+
+// DeleteRestrictedError is returned when a Node cannot be deleted, as it
+// has edges on a RESTRICT edge
+type DeleteRestrictedError struct {
+	TypeName string
+	Field    string
+	EdgeName string
+	NodeID   string
+	Count    int
+}
+
+func (e DeleteRestrictedError) Error() string {
+	return fmt.Sprintf(
+		"Cannot delete %s %s, %s.%s is %s and has %d %s edges",
+		e.TypeName,
+		e.NodeID,
+		e.TypeName,
+		e.Field,
+		RESTRICT,
+		e.Count,
+		e.EdgeName,
+	)
+}
+
+// WriteErrorKind is why DynamoDB did not write an item
+type WriteErrorKind string
+
+const (
+	// THROTTLED writes can be tried again later
+	THROTTLED WriteErrorKind = "THROTTLED"
+	// CONDITION_FAILED writes would have replaced an item that exists
+	CONDITION_FAILED WriteErrorKind = "CONDITION_FAILED"
+	// VALIDATION writes are not a valid item
+	VALIDATION WriteErrorKind = "VALIDATION"
+	// UNKNOWN is any other error
+	UNKNOWN WriteErrorKind = "UNKNOWN"
+)
+
+// WriteError is returned when an item could not be written. Item is the
+// Node, as "NamedType id", or the edge, as "edge dataType on id".
+type WriteError struct {
+	Kind WriteErrorKind
+	Item string
+	Err  error
+}
+
+func (e WriteError) Error() string {
+	switch e.Kind {
+	case THROTTLED:
+		return fmt.Sprintf(
+			"Cannot write %s, DynamoDB is throttling writes, try again",
+			e.Item,
+		)
+	case CONDITION_FAILED:
+		return fmt.Sprintf(
+			"Cannot write %s, an item with this id already exists",
+			e.Item,
+		)
+	case VALIDATION:
+		return fmt.Sprintf("Cannot write %s, it is not valid: %s", e.Item, e.Err)
+	}
+	return fmt.Sprintf("Cannot write %s, %s", e.Item, e.Err)
+}
+
+// RollbackError is returned when a create did not write every item, and
+// the items it did write were removed again. Failed holds the error for
+// each item not written, and RollbackErr is set when some written items
+// could not be removed.
+type RollbackError struct {
+	NamedType   string
+	Items       int
+	Failed      []error
+	Written     int
+	Removed     int
+	RollbackErr error
+}
+
+func (e RollbackError) Error() string {
+	if e.RollbackErr != nil {
+		return fmt.Sprintf(
+			"Cannot create %s, %d of %d items were not written and %d of the %d written could not be removed (%s): %s",
+			e.NamedType,
+			len(e.Failed),
+			e.Items,
+			e.Written-e.Removed,
+			e.Written,
+			e.RollbackErr,
+			e.Failed[0],
+		)
+	}
+	return fmt.Sprintf(
+		"Cannot create %s, %d of %d items were not written and the %d written were removed: %s",
+		e.NamedType,
+		len(e.Failed),
+		e.Items,
+		e.Written,
+		e.Failed[0],
+	)
+}
+
+// UniqueConstraintViolation is returned when a value of a @unique field
+// is already held by another Node
+type UniqueConstraintViolation struct {
+	TypeName string
+	Field    string
+	Value    interface{}
+}
+
+func (e UniqueConstraintViolation) Error() string {
+	return fmt.Sprintf(
+		"UniqueConstraintViolation: %s.%s %v is already used by another %s",
+		e.TypeName,
+		e.Field,
+		e.Value,
+		e.TypeName,
+	)
+}
+
+// VersionConflictError is returned when an update expected a Node to be at
+// a version it is no longer at. Node is the Node as it is now.
+type VersionConflictError struct {
+	NamedType string
+	ID        string
+	Expected  int64
+	Current   int64
+	Node      Node
+}
+
+func (e VersionConflictError) Error() string {
+	return fmt.Sprintf(
+		"VersionConflict: %s %s is at version %d, not the expected version %d",
+		e.NamedType,
+		e.ID,
+		e.Current,
+		e.Expected,
+	)
+}
+
+// IncrementGuardError is returned when an increment would leave a field at
+// a value its guard does not allow. Node is the Node as it is now.
+type IncrementGuardError struct {
+	NamedType string
+	ID        string
+	Field     string
+	Guard     string
+	Bound     Number
+	Value     Number
+	Node      Node
+}
+
+func (e IncrementGuardError) Error() string {
+	return fmt.Sprintf(
+		"IncrementGuardFailed: %s %s %s would be %v, which is not %s %v",
+		e.NamedType,
+		e.ID,
+		e.Field,
+		e.Value,
+		e.Guard,
+		e.Bound,
+	)
+}
@@ -6,6 +6,8 @@ type LambdaEvent struct {
 	DataSource   DataSourceDynamoDBConfig `json:"dataSource"`
 	NamedType    string                   `json:"namedType"`
 	EdgeTypes    []Edge                   `json:"edgeTypes"`
+	UniqueFields []UniqueField            `json:"uniqueFields"`
+	DeleteMode   DeleteMode               `json:"deleteMode"`
 	Context      LinnetResolverContext    `json:"context"`
 }
 
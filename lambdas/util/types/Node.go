@@ -2,3 +2,19 @@ package types
 
 // Node -
 type Node map[string]interface{}
+
+// Version of the Node, which is 0 for a Node written before versions
+// were kept
+func (n Node) Version() int64 {
+	version, _ := IntValue(n["linnet:version"])
+	return version
+}
+
+// WithVersion sets the version field returned to clients from
+// linnet:version, on Nodes that have one
+func (n Node) WithVersion() Node {
+	if n != nil && n["linnet:version"] != nil {
+		n["version"] = n.Version()
+	}
+	return n
+}
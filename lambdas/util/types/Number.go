@@ -0,0 +1,139 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// Number is a number from an event or a DynamoDB N attribute. It is kept
+// as the decimal it was written as, so Int and Float values, and money,
+// are not rounded to a float64. It is written to JSON as a number, and to
+// DynamoDB as an N attribute.
+type Number string
+
+// MarshalJSON writes the number as it was read
+func (n Number) MarshalJSON() ([]byte, error) {
+	if _, ok := new(big.Rat).SetString(string(n)); !ok {
+		return nil, &json.UnsupportedValueError{
+			Value: reflect.ValueOf(n),
+			Str:   string(n),
+		}
+	}
+	return []byte(n), nil
+}
+
+// MarshalDynamoDBAttributeValue writes the number as an N attribute
+func (n Number) MarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	value := string(n)
+	av.N = &value
+	return nil
+}
+
+// Rat is the exact value of the number
+func (n Number) Rat() (*big.Rat, bool) {
+	return new(big.Rat).SetString(string(n))
+}
+
+// NumberValue gets the exact value of a number of any type. ok is false
+// when value is not a number.
+func NumberValue(value interface{}) (number *big.Rat, ok bool) {
+	switch value := value.(type) {
+	case Number:
+		return value.Rat()
+	case json.Number:
+		return new(big.Rat).SetString(string(value))
+	case dynamodbattribute.Number:
+		return new(big.Rat).SetString(string(value))
+	case float32:
+		return new(big.Rat).SetString(strconv.FormatFloat(float64(value), 'g', -1, 32))
+	case float64:
+		return new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, 64))
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(reflect.ValueOf(value).Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Rat).SetUint64(reflect.ValueOf(value).Uint()), true
+	}
+	return nil, false
+}
+
+// IntValue gets a whole number of any type. ok is false when value is not
+// a whole number that fits in an int64.
+func IntValue(value interface{}) (number int64, ok bool) {
+	rat, ok := NumberValue(value)
+	if !ok || !rat.IsInt() || !rat.Num().IsInt64() {
+		return 0, false
+	}
+	return rat.Num().Int64(), true
+}
+
+// NumberFromRat writes number as a decimal, with as many places as it
+// needs up to the 38 digits DynamoDB keeps
+func NumberFromRat(number *big.Rat) Number {
+	places := 0
+	scale := big.NewInt(1)
+	ten := big.NewInt(10)
+	for places < 38 && new(big.Int).Mod(scale, number.Denom()).Sign() != 0 {
+		scale.Mul(scale, ten)
+		places++
+	}
+	return Number(number.FloatString(places))
+}
+
+// ToNumbers replaces every json.Number and dynamodbattribute.Number in
+// value with a Number. Maps, slices and the fields of structs are changed
+// in place, and value is returned with its numbers replaced.
+func ToNumbers(value interface{}) interface{} {
+	switch number := value.(type) {
+	case json.Number:
+		return Number(number)
+	case dynamodbattribute.Number:
+		return Number(number)
+	}
+
+	toNumbers(reflect.ValueOf(value))
+	return value
+}
+
+func toNumbers(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			toNumbers(v.Elem())
+		}
+	case reflect.Interface:
+		if !v.IsNil() && v.CanSet() {
+			v.Set(reflect.ValueOf(ToNumbers(v.Interface())))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				toNumbers(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			toNumbers(v.Index(i))
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.Interface {
+			for _, key := range v.MapKeys() {
+				toNumbers(v.MapIndex(key))
+			}
+			return
+		}
+		for _, key := range v.MapKeys() {
+			elem := v.MapIndex(key)
+			if !elem.IsNil() {
+				v.SetMapIndex(key, reflect.ValueOf(ToNumbers(elem.Interface())))
+			}
+		}
+	}
+}
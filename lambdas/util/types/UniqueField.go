@@ -0,0 +1,11 @@
+package types
+
+// UniqueField is a field marked @unique, no two Nodes of TypeName can
+// have the same value on it
+type UniqueField struct {
+	// Type where this field is found
+	TypeName string `json:"typeName"`
+
+	// Name of the field
+	Field string `json:"field"`
+}
@@ -11,10 +11,13 @@ import {
 
 const resolverTypes = [
   "create",
+  "upsert",
   "update",
   "updateMany",
   "delete",
   "deleteMany",
+  "restore",
+  "trash",
   "connection",
   "connectionPlural",
 ];
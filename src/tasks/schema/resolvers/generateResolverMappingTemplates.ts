@@ -26,6 +26,7 @@ import {
   Edge,
   EdgeCardinality,
 } from "../schemaProcessing/steps/generateArtifacts/extractEdges";
+import { UniqueField } from "../schemaProcessing/steps/generateArtifacts/extractUniqueFields";
 import { generateDynamoDBDataSourceTemplate } from "../dataSources/dynamoDB";
 
 import { Config } from "../../common/types";
@@ -41,6 +42,7 @@ function generateResolverMappingTemplates({
   types,
   typeDefs,
   edges,
+  uniqueFields,
   config,
 }: {
   dataSourceTemplates: DataSourceTemplates;
@@ -48,6 +50,7 @@ function generateResolverMappingTemplates({
   types: any;
   typeDefs: string;
   edges: Edge[];
+  uniqueFields: UniqueField[];
   config: Config;
 }): ResolverTemplates {
   const resolverTemplates: ResolverTemplates | any = {};
@@ -77,6 +80,7 @@ function generateResolverMappingTemplates({
         fieldType: queryTypeMap[field],
         resolverType: newTypeDataSourceMap.query[field].resolverType,
         namedType: newTypeDataSourceMap.query[field].name,
+        relay: newTypeDataSourceMap.query[field].relay,
         edges,
       });
     }
@@ -109,7 +113,9 @@ function generateResolverMappingTemplates({
             fieldType: mutationTypeMap[field],
             resolverType: newTypeDataSourceMap.mutation[field].resolverType,
             namedType: newTypeDataSourceMap.mutation[field].name,
+            deleteMode: newTypeDataSourceMap.mutation[field].deleteMode,
             edges,
+            uniqueFields,
           });
           break;
         case DataSource.ElasticSearch:
@@ -158,6 +164,7 @@ function generateResolverMappingTemplates({
             resolverType:
               newTypeDataSourceMap.query[connectionTypeName].resolverType,
             namedType: newTypeDataSourceMap.query[connectionTypeName].name,
+            relay: newTypeDataSourceMap.query[connectionTypeName].relay,
             edges: [edge],
           });
 
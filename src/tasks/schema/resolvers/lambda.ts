@@ -26,6 +26,7 @@ import {
   Edge,
   EdgePrinciple,
 } from "../schemaProcessing/steps/generateArtifacts/extractEdges";
+import { UniqueField } from "../schemaProcessing/steps/generateArtifacts/extractUniqueFields";
 const pkg = require("../../../../package.json");
 import { generateLambdaDataSourceTemplate } from "../dataSources/lambda";
 import * as createGenerator from "./lambda/create";
@@ -33,6 +34,9 @@ import * as deleteGenerator from "./lambda/delete";
 import * as deleteManyGenerator from "./lambda/delete";
 import * as updateGenerator from "./lambda/update";
 import * as updateManyGenerator from "./lambda/updateMany";
+import * as restoreGenerator from "./lambda/restore";
+import * as upsertGenerator from "./lambda/upsert";
+import * as trashGenerator from "./lambda/trash";
 
 import * as connection from "./lambda/connection";
 import * as connectionPlural from "./lambda/connectionPlural";
@@ -54,6 +58,9 @@ function generateLambdaResolverTemplate({
   namedType,
   resolverType,
   edges,
+  uniqueFields,
+  deleteMode,
+  relay,
   config,
 }: {
   dataSource: DataSourceTemplate;
@@ -64,6 +71,9 @@ function generateLambdaResolverTemplate({
   fieldType: GraphQLField<any, any, any>;
   resolverType: string;
   edges?: Edge[];
+  uniqueFields?: UniqueField[];
+  deleteMode?: string;
+  relay?: boolean;
   config: Config;
 }): ResolverTemplate | any {
   const date = new Date();
@@ -76,7 +86,7 @@ function generateLambdaResolverTemplate({
 ## FieldName: ${fieldName}
 
 ## This is an array of all the linnet system fields
-#set($linnetFields = ["linnet:dataType","linnet:edge","linnet:namedType","linnet:ttl"])
+#set($linnetFields = ["linnet:dataType","linnet:edge","linnet:namedType","linnet:ttl","linnet:version"])
 `;
   const lambdaDataSource = generateLambdaDataSourceTemplate({
     config,
@@ -96,6 +106,7 @@ function generateLambdaResolverTemplate({
       namedType,
       resolverType,
       edges,
+      relay,
       headerString,
     }),
     requestMappingTemplate: generateRequestTemplate({
@@ -106,6 +117,9 @@ function generateLambdaResolverTemplate({
       dataSource,
       resolverType,
       edges,
+      uniqueFields,
+      deleteMode,
+      relay,
       headerString,
     }),
   };
@@ -153,6 +167,9 @@ function generateRequestTemplate({
   dataSource,
   resolverType,
   edges,
+  uniqueFields,
+  deleteMode,
+  relay,
   headerString,
 }: {
   field: string;
@@ -162,6 +179,9 @@ function generateRequestTemplate({
   dataSource: DataSourceTemplate;
   resolverType: string;
   edges?: Edge[];
+  uniqueFields?: UniqueField[];
+  deleteMode?: string;
+  relay?: boolean;
   headerString: string;
 }): string {
   switch (resolverType) {
@@ -197,11 +217,23 @@ function generateRequestTemplate({
         dataSource,
         resolverType,
         edges,
+        relay,
         headerString,
       });
     case "connection":
       return connection.generateRequestTemplate({
         field,
+        fieldName,
+        fieldType,
+        namedType,
+        dataSource,
+        resolverType,
+        edges,
+        relay,
+        headerString,
+      });
+    case "trash":
+      return trashGenerator.generateRequestTemplate({
         fieldName,
         fieldType,
         namedType,
@@ -219,6 +251,18 @@ function generateRequestTemplate({
         dataSource,
         resolverType,
         edges,
+        uniqueFields,
+        headerString,
+      });
+    case "upsert":
+      return upsertGenerator.generateRequestTemplate({
+        fieldName,
+        fieldType,
+        namedType,
+        dataSource,
+        resolverType,
+        edges,
+        uniqueFields,
         headerString,
       });
     case "update":
@@ -229,6 +273,7 @@ function generateRequestTemplate({
         dataSource,
         resolverType,
         edges,
+        uniqueFields,
         headerString,
       });
     case "updateMany":
@@ -239,6 +284,7 @@ function generateRequestTemplate({
         dataSource,
         resolverType,
         edges,
+        uniqueFields,
         headerString,
       });
     case "delete":
@@ -249,6 +295,8 @@ function generateRequestTemplate({
         dataSource,
         resolverType,
         edges,
+        uniqueFields,
+        deleteMode,
         headerString,
       });
     case "deleteMany":
@@ -259,9 +307,21 @@ function generateRequestTemplate({
         dataSource,
         resolverType,
         edges,
+        uniqueFields,
+        deleteMode,
+        headerString,
+      });
+    case "restore":
+      return restoreGenerator.generateRequestTemplate({
+        fieldName,
+        fieldType,
+        namedType,
+        dataSource,
+        resolverType,
+        edges,
+        uniqueFields,
         headerString,
       });
-
     default:
   }
 }
@@ -278,6 +338,7 @@ function generateResponseTemplate({
   dataSource,
   resolverType,
   edges,
+  relay,
   headerString,
 }: {
   field: string;
@@ -287,6 +348,7 @@ function generateResponseTemplate({
   dataSource: DataSourceTemplate;
   resolverType: string;
   edges: Edge[];
+  relay?: boolean;
   headerString: string;
 }): string {
   // Add error handling to the repsonse templates
@@ -329,11 +391,23 @@ function generateResponseTemplate({
         dataSource,
         resolverType,
         edges,
+        relay,
         headerString,
       });
     case "connection":
       return connection.generateResponseTemplate({
         field,
+        fieldName,
+        fieldType,
+        namedType,
+        dataSource,
+        resolverType,
+        edges,
+        relay,
+        headerString,
+      });
+    case "trash":
+      return trashGenerator.generateResponseTemplate({
         fieldName,
         fieldType,
         namedType,
@@ -353,6 +427,16 @@ function generateResponseTemplate({
         edges,
         headerString: header,
       });
+    case "upsert":
+      return upsertGenerator.generateResponseTemplate({
+        fieldName,
+        fieldType,
+        namedType,
+        dataSource,
+        resolverType,
+        edges,
+        headerString: header,
+      });
     case "update":
       return updateGenerator.generateResponseTemplate({
         fieldName,
@@ -393,6 +477,16 @@ function generateResponseTemplate({
         edges,
         headerString: header,
       });
+    case "restore":
+      return restoreGenerator.generateResponseTemplate({
+        fieldName,
+        fieldType,
+        namedType,
+        dataSource,
+        resolverType,
+        edges,
+        headerString: header,
+      });
     default:
   }
 }
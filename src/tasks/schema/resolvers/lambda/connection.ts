@@ -11,6 +11,7 @@ function generateRequestTemplate({
   dataSource,
   resolverType,
   edges,
+  relay,
   headerString,
 }: {
   field: string;
@@ -20,6 +21,7 @@ function generateRequestTemplate({
   dataSource: DataSourceTemplate;
   resolverType: string;
   edges: Edge[];
+  relay?: boolean;
   headerString: string;
 }): string | any {
   const dataSourceConfig: DataSourceDynamoDBConfig = dataSource.config as DataSourceDynamoDBConfig;
@@ -41,6 +43,7 @@ function generateRequestTemplate({
 #set($payload.edgeTypes = ${JSON.stringify(edges)})
 
 #set($payload.context = $context)
+#set($payload.relay = ${relay ? "true" : "false"})
 
 {
   "version": "2017-02-28",
@@ -52,6 +55,7 @@ function generateRequestTemplate({
 
 function generateResponseTemplate({
   resolverType,
+  relay,
   headerString,
 }: {
   field: string;
@@ -61,6 +65,7 @@ function generateResponseTemplate({
   dataSource: DataSourceTemplate;
   resolverType: string;
   edges: Edge[];
+  relay?: boolean;
   headerString: string;
 }): string | any {
   return `${headerString}
@@ -73,9 +78,16 @@ function generateResponseTemplate({
     #end
 #end
 
-{
+${
+    relay
+      ? `{
+  "edges": $util.toJson($result.data.edges),
+  "pageInfo": $util.toJson($result.data.pageInfo),
+}`
+      : `{
   "edge": $util.toJson($result.data),
-}`;
+}`
+  }`;
 }
 
 export { generateRequestTemplate, generateResponseTemplate };
@@ -11,6 +11,7 @@ function generateRequestTemplate({
   dataSource,
   resolverType,
   edges,
+  relay,
   headerString,
 }: {
   field: string;
@@ -20,6 +21,7 @@ function generateRequestTemplate({
   dataSource: DataSourceTemplate;
   resolverType: string;
   edges: Edge[];
+  relay?: boolean;
   headerString: string;
 }): string | any {
   const dataSourceConfig: DataSourceDynamoDBConfig = dataSource.config as DataSourceDynamoDBConfig;
@@ -41,6 +43,7 @@ function generateRequestTemplate({
 #set($payload.edgeTypes = ${JSON.stringify(edges)})
 
 #set($payload.context = $context)
+#set($payload.relay = ${relay ? "true" : "false"})
 
 {
   "version": "2017-02-28",
@@ -52,6 +55,7 @@ function generateRequestTemplate({
 
 function generateResponseTemplate({
   resolverType,
+  relay,
   headerString,
 }: {
   field: string;
@@ -61,17 +65,28 @@ function generateResponseTemplate({
   dataSource: DataSourceTemplate;
   resolverType: string;
   edges: Edge[];
+  relay?: boolean;
   headerString: string;
 }): string | any {
   return `${headerString}
 ## ResolverType: ${resolverType}
 #set($result = $util.parseJson($util.base64Decode($ctx.result)))
 
-{
+${
+    relay
+      ? `{
+  "edges": $util.toJson($result.data.edges),
+  "pageInfo": $util.toJson($result.data.pageInfo),
+  "count": $util.toJson($result.data.count),
+  "scannedCount": $util.toJson($result.data.scannedCount)
+}`
+      : `{
   "edges": $util.toJson($result.data.edges),
   "cursor": $util.toJson($result.data.cursor),
-  "count": $util.toJson($result.data.count)
-}`;
+  "count": $util.toJson($result.data.count),
+  "scannedCount": $util.toJson($result.data.scannedCount)
+}`
+  }`;
 }
 
 export { generateRequestTemplate, generateResponseTemplate };
@@ -17,6 +17,7 @@ import {
   Edge,
   EdgePrinciple,
 } from "../../schemaProcessing/steps/generateArtifacts/extractEdges";
+import { UniqueField } from "../../schemaProcessing/steps/generateArtifacts/extractUniqueFields";
 import * as pluralize from "pluralize";
 
 function generateRequestTemplate({
@@ -26,6 +27,8 @@ function generateRequestTemplate({
   dataSource,
   resolverType,
   edges,
+  uniqueFields,
+  deleteMode,
   headerString,
 }: {
   fieldName: string;
@@ -34,6 +37,8 @@ function generateRequestTemplate({
   dataSource: DataSourceTemplate;
   resolverType: string;
   edges: Edge[];
+  uniqueFields?: UniqueField[];
+  deleteMode?: string;
   headerString: string;
 }): string | any {
   const dataSourceConfig: DataSourceDynamoDBConfig = dataSource.config as DataSourceDynamoDBConfig;
@@ -49,6 +54,8 @@ function generateRequestTemplate({
 
 #set($payload.namedType = "${namedType}")
 #set($payload.edgeTypes = ${JSON.stringify(edges)})
+#set($payload.uniqueFields = ${JSON.stringify(uniqueFields || [])})
+#set($payload.deleteMode = "${deleteMode || "SOFT"}")
 
 #set($payload.context = $context)
 {
@@ -1,5 +1,6 @@
 import { DataSourceDynamoDBConfig } from "../../dataSources/dataSources";
 import { Edge } from "../../schemaProcessing/steps/generateArtifacts/extractEdges";
+import { UniqueField } from "../../schemaProcessing/steps/generateArtifacts/extractUniqueFields";
 
 type CreateInput = {
   data?: {
@@ -26,13 +27,15 @@ type UpdateInput = {
 };
 
 type UpdateManyInput = {
-  data?: {
+  data: {
     [key: string]: any;
   };
-  connections?: string[];
-  where: {
+  where?: {
     ids: string[];
   };
+  filter?: {
+    [key: string]: any;
+  };
 };
 
 type DeleteInput = {
@@ -60,6 +63,7 @@ type HandlerEvent = {
   dataSource: DataSourceDynamoDBConfig;
   namedType: string;
   edgeTypes: Edge[];
+  uniqueFields?: UniqueField[];
   context: {
     arguments:
       | CreateInput
@@ -17,6 +17,7 @@ import {
   Edge,
   EdgePrinciple,
 } from "../../schemaProcessing/steps/generateArtifacts/extractEdges";
+import { UniqueField } from "../../schemaProcessing/steps/generateArtifacts/extractUniqueFields";
 import * as pluralize from "pluralize";
 
 function generateRequestTemplate({
@@ -26,6 +27,7 @@ function generateRequestTemplate({
   dataSource,
   resolverType,
   edges,
+  uniqueFields,
   headerString,
 }: {
   fieldName: string;
@@ -34,6 +36,7 @@ function generateRequestTemplate({
   dataSource: DataSourceTemplate;
   resolverType: string;
   edges: Edge[];
+  uniqueFields?: UniqueField[];
   headerString: string;
 }): string | any {
   const dataSourceConfig: DataSourceDynamoDBConfig = dataSource.config as DataSourceDynamoDBConfig;
@@ -49,6 +52,7 @@ function generateRequestTemplate({
 
 #set($payload.namedType = "${namedType}")
 #set($payload.edgeTypes = ${JSON.stringify(edges)})
+#set($payload.uniqueFields = ${JSON.stringify(uniqueFields || [])})
 
 #set($payload.context = $context)
 {
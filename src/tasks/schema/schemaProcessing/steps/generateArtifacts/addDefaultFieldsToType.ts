@@ -2,6 +2,7 @@ import {
   GraphQLObjectType,
   GraphQLString,
   GraphQLID,
+  GraphQLInt,
   GraphQLNonNull,
 } from "graphql";
 
@@ -10,6 +11,8 @@ import {
  * id: ID!
  * createdAt: DateTime!
  * updatedAt: DateTime!
+ * createdBy: ID!
+ * version: Int
  */
 function addDefaultFieldsToType(type: GraphQLObjectType): GraphQLObjectType {
   (type as GraphQLObjectType).getFields().id = {
@@ -40,6 +43,13 @@ function addDefaultFieldsToType(type: GraphQLObjectType): GraphQLObjectType {
     args: [],
   };
 
+  (type as GraphQLObjectType).getFields().version = {
+    name: "version",
+    type: GraphQLInt,
+    description: "",
+    args: [],
+  };
+
   return type;
 }
 
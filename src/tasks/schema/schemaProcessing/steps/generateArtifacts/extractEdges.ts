@@ -35,6 +35,7 @@ function extractEdges({ ast, schema }) {
                 if (directive.name.value === "edge") {
                   let edgeName: string;
                   let principal: boolean = false;
+                  let onDelete: EdgeDeletePolicy = EdgeDeletePolicy.DETACH;
 
                   directive.arguments.forEach(argument => {
                     if (
@@ -49,6 +50,16 @@ function extractEdges({ ast, schema }) {
                     ) {
                       principal = argument.value.value;
                     }
+                    if (
+                      argument.name.value === "onDelete" &&
+                      argument.value.kind === "StringValue"
+                    ) {
+                      onDelete = getDeletePolicy({
+                        policy: argument.value.value,
+                        typeName: node.name.value,
+                        field: field.name.value,
+                      });
+                    }
                   });
                   if (edgeName) {
                     const cardinality = getCardinalityFromType({
@@ -77,6 +88,7 @@ function extractEdges({ ast, schema }) {
                       cardinality,
                       edgeName,
                       required: field.type.kind === "NonNullType",
+                      onDelete,
                     });
                   }
                 }
@@ -118,6 +130,29 @@ function getCardinalityFromType({ type }: { type: TypeNode }): EdgeCardinality {
   }
 }
 
+/**
+ * Check the onDelete argument on an @edge is a known policy
+ * @param options
+ */
+function getDeletePolicy({
+  policy,
+  typeName,
+  field,
+}: {
+  policy: string;
+  typeName: string;
+  field: string;
+}): EdgeDeletePolicy {
+  if (Object.keys(EdgeDeletePolicy).indexOf(policy) === -1) {
+    throw new Error(
+      `${typeName}.${field} has an onDelete of ${policy}, it must be one of ${Object.keys(
+        EdgeDeletePolicy,
+      ).join(", ")}.`,
+    );
+  }
+  return EdgeDeletePolicy[policy];
+}
+
 /**
  * Validates that there are exactly 2 edges of each name
  * throws an exception if there isnt.
@@ -168,6 +203,14 @@ enum EdgePrinciple {
   TRUE = "TRUE",
   FALSE = "FALSE",
 }
+enum EdgeDeletePolicy {
+  // Delete the connected nodes as well
+  CASCADE = "CASCADE",
+  // Only delete the edges to the connected nodes
+  DETACH = "DETACH",
+  // Refuse to delete while there are connected nodes
+  RESTRICT = "RESTRICT",
+}
 type Edge = {
   // Type where this edge is found
   typeName: string;
@@ -188,6 +231,8 @@ type Edge = {
   // else
   // id === nestedItem.id
   principal: EdgePrinciple | string;
+  // What happens to connected nodes when a node of typeName is deleted
+  onDelete?: EdgeDeletePolicy | string;
   // The vertex on the other side of the edge
   counterpart?: {
     typeName: string;
@@ -196,4 +241,11 @@ type Edge = {
   };
 };
 
-export { extractEdges, getTypeFromEdge, Edge, EdgeCardinality, EdgePrinciple };
+export {
+  extractEdges,
+  getTypeFromEdge,
+  Edge,
+  EdgeCardinality,
+  EdgePrinciple,
+  EdgeDeletePolicy,
+};
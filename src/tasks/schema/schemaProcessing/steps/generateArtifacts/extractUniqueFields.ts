@@ -0,0 +1,73 @@
+import { visit, GraphQLObjectType } from "graphql";
+
+/**
+ * From an AST extract the fields marked with the @unique directive
+ *
+ * These are sent to the create, update and delete lambdas, which keep a
+ * guard item for each value so no two nodes of a type can share it
+ *
+ * @param options
+ */
+function extractUniqueFields({ ast, schema }): UniqueField[] {
+  let uniqueFields: UniqueField[] = [];
+  visit(ast, {
+    enter: (node: any) => {
+      if (node.kind == "ObjectTypeDefinition") {
+        if (
+          node.name.value === "Query" ||
+          node.name.value === "Mutation" ||
+          node.name.value === "Subscription"
+        ) {
+          return;
+        }
+        const type: GraphQLObjectType = schema.getType(node.name.value);
+
+        if (type.astNode && type.astNode.fields) {
+          type.astNode.fields.forEach(field => {
+            if (
+              field.directives &&
+              field.directives.some(
+                directive => directive.name.value === "unique",
+              )
+            ) {
+              if (field.name.value === "id") {
+                throw new Error(
+                  `${node.name.value}.id cannot be @unique, ids are already unique`,
+                );
+              }
+              uniqueFields.push({
+                typeName: node.name.value,
+                field: field.name.value,
+              });
+            }
+          });
+        }
+      }
+    },
+  });
+
+  return uniqueFields;
+}
+
+/**
+ * Get the @unique fields on a type
+ * @param options
+ */
+function getUniqueFieldsOnType({
+  typeName,
+  uniqueFields,
+}: {
+  typeName: string;
+  uniqueFields: UniqueField[];
+}): UniqueField[] {
+  return uniqueFields.filter(uniqueField => uniqueField.typeName === typeName);
+}
+
+type UniqueField = {
+  // Type where this field is found
+  typeName: string;
+  // Name of the field
+  field: string;
+};
+
+export { extractUniqueFields, getUniqueFieldsOnType, UniqueField };
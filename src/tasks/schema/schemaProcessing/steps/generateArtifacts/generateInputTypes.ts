@@ -11,13 +11,22 @@ import {
 } from "graphql";
 import { createInputTypes } from "./types/createInputTypes";
 
-function generateInputTypes({ ast, schema, newInputTypes, edges }) {
-  // Create our global filter types
+function generateInputTypes({
+  ast,
+  schema,
+  newInputTypes,
+  edges,
+  uniqueFields,
+}) {
+  // Create our global filter types. Every comparison on a field must pass, see
+  // lambdas/connectionPlural/item/filter.go for how each is applied
   newInputTypes["BooleanFilterInput"] = new GraphQLInputObjectType({
     name: "BooleanFilterInput",
     fields: {
       notEqualTo: { type: GraphQLBoolean },
       equalTo: { type: GraphQLBoolean },
+      isNull: { type: GraphQLBoolean },
+      exists: { type: GraphQLBoolean },
     },
   });
 
@@ -34,6 +43,13 @@ function generateInputTypes({ ast, schema, newInputTypes, edges }) {
       notContains: { type: GraphQLString },
       beginsWith: { type: GraphQLString },
       endsWith: { type: GraphQLString },
+      matches: { type: GraphQLString },
+      between: { type: new GraphQLList(GraphQLString) },
+      in: { type: new GraphQLList(GraphQLString) },
+      notIn: { type: new GraphQLList(GraphQLString) },
+      isNull: { type: GraphQLBoolean },
+      exists: { type: GraphQLBoolean },
+      caseInsensitive: { type: GraphQLBoolean },
     },
   });
 
@@ -49,6 +65,10 @@ function generateInputTypes({ ast, schema, newInputTypes, edges }) {
       contains: { type: GraphQLInt },
       notContains: { type: GraphQLInt },
       between: { type: new GraphQLList(GraphQLInt) },
+      in: { type: new GraphQLList(GraphQLInt) },
+      notIn: { type: new GraphQLList(GraphQLInt) },
+      isNull: { type: GraphQLBoolean },
+      exists: { type: GraphQLBoolean },
     },
   });
 
@@ -64,6 +84,10 @@ function generateInputTypes({ ast, schema, newInputTypes, edges }) {
       contains: { type: GraphQLFloat },
       notContains: { type: GraphQLFloat },
       between: { type: new GraphQLList(GraphQLFloat) },
+      in: { type: new GraphQLList(GraphQLFloat) },
+      notIn: { type: new GraphQLList(GraphQLFloat) },
+      isNull: { type: GraphQLBoolean },
+      exists: { type: GraphQLBoolean },
     },
   });
 
@@ -80,6 +104,27 @@ function generateInputTypes({ ast, schema, newInputTypes, edges }) {
     },
   });
 
+  // Guards on the value a field will have after an increment
+  newInputTypes["IntGuardInput"] = new GraphQLInputObjectType({
+    name: "IntGuardInput",
+    fields: {
+      lessThanOrEqualTo: { type: GraphQLInt },
+      lessThan: { type: GraphQLInt },
+      greaterThanOrEqualTo: { type: GraphQLInt },
+      greaterThan: { type: GraphQLInt },
+    },
+  });
+
+  newInputTypes["FloatGuardInput"] = new GraphQLInputObjectType({
+    name: "FloatGuardInput",
+    fields: {
+      lessThanOrEqualTo: { type: GraphQLFloat },
+      lessThan: { type: GraphQLFloat },
+      greaterThanOrEqualTo: { type: GraphQLFloat },
+      greaterThan: { type: GraphQLFloat },
+    },
+  });
+
   visit(ast, {
     leave: (node: any) => {
       if (node.kind === "ObjectTypeDefinition") {
@@ -105,6 +150,7 @@ function generateInputTypes({ ast, schema, newInputTypes, edges }) {
                   type,
                   newInputTypes,
                   edges,
+                  uniqueFields,
                 });
               }
             }
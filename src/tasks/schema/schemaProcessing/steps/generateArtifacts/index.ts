@@ -7,6 +7,12 @@ import {
   GraphQLObjectType,
   GraphQLInputObjectType,
   GraphQLInt,
+  GraphQLID,
+  GraphQLList,
+  GraphQLNonNull,
+  GraphQLString,
+  GraphQLBoolean,
+  GraphQLEnumType,
 } from "graphql";
 import { directives } from "../../../util/directives";
 import { printDirectives } from "../../../util/printer";
@@ -26,6 +32,7 @@ import { Config } from "../../../../common/types";
 import { mergeAndDeDupeAst } from "../../../util/ast";
 
 import { extractEdges } from "./extractEdges";
+import { extractUniqueFields } from "./extractUniqueFields";
 import { createEdgeTypes } from "./types/createEdgeTypes";
 
 /**
@@ -60,6 +67,15 @@ async function generateArtifacts({
   // Not used until AppSync supports cusotm scalars
   // let scalarTypeMap: any[] = [];
 
+  const updateManyFailure = new GraphQLObjectType({
+    name: `UpdateManyFailure`,
+    description: `A node that could not be updated, and why`,
+    fields: () => ({
+      id: { type: GraphQLID },
+      error: { type: GraphQLString },
+    }),
+  });
+
   // Input Types tobe used throughout the schema
   const newInputTypes = {
     BatchPayload: new GraphQLObjectType({
@@ -69,11 +85,83 @@ async function generateArtifacts({
         count: { type: GraphQLInt },
       }),
     }),
+    UpdateManyFailure: updateManyFailure,
+    UpdateManyPayload: new GraphQLObjectType({
+      name: `UpdateManyPayload`,
+      description: `Nodes updated by an updateMany mutation`,
+      fields: () => ({
+        count: { type: GraphQLInt },
+        updated: { type: new GraphQLList(GraphQLID) },
+        failed: { type: new GraphQLList(updateManyFailure) },
+      }),
+    }),
+    DeletePayload: new GraphQLObjectType({
+      name: `DeletePayload`,
+      description: `Number of nodes and edges removed by a delete, a continuation when it stopped before finishing, and the ids a dryRun would delete`,
+      fields: () => ({
+        count: { type: GraphQLInt },
+        nodes: { type: GraphQLInt },
+        edges: { type: GraphQLInt },
+        continuation: { type: GraphQLString },
+        ids: { type: new GraphQLList(GraphQLID) },
+      }),
+    }),
+    RestorePayload: new GraphQLObjectType({
+      name: `RestorePayload`,
+      description: `Number of nodes and edges restored, and edges left deleted`,
+      fields: () => ({
+        count: { type: GraphQLInt },
+        nodes: { type: GraphQLInt },
+        edges: { type: GraphQLInt },
+        skipped: { type: GraphQLInt },
+      }),
+    }),
+    DeleteConnection: new GraphQLInputObjectType({
+      name: `DeleteConnection`,
+      description: `Select the nodes connected to the node with id, through field`,
+      fields: () => ({
+        field: { type: new GraphQLNonNull(GraphQLString) },
+        id: { type: new GraphQLNonNull(GraphQLID) },
+      }),
+    }),
+    DeleteMode: new GraphQLEnumType({
+      name: `DeleteMode`,
+      description: `SOFT keeps deleted nodes until their timeToLive, HARD removes them`,
+      values: {
+        SOFT: { value: "SOFT" },
+        HARD: { value: "HARD" },
+      },
+    }),
+    PageInfo: new GraphQLObjectType({
+      name: `PageInfo`,
+      description: `Whether there are more nodes before or after a page of a Relay connection, and the cursors it starts and ends at`,
+      fields: () => ({
+        hasPreviousPage: { type: new GraphQLNonNull(GraphQLBoolean) },
+        hasNextPage: { type: new GraphQLNonNull(GraphQLBoolean) },
+        startCursor: { type: GraphQLString },
+        endCursor: { type: GraphQLString },
+      }),
+    }),
+    OrderDirection: new GraphQLEnumType({
+      name: `OrderDirection`,
+      description: `ASC sorts the smallest value first, DESC the largest`,
+      values: {
+        ASC: { value: "ASC" },
+        DESC: { value: "DESC" },
+      },
+    }),
+    NullsOrder: new GraphQLEnumType({
+      name: `NullsOrder`,
+      description: `Where nodes without a value sort, by default nulls are the largest value`,
+      values: {
+        FIRST: { value: "FIRST" },
+        LAST: { value: "LAST" },
+      },
+    }),
     DeleteAttributes: new GraphQLInputObjectType({
       name: `DeleteAttributes`,
       description: `Attributes to set on the deleted node`,
       fields: () => ({
-        // cascade: { type: GraphQLBoolean }, // this is cool, but not sure how to implement yet
         timeToLive: { type: GraphQLInt },
       }),
     }),
@@ -112,6 +200,13 @@ async function generateArtifacts({
     schema,
   });
 
+  // [ Extract Unique Fields ]--------------------------------------------------------------------
+  observer.next("Extracting unique fields from Schema");
+  const uniqueFields = extractUniqueFields({
+    ast,
+    schema,
+  });
+
   // [ Create Input Types ]-----------------------------------------------------------------------
   observer.next("Creating Input types");
   generateInputTypes({
@@ -119,6 +214,7 @@ async function generateArtifacts({
     schema,
     newInputTypes,
     edges,
+    uniqueFields,
   });
 
   // [ Create New Types ]-------------------------------------------------------------------------
@@ -202,6 +298,7 @@ mutation: Mutation
     types: newTypeFields,
     typeDefs: strippedTypeDefs,
     edges,
+    uniqueFields,
     config,
   });
 
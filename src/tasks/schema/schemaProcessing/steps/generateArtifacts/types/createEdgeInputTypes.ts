@@ -4,6 +4,7 @@ import {
   getNamedType,
   GraphQLInputObjectType,
   GraphQLType,
+  GraphQLID,
 } from "graphql";
 
 import { Edge } from "../extractEdges";
@@ -62,9 +63,10 @@ function createEdgeInputTypes({
                 hideField: edge.field,
                 edges,
               });
-              // We need to remove the ID field from create type, as it's autogenerated
+              // The ID is autogenerated, unless the client passes one
               delete fields.id;
               return {
+                id: { type: GraphQLID },
                 ...fields,
               };
             },
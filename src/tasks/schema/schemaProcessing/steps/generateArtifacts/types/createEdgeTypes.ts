@@ -1,7 +1,8 @@
-import { visit, DocumentNode } from "graphql";
+import { visit, DocumentNode, ObjectTypeDefinitionNode } from "graphql";
 import * as pluralize from "pluralize";
 
 import { Edge, EdgeCardinality } from "../extractEdges";
+import { usesRelayConnections } from "./createTypes";
 /**
  * Create all the input types for a Type
  * and store them on the newInputTypes object
@@ -31,6 +32,22 @@ function createEdgeTypes({
               let returnType = field.type;
 
               if (field.name.value === edge.field) {
+                // Deleted nodes are hidden, unless this is true
+                args.push({
+                  kind: "InputValueDefinition",
+                  name: {
+                    kind: "Name",
+                    value: "includeDeleted",
+                  },
+                  type: {
+                    kind: "NamedType",
+                    name: {
+                      kind: "Name",
+                      value: "Boolean",
+                    },
+                  },
+                });
+
                 if (edge.cardinality === EdgeCardinality.ONE) {
                   args.push({
                     kind: "InputValueDefinition",
@@ -89,30 +106,50 @@ function createEdgeTypes({
                     kind: "InputValueDefinition",
                     name: {
                       kind: "Name",
-                      value: "cursor",
+                      value: "orderBy",
                     },
                     type: {
-                      kind: "NamedType",
-                      name: {
-                        kind: "Name",
-                        value: "String",
+                      kind: "ListType",
+                      type: {
+                        kind: "NonNullType",
+                        type: {
+                          kind: "NamedType",
+                          name: {
+                            kind: "Name",
+                            value: `${edge.fieldType}OrderBy`,
+                          },
+                        },
                       },
                     },
                   });
 
-                  args.push({
-                    kind: "InputValueDefinition",
-                    name: {
-                      kind: "Name",
-                      value: "limit",
-                    },
-                    type: {
-                      kind: "NamedType",
+                  // Connections to a type with @node(relay: true) page with
+                  // first, after, last and before, others with cursor and limit
+                  const fieldTypeNode = schemaDocument.definitions.find(
+                    (definition: any) =>
+                      definition.kind === "ObjectTypeDefinition" &&
+                      definition.name.value === edge.fieldType,
+                  ) as ObjectTypeDefinitionNode;
+                  const pageArgs =
+                    fieldTypeNode && usesRelayConnections({ node: fieldTypeNode })
+                      ? { first: "Int", after: "String", last: "Int", before: "String" }
+                      : { cursor: "String", limit: "Int" };
+
+                  Object.keys(pageArgs).forEach(pageArg => {
+                    args.push({
+                      kind: "InputValueDefinition",
                       name: {
                         kind: "Name",
-                        value: "Int",
+                        value: pageArg,
                       },
-                    },
+                      type: {
+                        kind: "NamedType",
+                        name: {
+                          kind: "Name",
+                          value: pageArgs[pageArg],
+                        },
+                      },
+                    });
                   });
 
                   returnType = {
@@ -5,11 +5,21 @@ import {
   GraphQLID,
   GraphQLNonNull,
   GraphQLType,
+  GraphQLObjectType,
+  GraphQLEnumType,
+  GraphQLInt,
+  GraphQLFloat,
+  getNamedType,
+  getNullableType,
+  isListType,
+  isScalarType,
+  isEnumType,
 } from "graphql";
 
 import { getFieldsForInputType, mutationType } from "./getFieldsForInputType";
 import { getFieldsForFilterInputType } from "./getFieldsForFilterInputType";
 import { Edge } from "../extractEdges";
+import { UniqueField, getUniqueFieldsOnType } from "../extractUniqueFields";
 /**
  * Create all the input types for a Type
  * and store them on the newInputTypes object
@@ -20,11 +30,13 @@ function createInputTypes({
   type,
   newInputTypes,
   edges,
+  uniqueFields,
 }: {
   node: ObjectTypeDefinitionNode;
   type: GraphQLType;
   newInputTypes: any;
   edges: Edge[];
+  uniqueFields: UniqueField[];
 }) {
   // [ data ]---------------------------------------------------------------------------------------
   const dataInputType: GraphQLInputObjectType = new GraphQLInputObjectType({
@@ -38,17 +50,38 @@ function createInputTypes({
         edges,
       });
 
-      // We need to remove the ID field from data type, as it's autogenerated, or provided
-      // by the where type
+      // The ID is autogenerated, unless the client passes one. It must not already exist.
       delete fields.id;
 
       return {
+        id: { type: GraphQLID },
         ...fields,
       };
     },
   });
   newInputTypes[`${node.name.value}Data`] = dataInputType;
 
+  const updateDataInputType: GraphQLInputObjectType = new GraphQLInputObjectType({
+    name: `${node.name.value}UpdateData`,
+    fields: () => {
+      const fields = getFieldsForInputType({
+        type,
+        node,
+        newInputTypes,
+        mutation: mutationType.UPDATE,
+        edges,
+      });
+
+      // The node to update is selected by the where type
+      delete fields.id;
+
+      return {
+        ...fields,
+      };
+    },
+  });
+  newInputTypes[`${node.name.value}UpdateData`] = updateDataInputType;
+
   // [ where ]--------------------------------------------------------------------------------------
   const whereUniqueType: GraphQLInputObjectType = new GraphQLInputObjectType({
     name: `${node.name.value}WhereUnique`,
@@ -66,6 +99,125 @@ function createInputTypes({
   });
   newInputTypes[`${node.name.value}Where`] = whereType;
 
+  const deleteWhereType: GraphQLInputObjectType = new GraphQLInputObjectType({
+    name: `${node.name.value}DeleteWhere`,
+    fields: () => ({
+      ids: { type: new GraphQLList(GraphQLID) },
+      connection: { type: newInputTypes["DeleteConnection"] },
+    }),
+  });
+  newInputTypes[`${node.name.value}DeleteWhere`] = deleteWhereType;
+
+  // [ upsert ]-------------------------------------------------------------------------------------
+  // A node can be upserted by any single @unique field, which is held with a unique guard item
+  const uniqueFieldsOnType = getUniqueFieldsOnType({
+    typeName: node.name.value,
+    uniqueFields,
+  });
+  if (uniqueFieldsOnType.length > 0) {
+    const upsertWhereType: GraphQLInputObjectType = new GraphQLInputObjectType({
+      name: `${node.name.value}UpsertWhere`,
+      fields: () => {
+        const typeFields = (type as GraphQLObjectType).getFields();
+        const fields = {};
+
+        uniqueFieldsOnType.forEach(uniqueField => {
+          const fieldType = typeFields[uniqueField.field].type;
+          const namedFieldType = getNamedType(fieldType);
+
+          if (
+            !isListType(getNullableType(fieldType)) &&
+            (isScalarType(namedFieldType) || isEnumType(namedFieldType))
+          ) {
+            fields[uniqueField.field] = { type: namedFieldType };
+          }
+        });
+
+        return fields;
+      },
+    });
+    newInputTypes[`${node.name.value}UpsertWhere`] = upsertWhereType;
+  }
+
+  // [ increment ]----------------------------------------------------------------------------------
+  // Int and Float fields can be incremented atomically, with guards on the value after the update
+  const typeFields = (type as GraphQLObjectType).getFields();
+  const numericFields = Object.keys(typeFields).filter(typeFieldKey => {
+    const fieldType = typeFields[typeFieldKey].type;
+    const namedFieldType = getNamedType(fieldType);
+
+    return (
+      typeFieldKey !== "version" &&
+      !isListType(getNullableType(fieldType)) &&
+      (namedFieldType === GraphQLInt || namedFieldType === GraphQLFloat) &&
+      !uniqueFieldsOnType.find(uniqueField => uniqueField.field === typeFieldKey)
+    );
+  });
+  if (numericFields.length > 0) {
+    const incrementType: GraphQLInputObjectType = new GraphQLInputObjectType({
+      name: `${node.name.value}Increment`,
+      fields: () => {
+        const fields = {};
+        numericFields.forEach(numericField => {
+          fields[numericField] = {
+            type: getNamedType(typeFields[numericField].type),
+          };
+        });
+        return fields;
+      },
+    });
+    newInputTypes[`${node.name.value}Increment`] = incrementType;
+
+    const incrementGuardType: GraphQLInputObjectType = new GraphQLInputObjectType({
+      name: `${node.name.value}IncrementGuard`,
+      fields: () => {
+        const fields = {};
+        numericFields.forEach(numericField => {
+          fields[numericField] = {
+            type:
+              getNamedType(typeFields[numericField].type) === GraphQLInt
+                ? newInputTypes["IntGuardInput"]
+                : newInputTypes["FloatGuardInput"],
+          };
+        });
+        return fields;
+      },
+    });
+    newInputTypes[`${node.name.value}IncrementGuard`] = incrementGuardType;
+  }
+
+  // [ orderBy ]----------------------------------------------------------------------------------
+  // Connections can be sorted by any field that holds a single scalar or enum value,
+  // see lambdas/connectionPlural/item/orderBy.go for how values are compared
+  const sortableFields = Object.keys(typeFields).filter(typeFieldKey => {
+    const fieldType = typeFields[typeFieldKey].type;
+    const namedFieldType = getNamedType(fieldType);
+
+    return (
+      !isListType(getNullableType(fieldType)) &&
+      (isScalarType(namedFieldType) || isEnumType(namedFieldType))
+    );
+  });
+
+  const orderFieldType: GraphQLEnumType = new GraphQLEnumType({
+    name: `${node.name.value}OrderField`,
+    values: sortableFields.reduce((values, sortableField) => {
+      values[sortableField] = { value: sortableField };
+      return values;
+    }, {}),
+  });
+  newInputTypes[`${node.name.value}OrderField`] = orderFieldType;
+
+  const orderByType: GraphQLInputObjectType = new GraphQLInputObjectType({
+    name: `${node.name.value}OrderBy`,
+    fields: () => ({
+      field: { type: new GraphQLNonNull(orderFieldType) },
+      direction: { type: newInputTypes["OrderDirection"] },
+      nulls: { type: newInputTypes["NullsOrder"] },
+    }),
+  });
+  newInputTypes[`${node.name.value}OrderBy`] = orderByType;
+
   // [ filter ]-------------------------------------------------------------------------------------
   // This doesn't work yet :(
   // Maybe try add the edge id's to another indexed field on the node
@@ -86,6 +238,9 @@ function createInputTypes({
 
       return {
         ...fields,
+        AND: { type: new GraphQLList(newInputTypes[`${node.name.value}Filter`]) },
+        OR: { type: new GraphQLList(newInputTypes[`${node.name.value}Filter`]) },
+        NOT: { type: newInputTypes[`${node.name.value}Filter`] },
       };
     },
   });
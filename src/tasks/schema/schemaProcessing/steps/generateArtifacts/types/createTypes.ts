@@ -4,8 +4,11 @@ import {
   GraphQLString,
   GraphQLList,
   GraphQLInt,
+  GraphQLBoolean,
   GraphQLNonNull,
   GraphQLType,
+  StringValueNode,
+  BooleanValueNode,
 } from "graphql";
 import * as pluralize from "pluralize";
 import { Edge } from "../extractEdges";
@@ -13,9 +16,11 @@ import { Edge } from "../extractEdges";
 /**
  * Add the following Mutations
  * createType(data: CreateTypeInput)
- * updateType(data: UpdateTypeInput)
+ * upsertType(where: UpsertTypeWhere, create: TypeData, update: TypeUpdateData)
+ * updateType(data: UpdateTypeInput, expectedVersion: Int, increment: TypeIncrement, guard: TypeIncrementGuard)
  * deleteType(where: DeleteTypeWhereInput)
  * deleteManyType(data: DeleteTypeWhereManyInput)
+ * restoreType(where: TypeWhereUniqueInput)
  *
  * Add add the following input types:
  * input CreateTypeInput {
@@ -57,6 +62,7 @@ function createTypes({
           newInputTypes[`${node.name.value}WhereUnique`],
         ),
       },
+      includeDeleted: { type: GraphQLBoolean },
     },
   };
   newTypeDataSourceMap.query[node.name.value] = {
@@ -76,6 +82,7 @@ function createTypes({
       },
       cursor: { type: GraphQLString },
       limit: { type: GraphQLInt },
+      includeDeleted: { type: GraphQLBoolean },
     },
   };
   newTypeDataSourceMap.query[`${pluralize.plural(node.name.value)}`] = {
@@ -86,13 +93,32 @@ function createTypes({
   };
 
   // [ query Connection ]---------------------------------------------------------------------------
+  // Types with @node(relay: true) use the Relay Cursor Connections shape
+  const relay = usesRelayConnections({ node });
+  let nodeEdgeType;
+  if (relay) {
+    nodeEdgeType = new GraphQLObjectType({
+      name: `${node.name.value}Edge`,
+      fields: () => ({
+        cursor: { type: new GraphQLNonNull(GraphQLString) },
+        node: { type: type as GraphQLObjectType },
+      }),
+    });
+  }
+
   newTypeFields.query[`${node.name.value}Connection`] = {
     name: `${node.name.value}}Connection`,
     type: new GraphQLObjectType({
       name: `${node.name.value}Connection`,
-      fields: () => ({
-        edge: { type: type as GraphQLObjectType },
-      }),
+      fields: () =>
+        relay
+          ? {
+              edges: { type: new GraphQLList(nodeEdgeType) },
+              pageInfo: { type: new GraphQLNonNull(newInputTypes["PageInfo"]) },
+            }
+          : {
+              edge: { type: type as GraphQLObjectType },
+            },
     }),
     args: {
       where: {
@@ -103,6 +129,7 @@ function createTypes({
       filter: {
         type: newInputTypes[`${node.name.value}Filter`],
       },
+      includeDeleted: { type: GraphQLBoolean },
     },
   };
   newTypeDataSourceMap.query[`${node.name.value}Connection`] = {
@@ -110,16 +137,27 @@ function createTypes({
     name: `${node.name.value}Connection`,
     field: `${node.name.value}Connection`,
     resolverType: "connection",
+    relay,
   };
 
   newTypeFields.query[`${pluralize.plural(node.name.value)}Connection`] = {
     name: `${pluralize.plural(node.name.value)}Connection`,
     type: new GraphQLObjectType({
       name: `${pluralize.plural(node.name.value)}Connection`,
-      fields: () => ({
-        edges: { type: new GraphQLList(type as GraphQLObjectType) },
-        cursor: { type: GraphQLString },
-      }),
+      fields: () =>
+        relay
+          ? {
+              edges: { type: new GraphQLList(nodeEdgeType) },
+              pageInfo: { type: new GraphQLNonNull(newInputTypes["PageInfo"]) },
+              count: { type: GraphQLInt },
+              scannedCount: { type: GraphQLInt },
+            }
+          : {
+              edges: { type: new GraphQLList(type as GraphQLObjectType) },
+              cursor: { type: GraphQLString },
+              count: { type: GraphQLInt },
+              scannedCount: { type: GraphQLInt },
+            },
     }),
     args: {
       where: {
@@ -127,11 +165,26 @@ function createTypes({
           newInputTypes[`${node.name.value}WhereUnique`],
         ),
       },
-      cursor: { type: GraphQLString },
-      limit: { type: GraphQLInt },
+      ...(relay
+        ? {
+            first: { type: GraphQLInt },
+            after: { type: GraphQLString },
+            last: { type: GraphQLInt },
+            before: { type: GraphQLString },
+          }
+        : {
+            cursor: { type: GraphQLString },
+            limit: { type: GraphQLInt },
+          }),
       filter: {
         type: newInputTypes[`${node.name.value}Filter`],
       },
+      orderBy: {
+        type: new GraphQLList(
+          new GraphQLNonNull(newInputTypes[`${node.name.value}OrderBy`]),
+        ),
+      },
+      includeDeleted: { type: GraphQLBoolean },
     },
   };
   newTypeDataSourceMap.query[
@@ -141,6 +194,32 @@ function createTypes({
     name: `${pluralize.plural(node.name.value)}Connection`,
     field: `${pluralize.plural(node.name.value)}Connection`,
     resolverType: "connectionPlural",
+    relay,
+  };
+
+  // [ query trash ]-------------------------------------------------------------------------------
+  newTypeFields.query[`trash${pluralize.plural(node.name.value)}`] = {
+    name: `trash${pluralize.plural(node.name.value)}`,
+    type: new GraphQLObjectType({
+      name: `${pluralize.plural(node.name.value)}Trash`,
+      description: `Deleted ${pluralize.plural(
+        node.name.value,
+      )} that can still be restored`,
+      fields: () => ({
+        edges: { type: new GraphQLList(type as GraphQLObjectType) },
+        cursor: { type: GraphQLString },
+      }),
+    }),
+    args: {
+      cursor: { type: GraphQLString },
+      limit: { type: GraphQLInt },
+    },
+  };
+  newTypeDataSourceMap.query[`trash${pluralize.plural(node.name.value)}`] = {
+    typeName: "Query",
+    name: node.name.value,
+    field: `trash${pluralize.plural(node.name.value)}`,
+    resolverType: "trash",
   };
 
   // [ create ]-------------------------------------------------------------------------------------
@@ -151,6 +230,7 @@ function createTypes({
       data: {
         type: new GraphQLNonNull(newInputTypes[`${node.name.value}Data`]),
       },
+      idempotencyKey: { type: GraphQLString },
     },
   };
   newTypeDataSourceMap.mutation[`create${node.name.value}`] = {
@@ -158,19 +238,62 @@ function createTypes({
     resolverType: "create",
   };
 
+  // [ upsert ]-------------------------------------------------------------------------------------
+  // Only types with a @unique field can be upserted, the UpsertWhere input
+  // is not created for the others
+  if (newInputTypes[`${node.name.value}UpsertWhere`]) {
+    newTypeFields.mutation[`upsert${node.name.value}`] = {
+      name: `upsert${node.name.value}`,
+      type: type,
+      args: {
+        where: {
+          type: new GraphQLNonNull(newInputTypes[`${node.name.value}UpsertWhere`]),
+        },
+        create: {
+          type: new GraphQLNonNull(newInputTypes[`${node.name.value}Data`]),
+        },
+        update: {
+          type: new GraphQLNonNull(newInputTypes[`${node.name.value}UpdateData`]),
+        },
+      },
+    };
+    newTypeDataSourceMap.mutation[`upsert${node.name.value}`] = {
+      name: node.name.value,
+      resolverType: "upsert",
+    };
+  }
+
   // [ update ]-------------------------------------------------------------------------------------
+  // data can be left out of an update that only increments, the lambda
+  // checks one of them is passed
+  const hasIncrement = !!newInputTypes[`${node.name.value}Increment`];
   newTypeFields.mutation[`update${node.name.value}`] = {
     name: `update${node.name.value}`,
     type: type,
     args: {
       data: {
-        type: new GraphQLNonNull(newInputTypes[`${node.name.value}Data`]),
+        type: hasIncrement
+          ? newInputTypes[`${node.name.value}UpdateData`]
+          : new GraphQLNonNull(newInputTypes[`${node.name.value}UpdateData`]),
       },
       where: {
         type: new GraphQLNonNull(
           newInputTypes[`${node.name.value}WhereUnique`],
         ),
       },
+      expectedVersion: {
+        type: GraphQLInt,
+      },
+      ...(hasIncrement
+        ? {
+            increment: {
+              type: newInputTypes[`${node.name.value}Increment`],
+            },
+            guard: {
+              type: newInputTypes[`${node.name.value}IncrementGuard`],
+            },
+          }
+        : {}),
     },
   };
   newTypeDataSourceMap.mutation[`update${node.name.value}`] = {
@@ -181,15 +304,16 @@ function createTypes({
   // [ updateMany ]---------------------------------------------------------------------------------
   newTypeFields.mutation[`updateMany${pluralize.plural(node.name.value)}`] = {
     name: `updateMany${pluralize.plural(node.name.value)}`,
-    type: newInputTypes["BatchPayload"],
+    type: newInputTypes["UpdateManyPayload"],
     args: {
       data: {
-        type: new GraphQLNonNull(
-          new GraphQLList(newInputTypes[`${node.name.value}Data`]),
-        ),
+        type: new GraphQLNonNull(newInputTypes[`${node.name.value}Data`]),
       },
       where: {
-        type: new GraphQLNonNull(newInputTypes[`${node.name.value}Where`]),
+        type: newInputTypes[`${node.name.value}Where`],
+      },
+      filter: {
+        type: newInputTypes[`${node.name.value}Filter`],
       },
     },
   };
@@ -200,7 +324,7 @@ function createTypes({
   // [ delete ]-------------------------------------------------------------------------------------
   newTypeFields.mutation[`delete${node.name.value}`] = {
     name: `delete${node.name.value}`,
-    type: newInputTypes["BatchPayload"],
+    type: newInputTypes["DeletePayload"],
     args: {
       where: {
         type: new GraphQLNonNull(
@@ -210,29 +334,128 @@ function createTypes({
       set: {
         type: newInputTypes["DeleteAttributes"],
       },
+      mode: {
+        type: newInputTypes["DeleteMode"],
+      },
+      continuation: {
+        type: GraphQLString,
+      },
     },
   };
   newTypeDataSourceMap.mutation[`delete${node.name.value}`] = {
     name: node.name.value,
     resolverType: "delete",
+    deleteMode: getDeleteMode({ node }),
   };
 
   // [ deleteMany ]---------------------------------------------------------------------------------
   newTypeFields.mutation[`deleteMany${pluralize.plural(node.name.value)}`] = {
     name: `deleteMany${pluralize.plural(node.name.value)}`,
-    type: newInputTypes["BatchPayload"],
+    type: newInputTypes["DeletePayload"],
     args: {
       where: {
-        type: new GraphQLNonNull(newInputTypes[`${node.name.value}Where`]),
+        type: newInputTypes[`${node.name.value}DeleteWhere`],
+      },
+      filter: {
+        type: newInputTypes[`${node.name.value}Filter`],
+      },
+      dryRun: {
+        type: GraphQLBoolean,
       },
       set: {
         type: newInputTypes["DeleteAttributes"],
       },
+      mode: {
+        type: newInputTypes["DeleteMode"],
+      },
+      continuation: {
+        type: GraphQLString,
+      },
     },
   };
   newTypeDataSourceMap.mutation[
     `deleteMany${pluralize.plural(node.name.value)}`
-  ] = { name: node.name.value, resolverType: "deleteMany" };
+  ] = {
+    name: node.name.value,
+    resolverType: "deleteMany",
+    deleteMode: getDeleteMode({ node }),
+  };
+
+  // [ restore ]------------------------------------------------------------------------------------
+  newTypeFields.mutation[`restore${node.name.value}`] = {
+    name: `restore${node.name.value}`,
+    type: newInputTypes["RestorePayload"],
+    args: {
+      where: {
+        type: new GraphQLNonNull(
+          newInputTypes[`${node.name.value}WhereUnique`],
+        ),
+      },
+    },
+  };
+  newTypeDataSourceMap.mutation[`restore${node.name.value}`] = {
+    name: node.name.value,
+    resolverType: "restore",
+  };
+}
+
+enum DeleteMode {
+  // Set a ttl, so the node can be restored until it passes
+  SOFT = "SOFT",
+  // Remove the node and its edges straight away
+  HARD = "HARD",
+}
+
+/**
+ * Get the deleteMode argument of the @node directive, SOFT when it is not set
+ * @param options
+ */
+function getDeleteMode({
+  node,
+}: {
+  node: ObjectTypeDefinitionNode;
+}): DeleteMode {
+  let deleteMode: string = DeleteMode.SOFT;
+  (node.directives || []).forEach(directive => {
+    if (directive.name.value === "node") {
+      (directive.arguments || []).forEach(argument => {
+        if (argument.name.value === "deleteMode") {
+          deleteMode = (argument.value as StringValueNode).value;
+        }
+      });
+    }
+  });
+
+  if (Object.keys(DeleteMode).indexOf(deleteMode) === -1) {
+    throw new Error(
+      `${node.name.value} has a deleteMode of ${deleteMode}, it must be one of ${Object.keys(
+        DeleteMode,
+      ).join(", ")}.`,
+    );
+  }
+  return DeleteMode[deleteMode];
+}
+
+/**
+ * Get the relay argument of the @node directive, false when it is not set
+ * @param options
+ */
+function usesRelayConnections({
+  node,
+}: {
+  node: ObjectTypeDefinitionNode;
+}): boolean {
+  let relay = false;
+  (node.directives || []).forEach(directive => {
+    if (directive.name.value === "node") {
+      (directive.arguments || []).forEach(argument => {
+        if (argument.name.value === "relay") {
+          relay = (argument.value as BooleanValueNode).value;
+        }
+      });
+    }
+  });
+  return relay;
 }
 
-export { createTypes };
+export { createTypes, DeleteMode, usesRelayConnections };
@@ -74,9 +74,10 @@ function getFieldsForInputType({
 
           foundEdge = true;
 
-          const newInnerFieldName: string = `${
-            edge.fieldType
-          }${mutation}Without${capitalizeFirstLetter(edge.counterpart.field)}`;
+          // Nested data always creates new nodes
+          const newInnerFieldName: string = `${edge.fieldType}${
+            mutationType.CREATE
+          }Without${capitalizeFirstLetter(edge.counterpart.field)}`;
           let newFieldName: string;
 
           if (edge.cardinality === EdgeCardinality.ONE) {
@@ -102,6 +103,13 @@ function getFieldsForInputType({
                   connection: {
                     type: GraphQLID,
                   },
+                  ...(mutation === mutationType.UPDATE
+                    ? {
+                        connect: { type: GraphQLID },
+                        disconnect: { type: GraphQLID },
+                        set: { type: GraphQLID },
+                      }
+                    : {}),
                 }),
                 description: typeFields[typeFieldKey].description,
               });
@@ -115,6 +123,13 @@ function getFieldsForInputType({
                   connection: {
                     type: new GraphQLList(GraphQLID),
                   },
+                  ...(mutation === mutationType.UPDATE
+                    ? {
+                        connect: { type: new GraphQLList(GraphQLID) },
+                        disconnect: { type: new GraphQLList(GraphQLID) },
+                        set: { type: new GraphQLList(GraphQLID) },
+                      }
+                    : {}),
                 }),
                 description: typeFields[typeFieldKey].description,
               });
@@ -123,9 +138,11 @@ function getFieldsForInputType({
 
           fields[typeFieldKey] = {
             name: typeFieldKey,
-            type: edge.required
-              ? new GraphQLNonNull(newInputTypes[newFieldName])
-              : newInputTypes[newFieldName],
+            // An update only needs the edges that are changing
+            type:
+              edge.required && mutation === mutationType.CREATE
+                ? new GraphQLNonNull(newInputTypes[newFieldName])
+                : newInputTypes[newFieldName],
             description: typeFields[typeFieldKey].description,
             directives: subTypeAstDirectives,
           };
@@ -138,7 +155,8 @@ function getFieldsForInputType({
       if (
         typeFields[typeFieldKey].name === "createdBy" ||
         typeFields[typeFieldKey].name === "createdAt" ||
-        typeFields[typeFieldKey].name === "updatedAt"
+        typeFields[typeFieldKey].name === "updatedAt" ||
+        typeFields[typeFieldKey].name === "version"
       ) {
         // createdAt and updatedAt are added by the resolvers
         // So they're hidden field
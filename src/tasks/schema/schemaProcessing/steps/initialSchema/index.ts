@@ -18,6 +18,7 @@ id: ID!
 createdAt: String!
 updatedAt: String!
 createdBy: ID!
+version: Int
 }
 `;
     // Merge it with the users typedefs
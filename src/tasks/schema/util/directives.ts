@@ -17,6 +17,16 @@ const directives: GraphQLDirective[] = [
             consistentRead: {
                 type: GraphQLBoolean,
             },
+            // How delete and deleteMany remove nodes of this type, when no
+            // mode is passed. SOFT (the default) or HARD
+            deleteMode: {
+                type: GraphQLString,
+            },
+            // Connections to this type use the Relay Cursor Connections
+            // shape, with first, after, last and before
+            relay: {
+                type: GraphQLBoolean,
+            },
         },
     }),
     new GraphQLDirective({
@@ -29,8 +39,18 @@ const directives: GraphQLDirective[] = [
             principal: {
                 type: GraphQLBoolean,
             },
+            // What happens to the connected nodes when this node is deleted
+            // CASCADE, DETACH (the default) or RESTRICT
+            onDelete: {
+                type: GraphQLString,
+            },
         },
     }),
+    // No two nodes of the type can have the same value on this field
+    new GraphQLDirective({
+        name: "unique",
+        locations: [DirectiveLocation.FIELD_DEFINITION],
+    }),
     // To be implemented when AppSync can do custom scalars
     // new GraphQLDirective({
     //     name: "scalarSerialise",